@@ -0,0 +1,45 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import "sync"
+
+// DocumentCache holds downloaded WSDL and XSD documents by URL. A nil cache
+// keeps nothing.
+type DocumentCache struct {
+	mu        sync.Mutex
+	documents map[string]cachedDocument
+}
+
+// cachedDocument is a downloaded document and the URL it was downloaded
+// from after redirects.
+type cachedDocument struct {
+	data     []byte
+	finalURL string
+}
+
+// NewDocumentCache creates an empty cache.
+func NewDocumentCache() *DocumentCache {
+	return &DocumentCache{documents: map[string]cachedDocument{}}
+}
+
+func (c *DocumentCache) get(url string) (data []byte, finalURL string) {
+	if c == nil {
+		return nil, ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	document := c.documents[url]
+	return document.data, document.finalURL
+}
+
+func (c *DocumentCache) put(url string, data []byte, finalURL string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.documents[url] = cachedDocument{data: data, finalURL: finalURL}
+}
@@ -0,0 +1,245 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hooklift/gowsdl"
+	"gopkg.in/yaml.v3"
+)
+
+// config lists the services generated by a single run with -config:
+//
+//	services:
+//	  - wsdl: billing.wsdl
+//	    package: billing
+//	    dir: ./gen
+//	    options:
+//	      getters: true
+//	      operations: [GetInvoice]
+//	      environment:
+//	        prod: https://billing.example.com/ws
+//	        test: https://billing.test.example.com/ws
+//
+// Relative paths are resolved against the directory of the config file.
+type config struct {
+	Services []serviceConfig `yaml:"services"`
+}
+
+// serviceConfig describes the generation of one WSDL.
+type serviceConfig struct {
+	WSDL    string         `yaml:"wsdl"`
+	Package string         `yaml:"package"`
+	Dir     string         `yaml:"dir"`
+	Options serviceOptions `yaml:"options"`
+}
+
+// serviceOptions are the command line options, keyed by flag name.
+type serviceOptions struct {
+	FilePrefix           string            `yaml:"l"`
+	Insecure             bool              `yaml:"i"`
+	MakePublic           *bool             `yaml:"make-public"`
+	HoistNamespaces      bool              `yaml:"hoist-ns"`
+	PrefixTypes          bool              `yaml:"prefix-types"`
+	TypePrefixes         map[string]string `yaml:"type-prefix"`
+	NamespacePackages    map[string]string `yaml:"ns-package"`
+	ImportPath           string            `yaml:"import-path"`
+	SchemaDir            string            `yaml:"schema-dir"`
+	AuthUser             string            `yaml:"auth-user"`
+	AuthPass             string            `yaml:"auth-pass"`
+	Headers              map[string]string `yaml:"header"`
+	Getters              bool              `yaml:"getters"`
+	RequiredValues       bool              `yaml:"required-values"`
+	XSDBooleans          bool              `yaml:"xsd-booleans"`
+	XSDTokens            bool              `yaml:"xsd-tokens"`
+	ValidateOccurs       bool              `yaml:"validate-occurs"`
+	RequiredConstructors bool              `yaml:"required-constructors"`
+	ContextOnly          bool              `yaml:"context-only-interfaces"`
+	DeprecationKeyword   string            `yaml:"deprecation-keyword"`
+	GenericCalls         bool              `yaml:"generic-calls"`
+	FaultDetails         bool              `yaml:"fault-details"`
+	SecurityPolicies     bool              `yaml:"security-policies"`
+	ClientPackage        string            `yaml:"client-package"`
+	PlainTypes           bool              `yaml:"plain-types"`
+	Transliterate        bool              `yaml:"transliterate"`
+	RootPrefix           string            `yaml:"root-prefix"`
+	OperationPrefixes    map[string]string `yaml:"operation-prefix"`
+	StrictPrefixes       bool              `yaml:"strict-prefixes"`
+	NamespacePrefixes    map[string]string `yaml:"ns-prefix"`
+	FaultErrors          map[string]string `yaml:"fault-error"`
+	Environments         map[string]string `yaml:"environment"`
+	AsyncOperations      map[string]string `yaml:"async-operation"`
+	AllowUnsupported     bool              `yaml:"allow-unsupported"`
+	SkipUnresolved       bool              `yaml:"skip-unresolved"`
+	MaxDownloads         int               `yaml:"max-downloads"`
+	Operations           []string          `yaml:"operations"`
+	BuildTag             string            `yaml:"build-tag"`
+	NoLint               bool              `yaml:"nolint"`
+	FileSuffix           string            `yaml:"file-suffix"`
+	StructTags           []string          `yaml:"struct-tags"`
+	NoOmitEmpty          bool              `yaml:"no-omitempty"`
+	CDATA                []string          `yaml:"cdata"`
+	RoundTripTests       bool              `yaml:"roundtrip-tests"`
+	Overwrite            *bool             `yaml:"overwrite"`
+	Backup               bool              `yaml:"backup"`
+}
+
+// loadConfig reads and validates the config file.
+func loadConfig(path string) (ret *config, err error) {
+	var data []byte
+	if data, err = os.ReadFile(path); err != nil {
+		return
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	ret = &config{}
+	if err = decoder.Decode(ret); err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+	if len(ret.Services) == 0 {
+		return nil, fmt.Errorf("%v: no services configured", path)
+	}
+
+	base := filepath.Dir(path)
+	for i := range ret.Services {
+		service := &ret.Services[i]
+		if service.WSDL == "" {
+			return nil, fmt.Errorf("%v: service %d: wsdl is required", path, i+1)
+		}
+		if service.Package == "" {
+			return nil, fmt.Errorf("%v: service %d (%v): package is required", path, i+1, service.WSDL)
+		}
+		if service.Dir == "" {
+			service.Dir = "./"
+		}
+		if service.WSDL != "-" && !strings.Contains(service.WSDL, "://") && !filepath.IsAbs(service.WSDL) {
+			service.WSDL = filepath.Join(base, service.WSDL)
+		}
+		if schemaDir := service.Options.SchemaDir; schemaDir != "" && !strings.Contains(schemaDir, "://") && !filepath.IsAbs(schemaDir) {
+			service.Options.SchemaDir = filepath.Join(base, schemaDir)
+		}
+		if !filepath.IsAbs(service.Dir) {
+			service.Dir = filepath.Join(base, service.Dir)
+		}
+	}
+	return
+}
+
+// generateConfig generates all services of the config file, sharing downloaded
+// documents between them. A failing service doesn't stop the others.
+func generateConfig(path string) error {
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	cache := gowsdl.NewDocumentCache()
+	var failed []string
+	for _, service := range cfg.Services {
+		log.Println("Generating", service.WSDL)
+		if err := generateService(service, cache, nil); err != nil {
+			log.Printf("[ERROR] %v: %v", service.WSDL, err)
+			failed = append(failed, service.WSDL)
+		}
+	}
+	if len(failed) > 0 {
+		return errors.New("generation failed for " + strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// generateService generates the code of one service, to output instead of
+// files if it isn't nil.
+func generateService(service serviceConfig, cache *gowsdl.DocumentCache, output io.Writer) (err error) {
+	options := service.Options
+	makePublic := true
+	if options.MakePublic != nil {
+		makePublic = *options.MakePublic
+	}
+
+	var wsdl *gowsdl.GoWSDL
+	if wsdl, err = gowsdl.NewGoWSDL(
+		service.WSDL, options.FilePrefix,
+		strings.TrimSpace(service.Dir),
+		strings.TrimSpace(service.Package),
+		options.Insecure, makePublic, map[string]string{}); err != nil {
+		return
+	}
+	wsdl.HoistNamespaces = options.HoistNamespaces
+	wsdl.PrefixTypeNames = options.PrefixTypes || len(options.TypePrefixes) > 0
+	wsdl.TypeNamePrefixes = options.TypePrefixes
+	wsdl.NamespacePackages = options.NamespacePackages
+	wsdl.ImportPath = options.ImportPath
+	wsdl.SchemaDir = options.SchemaDir
+	if service.WSDL == "-" {
+		if wsdl.WSDLData, err = io.ReadAll(os.Stdin); err != nil {
+			return
+		}
+	}
+	if wsdl.ImportPath == "" {
+		// generating into a module, the packages are imported below its path
+		if wsdl.ImportPath, err = gowsdl.ModuleImportPath(strings.TrimSpace(service.Dir)); err != nil {
+			return
+		}
+	}
+	wsdl.DownloadUser = options.AuthUser
+	wsdl.DownloadPassword = options.AuthPass
+	wsdl.DownloadHeaders = options.Headers
+	wsdl.GenerateGetters = options.Getters
+	wsdl.RequiredValues = options.RequiredValues
+	wsdl.XSDBooleans = options.XSDBooleans
+	wsdl.XSDTokens = options.XSDTokens
+	wsdl.ValidateOccurs = options.ValidateOccurs
+	wsdl.RequiredConstructors = options.RequiredConstructors
+	wsdl.GenericCalls = options.GenericCalls
+	wsdl.FaultDetails = options.FaultDetails
+	wsdl.SecurityPolicies = options.SecurityPolicies
+	wsdl.ContextOnlyInterfaces = options.ContextOnly
+	wsdl.DeprecationKeyword = options.DeprecationKeyword
+	wsdl.ClientPackage = options.ClientPackage
+	wsdl.PlainTypes = options.PlainTypes
+	wsdl.TransliterateNames = options.Transliterate
+	wsdl.RootPrefix = options.RootPrefix
+	wsdl.OperationRootPrefixes = options.OperationPrefixes
+	wsdl.StrictPrefixes = options.StrictPrefixes
+	wsdl.NamespacePrefixes = options.NamespacePrefixes
+	wsdl.FaultErrors = options.FaultErrors
+	wsdl.Environments = options.Environments
+	wsdl.AsyncOperations = options.AsyncOperations
+	wsdl.AllowUnsupportedBindings = options.AllowUnsupported
+	wsdl.SkipUnresolvedExternals = options.SkipUnresolved
+	wsdl.MaxConcurrentDownloads = options.MaxDownloads
+	wsdl.BuildTag = options.BuildTag
+	wsdl.NoLint = options.NoLint
+	wsdl.FileSuffix = options.FileSuffix
+	wsdl.StructTags = options.StructTags
+	wsdl.NoOmitEmpty = options.NoOmitEmpty
+	wsdl.Operations = options.Operations
+	wsdl.CDATAElements = options.CDATA
+	wsdl.RoundTripTests = options.RoundTripTests
+	wsdl.KeepExisting = options.Overwrite != nil && !*options.Overwrite
+	wsdl.BackupExisting = options.Backup
+	wsdl.Cache = cache
+	wsdl.Output = output
+	if *diff {
+		wsdl.Diff = os.Stdout
+		wsdl.DiffColor = *diffColor
+	}
+
+	err = wsdl.Generate()
+	for _, warning := range wsdl.Warnings() {
+		log.Println("[WARN]", warning)
+	}
+	return
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "gowsdl.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfig(t, `
+services:
+  - wsdl: orders.wsdl
+    package: orders
+    options:
+      getters: true
+      make-public: false
+      operations: [PlaceOrder]
+      environment:
+        prod: https://orders.example.com/ws
+  - wsdl: http://example.com/prices?wsdl
+    package: prices
+    dir: /tmp/prices
+`)
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := filepath.Dir(path)
+	orders := cfg.Services[0]
+	if orders.WSDL != filepath.Join(base, "orders.wsdl") || orders.Dir != base {
+		t.Errorf("relative paths not resolved: %+v", orders)
+	}
+	if !orders.Options.Getters || orders.Options.MakePublic == nil || *orders.Options.MakePublic ||
+		len(orders.Options.Operations) != 1 || orders.Options.Environments["prod"] != "https://orders.example.com/ws" {
+		t.Errorf("unexpected options: %+v", orders.Options)
+	}
+	if prices := cfg.Services[1]; prices.WSDL != "http://example.com/prices?wsdl" || prices.Dir != "/tmp/prices" {
+		t.Errorf("absolute locations changed: %+v", prices)
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"no services":     `services: []`,
+		"missing wsdl":    "services:\n  - package: orders\n",
+		"missing package": "services:\n  - wsdl: orders.wsdl\n",
+		"unknown option":  "services:\n  - wsdl: orders.wsdl\n    package: orders\n    options:\n      getter: true\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := loadConfig(writeConfig(t, content)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestGenerateConfig_ContinuesAfterFailure(t *testing.T) {
+	fixture, err := filepath.Abs(filepath.Join("..", "..", "fixtures", "operations.wsdl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := t.TempDir()
+	path := writeConfig(t, `
+services:
+  - wsdl: missing.wsdl
+    package: missing
+    dir: `+out+`
+  - wsdl: `+fixture+`
+    package: orders
+    dir: `+out+`
+`)
+
+	err = generateConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "missing.wsdl") {
+		t.Errorf("expected the failing service to be reported, got %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(out, "example.com", "orders", "service_orders.go")); err != nil {
+		t.Errorf("the valid service wasn't generated: %v", err)
+	}
+}
@@ -8,6 +8,7 @@ Gowsdl generates Go code from a WSDL file.
 This project is originally intended to generate Go clients for WS-* services.
 
 Usage: gowsdl [options] myservice.wsdl
+       gowsdl -config gowsdl.yaml
   -o string
         File where the generated code will be saved (default "myservice.go")
   -p string
@@ -49,7 +50,7 @@ package main
 import (
 	"flag"
 	"fmt"
-	"github.com/hooklift/gowsdl"
+	"io"
 	"log"
 	"os"
 	"strings"
@@ -67,8 +68,80 @@ var pkg = flag.String("p", "myservice", "Package under which code will be genera
 var dir = flag.String("d", "./", "Directory under which service package directory will be created")
 var insecure = flag.Bool("i", false, "Skips TLS Verification")
 var makePublic = flag.Bool("make-public", true, "Make the generated types public/exported")
+var hoistNamespaces = flag.Bool("hoist-ns", false, "Declare the target namespaces on the SOAP Envelope of generated clients")
+var prefixTypeNames = flag.Bool("prefix-types", false, "Prefix generated type names with a namespace derived token")
+var schemaDir = flag.String("schema-dir", "", "Directory or URL the relative schema locations of a WSDL read from standard input as - resolve against")
+var importPath = flag.String("import-path", "", "Import path of the -d directory for the imports between the generated packages, detected from the go.mod of the directory if empty, the -p package otherwise")
+var typeNamePrefixes = keyValueFlag{}
+var namespacePackages = keyValueFlag{}
+var authUser = flag.String("auth-user", "", "Basic auth user for downloading the WSDL and its schemas")
+var authPass = flag.String("auth-pass", "", "Basic auth password for downloading the WSDL and its schemas")
+var downloadHeaders = keyValueFlag{}
+var operationRootPrefixes = keyValueFlag{}
+var namespacePrefixes = keyValueFlag{}
+var faultErrors = keyValueFlag{}
+var environments = keyValueFlag{}
+var asyncOperations = keyValueFlag{}
+var generateGetters = flag.Bool("getters", false, "Generate nil safe GetX accessors for struct fields")
+var requiredValues = flag.Bool("required-values", false, "Generate required element references and extension bases as values instead of pointers")
+var genericCalls = flag.Bool("generic-calls", false, "Generate service methods calling soap.CallOperationTyped instead of passing responses as interface{}")
+var securityPolicies = flag.Bool("security-policies", false, "Generate the security the WS-SecurityPolicy of the bindings requires as soap.SecurityPolicy, and constructors setting a required UsernameToken")
+var faultDetails = flag.Bool("fault-details", false, "Generate service methods decoding the fault details of their operations into the types of the detail elements, returned as soap.FaultDetailError")
+var xsdBooleans = flag.Bool("xsd-booleans", false, "Generate xsd:boolean as soap.XSDBoolean, written as 1 or 0 instead of true or false")
+var xsdTokens = flag.Bool("xsd-tokens", false, "Generate xsd:token and xsd:normalizedString as soap.Token and soap.NormalizedString, which collapse or replace whitespace")
+var validateOccurs = flag.Bool("validate-occurs", false, "Generate Validate methods checking the number of items of elements with a bounded maxOccurs")
+var clientPackage = flag.String("client-package", "", "Sub package for the client, the port type interfaces are then generated to a contract file next to the types")
+var plainTypes = flag.Bool("plain-types", false, "Generate the types without XMLName fields and soap types, for reuse with other transports, the client and server go to -client-package, client by default")
+var transliterate = flag.Bool("transliterate", false, "Spell accented letters of XML names in ASCII in the Go identifiers, like ae for ä, the XML names are kept in the tags")
+var rootPrefix = flag.String("root-prefix", "", "Namespace prefix for the request root elements, instead of a default namespace")
+var strictPrefixes = flag.Bool("strict-prefixes", false, "Send requests with the target namespaces bound to the same prefixes, declared by the documents or set with -ns-prefix, registered by the generated client")
+var allowUnsupported = flag.Bool("allow-unsupported", false, "Generate operations of rpc style or encoded use bindings as document/literal with a warning, instead of failing")
+var maxDownloads = flag.Int("max-downloads", 0, "Number of external schemas fetched at once, 8 if zero, 1 fetches them one after another")
+var skipUnresolved = flag.Bool("skip-unresolved", false, "Continue past schemaLocations which can't be fetched, as long as their types aren't needed")
+var operations = flag.String("operations", "", "Comma separated operations to generate, with only the types they reference")
+var buildTag = flag.String("build-tag", "", "Build constraint expression for the //go:build line of generated files")
+var fileSuffix = flag.String("file-suffix", "", "Suffix inserted before the .go extension of generated files, like .gen")
+var noLint = flag.Bool("nolint", false, "Add a //nolint:all directive to the generated files for linters not skipping generated code")
+var structTags = flag.String("struct-tags", "", "Comma separated keys of the struct tags of generated fields in their order, like json,xml or xml,json,yaml, xml,json by default")
+var noOmitEmpty = flag.Bool("no-omitempty", false, "Leave omitempty out of the struct tags other than xml")
+var requiredConstructors = flag.Bool("required-constructors", false, "Generate New<Type>Required constructors taking the required elements of complex types as parameters")
+var contextOnlyInterfaces = flag.Bool("context-only-interfaces", false, "Generate the port type interfaces with only the methods taking a context")
+var deprecationKeyword = flag.String("deprecation-keyword", "", "Word, like deprecated, marking operations and schema declarations as deprecated when a line or sentence of their documentation starts with it")
+var configFile = flag.String("config", "", "YAML file listing services to generate in one run, instead of a WSDL argument")
+var roundTripTests = flag.Bool("roundtrip-tests", false, "Generate _roundtrip_test.go files marshaling sample values of the generated types to XML and back")
+var overwrite = flag.Bool("overwrite", true, "Overwrite existing generated files, with false they are kept and reported as error")
+var stdout = flag.Bool("stdout", false, "Print the generated code as one source file to standard output instead of writing files, the code must generate to a single package")
+var diff = flag.Bool("diff", false, "Print a unified diff of the generated code against the existing files instead of writing them, exits non-zero if they differ")
+var diffColor = flag.Bool("diff-color", false, "Color the removed and added lines of -diff")
+var backup = flag.Bool("backup", false, "Rename existing generated files with a .bak suffix before overwriting them")
+var cdataElements = flag.String("cdata", "", "Comma separated string elements, as name or TypeName.name, to wrap in CDATA")
+
+// keyValueFlag collects repeated key=value flags.
+type keyValueFlag map[string]string
+
+func (o keyValueFlag) String() string {
+	return fmt.Sprintf("%v", map[string]string(o))
+}
+
+func (o keyValueFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	o[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	return nil
+}
 
 func init() {
+	flag.Var(typeNamePrefixes, "type-prefix", "Type name prefix for a namespace as namespace=Prefix, implies -prefix-types (repeatable)")
+	flag.Var(namespacePackages, "ns-package", "Package for a namespace as namespace=name or namespace=path/name, relative to -p (repeatable)")
+	flag.Var(operationRootPrefixes, "operation-prefix", "Namespace prefix for the request root element of an operation as Operation=prefix (repeatable)")
+	flag.Var(namespacePrefixes, "ns-prefix", "Prefix for a namespace with -strict-prefixes as namespace=prefix (repeatable)")
+	flag.Var(faultErrors, "fault-error", "Error variable generated for a fault code as code=ErrName, returned by the service methods for its faults (repeatable)")
+	flag.Var(environments, "environment", "Service address in an environment as name=URL, generates <Service>Environment constants, <Service>Endpoints and NewXForEnvironment constructors (repeatable)")
+	flag.Var(asyncOperations, "async-operation", "Operation submitting an asynchronous request and the operation polling for its result as Operation=PollOperation, generates an AndWait function (repeatable)")
+	flag.Var(downloadHeaders, "header", "HTTP header for downloading the WSDL and its schemas as Name=value (repeatable)")
+
 	log.SetFlags(0)
 	log.SetOutput(os.Stdout)
 	log.SetPrefix("🍀  ")
@@ -76,7 +149,7 @@ func init() {
 
 func main() {
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s [options] myservice.wsdl\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] myservice.wsdl|-\n       %s -config gowsdl.yaml\n", os.Args[0], os.Args[0])
 		flag.PrintDefaults()
 	}
 
@@ -93,26 +166,100 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *stdout {
+		if *configFile != "" {
+			log.Fatalln("-stdout can't be combined with -config")
+		}
+		if *diff {
+			log.Fatalln("-stdout can't be combined with -diff")
+		}
+		// keep standard output for the generated code
+		log.SetOutput(os.Stderr)
+	}
+	if *diff {
+		// keep standard output for the diff
+		log.SetOutput(os.Stderr)
+	}
+
+	if *configFile != "" {
+		if err := generateConfig(*configFile); err != nil {
+			log.Fatalln(err)
+		}
+		log.Println("Done 👍")
+		return
+	}
+
 	if err := generate(); err != nil {
 		log.Fatalln(err)
 	}
 }
 
 func generate() (err error) {
-	wsdlPath := os.Args[len(os.Args)-1]
-
-	// load wsdl
-	var wsdl *gowsdl.GoWSDL
-	if wsdl, err = gowsdl.NewGoWSDL(
-		wsdlPath, *filePrefix,
-		strings.TrimSpace(*dir),
-		strings.TrimSpace(*pkg),
-		*insecure, *makePublic, map[string]string{}); err != nil {
-		return
+	service := serviceConfig{
+		WSDL:    os.Args[len(os.Args)-1],
+		Package: *pkg,
+		Dir:     *dir,
+		Options: serviceOptions{
+			FilePrefix:           *filePrefix,
+			Insecure:             *insecure,
+			MakePublic:           makePublic,
+			HoistNamespaces:      *hoistNamespaces,
+			PrefixTypes:          *prefixTypeNames,
+			TypePrefixes:         typeNamePrefixes,
+			NamespacePackages:    namespacePackages,
+			ImportPath:           *importPath,
+			SchemaDir:            *schemaDir,
+			AuthUser:             *authUser,
+			AuthPass:             *authPass,
+			Headers:              downloadHeaders,
+			Getters:              *generateGetters,
+			RequiredValues:       *requiredValues,
+			XSDBooleans:          *xsdBooleans,
+			XSDTokens:            *xsdTokens,
+			ValidateOccurs:       *validateOccurs,
+			GenericCalls:         *genericCalls,
+			FaultDetails:         *faultDetails,
+			SecurityPolicies:     *securityPolicies,
+			ClientPackage:        *clientPackage,
+			PlainTypes:           *plainTypes,
+			Transliterate:        *transliterate,
+			RootPrefix:           *rootPrefix,
+			OperationPrefixes:    operationRootPrefixes,
+			StrictPrefixes:       *strictPrefixes,
+			NamespacePrefixes:    namespacePrefixes,
+			FaultErrors:          faultErrors,
+			Environments:         environments,
+			AsyncOperations:      asyncOperations,
+			AllowUnsupported:     *allowUnsupported,
+			SkipUnresolved:       *skipUnresolved,
+			MaxDownloads:         *maxDownloads,
+			BuildTag:             *buildTag,
+			NoLint:               *noLint,
+			FileSuffix:           *fileSuffix,
+			NoOmitEmpty:          *noOmitEmpty,
+			RequiredConstructors: *requiredConstructors,
+			ContextOnly:          *contextOnlyInterfaces,
+			DeprecationKeyword:   *deprecationKeyword,
+			RoundTripTests:       *roundTripTests,
+			Overwrite:            overwrite,
+			Backup:               *backup,
+		},
+	}
+	if *operations != "" {
+		service.Options.Operations = strings.Split(*operations, ",")
+	}
+	if *cdataElements != "" {
+		service.Options.CDATA = strings.Split(*cdataElements, ",")
+	}
+	if *structTags != "" {
+		service.Options.StructTags = strings.Split(*structTags, ",")
 	}
 
-	// generate code
-	if err = wsdl.Generate(); err != nil {
+	var output io.Writer
+	if *stdout {
+		output = os.Stdout
+	}
+	if err = generateService(service, nil, output); err != nil {
 		return
 	}
 
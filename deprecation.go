@@ -0,0 +1,80 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"regexp"
+	"strings"
+)
+
+// defaultDeprecation is the message of a Deprecated comment whose marker
+// gives no reason.
+const defaultDeprecation = "the service documents it as deprecated."
+
+// deprecationMessage returns the reason a declaration with the documentation
+// doc and the appinfo annotations is deprecated for, ok is false if it isn't.
+// A deprecated element in an appinfo marks it, with its text as reason, as
+// does DeprecationKeyword starting a line or sentence of the documentation,
+// with the rest of its line.
+func (g *GoWSDL) deprecationMessage(doc string, appInfo []*XSDAppInfo) (message string, ok bool) {
+	for _, info := range appInfo {
+		if message, ok = deprecatedAppInfo(info.Content); ok {
+			return
+		}
+	}
+	if g.DeprecationKeyword == "" || doc == "" {
+		return "", false
+	}
+	// the keyword starts a line or a sentence, as a word of its own
+	keyword := regexp.MustCompile(`(?im)(?:^|[.!?]\s)\s*` + regexp.QuoteMeta(g.DeprecationKeyword) + `\b[:\s-]*(.*)$`)
+	if match := keyword.FindStringSubmatch(doc); match != nil {
+		return strings.TrimSpace(match[1]), true
+	}
+	return "", false
+}
+
+// deprecatedAppInfo looks for a deprecated element in the content of an
+// appinfo, of any namespace, and returns its text.
+func deprecatedAppInfo(content string) (message string, ok bool) {
+	d := xml.NewDecoder(strings.NewReader(content))
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return "", false
+		}
+		if start, isStart := token.(xml.StartElement); isStart && strings.EqualFold(start.Name.Local, "deprecated") {
+			var text string
+			if err = d.DecodeElement(&text, &start); err != nil {
+				return "", true
+			}
+			return strings.Join(strings.Fields(text), " "), true
+		}
+	}
+}
+
+// deprecation returns the message of the Deprecated comment of a
+// declaration, see deprecationMessage, empty if it isn't deprecated.
+func (g *GoWSDL) deprecation(doc string, appInfo []*XSDAppInfo) string {
+	message, ok := g.deprecationMessage(doc, appInfo)
+	if ok && message == "" {
+		message = defaultDeprecation
+	}
+	return message
+}
+
+// deprecated returns the Deprecated paragraph to append to the comment of a
+// declaration, separated from its documentation by an empty comment line. It
+// is empty if the declaration isn't deprecated.
+func (g *GoWSDL) deprecated(doc string, appInfo []*XSDAppInfo) string {
+	message := g.deprecation(doc, appInfo)
+	if message == "" {
+		return ""
+	}
+	if comment(doc) != "" {
+		return "\n//\n// Deprecated: " + message
+	}
+	return "// Deprecated: " + message
+}
@@ -0,0 +1,97 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// ErrGeneratedDiffers is returned by Generate with Diff if a generated file
+// differs from the file in the directory or doesn't exist yet.
+var ErrGeneratedDiffers = errors.New("generated code differs from the existing files")
+
+// ANSI escapes of the DiffColor lines.
+const (
+	diffRemoved = "\x1b[31m"
+	diffAdded   = "\x1b[32m"
+	diffHunk    = "\x1b[36m"
+	diffReset   = "\x1b[0m"
+)
+
+// writeDiff writes the unified diffs of the collected files against the
+// files in the directory to Diff, sorted by path.
+func (g *GoWSDL) writeDiff() error {
+	files := append([]generatedFile(nil), g.generated...)
+	sort.Slice(files, func(i, j int) bool {
+		return filepath.Join(files[i].dir, files[i].name) < filepath.Join(files[j].dir, files[j].name)
+	})
+
+	differs := false
+	for _, file := range files {
+		path := filepath.Join(g.dir, file.dir, file.name)
+		existing, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		diff := difflib.UnifiedDiff{
+			B:        difflib.SplitLines(string(file.source)),
+			FromFile: path,
+			ToFile:   path,
+			Context:  3,
+		}
+		if err == nil {
+			diff.A = difflib.SplitLines(string(existing))
+		} else {
+			diff.FromFile = os.DevNull
+		}
+		text, err := difflib.GetUnifiedDiffString(diff)
+		if err != nil {
+			return err
+		}
+		if text == "" {
+			continue
+		}
+		differs = true
+		if g.DiffColor {
+			text = colorDiff(text)
+		}
+		if _, err = io.WriteString(g.Diff, text); err != nil {
+			return err
+		}
+	}
+	if differs {
+		return ErrGeneratedDiffers
+	}
+	return nil
+}
+
+// colorDiff colors the removed, added and hunk lines of a unified diff.
+func colorDiff(text string) string {
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		var color string
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+		case strings.HasPrefix(line, "-"):
+			color = diffRemoved
+		case strings.HasPrefix(line, "+"):
+			color = diffAdded
+		case strings.HasPrefix(line, "@@"):
+			color = diffHunk
+		}
+		if color != "" {
+			content := strings.TrimSuffix(line, "\n")
+			lines[i] = color + content + diffReset + line[len(content):]
+		}
+	}
+	return strings.Join(lines, "")
+}
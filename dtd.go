@@ -0,0 +1,41 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"regexp"
+)
+
+// internalSubset matches the internal subset of a DOCTYPE declaration.
+var internalSubset = regexp.MustCompile(`(?s)<!DOCTYPE[^\[>]*\[(.*?)\]\s*>`)
+
+// entityDeclaration matches the general entity declarations with a literal
+// value, parameter and external entities aren't expanded.
+var entityDeclaration = regexp.MustCompile(`<!ENTITY\s+([^\s%]+)\s+(?:"([^"]*)"|'([^']*)')\s*>`)
+
+// newDocumentDecoder returns a decoder for a WSDL or XSD document which
+// expands the entities declared by the internal subset of its DOCTYPE, the
+// declaration itself is skipped like any other directive.
+func newDocumentDecoder(data []byte) *xml.Decoder {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	subset := internalSubset.FindSubmatch(data)
+	if subset == nil {
+		return d
+	}
+
+	d.Entity = map[string]string{}
+	for _, entity := range entityDeclaration.FindAllSubmatch(subset[1], -1) {
+		d.Entity[string(entity[1])] = string(entity[2]) + string(entity[3])
+	}
+	return d
+}
+
+// unmarshalDocument decodes a WSDL or XSD document into v, see
+// newDocumentDecoder.
+func unmarshalDocument(data []byte, v interface{}) error {
+	return newDocumentDecoder(data).Decode(v)
+}
@@ -2,6 +2,7 @@ package example
 
 import (
 	"crypto/tls"
+	"github.com/hooklift/gowsdl"
 	"github.com/hooklift/gowsdl/example/gen"
 	"github.com/hooklift/gowsdl/soap"
 	"log"
@@ -33,3 +34,34 @@ func ExampleWithOptions() {
 	}
 	log.Println(reply)
 }
+
+// enumerationCollector collects the values of the named enumerations.
+type enumerationCollector struct {
+	values map[string][]string
+}
+
+func (c *enumerationCollector) OnSimpleType(item *gowsdl.XSDSimpleType) {
+	if item.Name == "" {
+		return
+	}
+	for _, enumeration := range item.Restriction.Enumeration {
+		c.values[item.Name] = append(c.values[item.Name], enumeration.Value)
+	}
+}
+
+func (c *enumerationCollector) OnComplexType(item *gowsdl.XSDComplexType) {}
+func (c *enumerationCollector) OnElement(item *gowsdl.XSDElement)         {}
+func (c *enumerationCollector) OnAttribute(item *gowsdl.XSDAttribute)     {}
+
+func ExampleSchemaVisitor() {
+	collector := &enumerationCollector{values: map[string][]string{}}
+	generator, err := gowsdl.NewGoWSDL("stockquote.wsdl", "", "gen", "gen", false, true, nil)
+	if err != nil {
+		log.Fatalf("couldn't read the WSDL: %v", err)
+	}
+	generator.Visitors = append(generator.Visitors, collector)
+	if err = generator.Generate(); err != nil {
+		log.Fatalf("couldn't generate the client: %v", err)
+	}
+	log.Println(collector.values)
+}
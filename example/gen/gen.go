@@ -2,15 +2,10 @@ package gen
 
 import (
 	"encoding/xml"
-	"time"
 
 	"github.com/hooklift/gowsdl/soap"
 )
 
-// against "unused imports"
-var _ time.Time
-var _ xml.Name
-
 type TradePriceRequest struct {
 	XMLName xml.Name `xml:"http://example.com/stockquote.xsd TradePriceRequest"`
 
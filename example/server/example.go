@@ -12,7 +12,7 @@ import (
 var done = make(chan struct{})
 
 func client() {
-	client := soap.NewClient("http://127.0.0.1:8000")
+	client := soap.NewClient("http://127.0.0.1:8000", nil)
 	service := gen.NewMNBArfolyamServiceType(client)
 	resp, err := service.GetInfoSoap(&gen.GetInfo{
 		Id: "shenfuqiang",
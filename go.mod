@@ -8,7 +8,10 @@ require (
 	github.com/Masterminds/sprig/v3 v3.2.3
 	github.com/go-ee/utils v0.0.0-20230926154510-146da1b689e8
 	github.com/iancoleman/strcase v0.3.0
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/stretchr/testify v1.6.1
+	gopkg.in/yaml.v3 v3.0.1
+	software.sslmate.com/src/go-pkcs12 v0.4.0
 )
 
 require (
@@ -20,9 +23,7 @@ require (
 	github.com/imdario/mergo v0.3.16 // indirect
 	github.com/mitchellh/copystructure v1.2.0 // indirect
 	github.com/mitchellh/reflectwalk v1.0.2 // indirect
-	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/shopspring/decimal v1.3.1 // indirect
 	github.com/spf13/cast v1.5.1 // indirect
 	golang.org/x/crypto v0.13.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -12,14 +12,21 @@ import (
 	"fmt"
 	"github.com/Masterminds/sprig/v3"
 	"github.com/iancoleman/strcase"
+	"go/build/constraint"
 	"go/format"
+	"go/token"
+	"io"
 	"io/ioutil"
 	"log"
+	"math"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 	"time"
@@ -55,11 +62,260 @@ type GoWSDL struct {
 	makePublicFn          func(string) string
 	wsdl                  *WSDL
 	resolvedXSDExternals  map[string]bool
+	prefetched            *prefetchedDocuments
 	currentRecursionLevel uint8
 	typeResolver          *TypeResolver
 	nsPkgReplacements     map[string]string
+
+	// HoistNamespaces makes the generated service constructors declare the
+	// target namespaces on the SOAP Envelope instead of only inline.
+	HoistNamespaces bool
+
+	// PrefixTypeNames prefixes the generated type names with a token derived
+	// from the package of their namespace, or the alias in TypeNamePrefixes.
+	// XML names are not affected.
+	PrefixTypeNames  bool
+	TypeNamePrefixes map[string]string
+
+	// NamespacePackages pins the package of a namespace, as package name or
+	// path relative to the base package, instead of deriving it from the
+	// namespace. The directory and import path follow the package.
+	NamespacePackages map[string]string
+
+	// ImportPath is the import path of the directory generated to, like
+	// github.com/acme/app/internal/ws, which the generated code imports the
+	// packages of other namespaces below. The package name given to
+	// NewGoWSDL is used if empty, which is only right for a module of that
+	// name generated to its root. See ModuleImportPath.
+	ImportPath string
+
+	// DownloadUser and DownloadPassword are sent as Basic auth and
+	// DownloadHeaders as HTTP headers when fetching the WSDL and its schemas.
+	DownloadUser     string
+	DownloadPassword string
+	DownloadHeaders  map[string]string
+	// HTTPClient replaces the default client used for downloads, ignoreTLS
+	// has no effect then.
+	HTTPClient *http.Client
+
+	// CDATAElements names the string elements generated as soap.CDATAString,
+	// either by element name or as TypeName.elementName.
+	CDATAElements []string
+
+	// GenerateGetters adds nil safe GetX accessors for the fields of the
+	// generated structs.
+	GenerateGetters bool
+
+	// RequiredValues generates required element references and the bases of
+	// complex content extensions as struct values instead of pointers, which
+	// are then kept for optional and nillable elements only.
+	RequiredValues bool
+
+	// XSDBooleans generates xsd:boolean as soap.XSDBoolean, written as 1 or
+	// 0, for servers rejecting true and false.
+	XSDBooleans bool
+
+	// XSDTokens generates xsd:token as soap.Token and xsd:normalizedString
+	// as soap.NormalizedString, which collapse or replace whitespace as the
+	// schema mandates, instead of string.
+	XSDTokens bool
+
+	// ValidateOccurs generates a Validate method for complex types with
+	// elements of a bounded maxOccurs, which checks the number of their items
+	// against minOccurs and maxOccurs, see soap.Options.Validate.
+	ValidateOccurs bool
+
+	// RequiredConstructors generates a New<Type>Required constructor for
+	// complex types with required elements, taking the fields of the
+	// elements of minOccurs one or more as parameters, so leaving one out is
+	// a compile error. Optional fields are left to the With setters. New<Type>
+	// keeps no parameters, the type registry and Default constructors build
+	// on it.
+	RequiredConstructors bool
+
+	// RootPrefix makes the generated operations marshal their request root
+	// element and its children with this namespace prefix instead of a
+	// default namespace declaration. OperationRootPrefixes overrides it per
+	// operation name, an empty prefix keeps the default namespace.
+	RootPrefix            string
+	OperationRootPrefixes map[string]string
+
+	// StrictPrefixes makes the generated operations send their requests as
+	// soap.StrictPrefixedContent, with the target namespaces of the WSDL and
+	// its schemas bound to the same prefixes in every request, which the
+	// service constructors register with the soap.Client. A namespace gets
+	// its prefix of NamespacePrefixes, else the first prefix the documents
+	// declare for it, else ns1, ns2 and so on. Generation fails if
+	// NamespacePrefixes binds two namespaces to the same prefix or uses a
+	// prefix of the envelope. It takes precedence over RootPrefix.
+	StrictPrefixes    bool
+	NamespacePrefixes map[string]string
+
+	// AllowUnsupportedBindings generates the operations of bindings with rpc
+	// style or encoded use, which Generate rejects otherwise, as
+	// document/literal. They are reported by Warnings and marked in their doc
+	// comments, as their messages likely don't match what the service expects.
+	AllowUnsupportedBindings bool
+
+	// SkipUnresolvedExternals continues past schemaLocations which can't be
+	// fetched or parsed, they are reported by Warnings instead. Generation
+	// still fails if a type of their namespace is referenced but not declared
+	// by any of the loaded schemas.
+	SkipUnresolvedExternals bool
+	warnings                []error
+	unresolvedNamespaces    map[string]bool
+
+	// Operations limits generation to the named operations and the types
+	// they transitively reference, all operations are generated if empty.
+	Operations []string
+
+	// BuildTag is a build constraint expression, like "soap && !test",
+	// placed as //go:build line before the package clause of every
+	// generated file.
+	BuildTag string
+
+	// NoLint places a //nolint:all directive in the package doc comment of
+	// every generated file, next to the "Code generated ... DO NOT EDIT."
+	// line, so linters like golangci-lint skip the files entirely.
+	NoLint bool
+
+	// FileSuffix is inserted before the .go extension of the generated file
+	// names, like ".gen" for types_name.gen.go, to match linter or vendoring
+	// globs. It may only contain letters, digits, dots and hyphens, so it
+	// can't turn a file into a test or a GOOS/GOARCH constrained file.
+	FileSuffix string
+
+	// StructTags lists the keys of the struct tags of the generated fields in
+	// their order, "xml" then "json" if empty. It must contain "xml", the
+	// other keys, like "json" or "yaml", get the XML local name as value.
+	StructTags []string
+
+	// NoOmitEmpty leaves omitempty out of the struct tags other than xml,
+	// whose omitempty on optional elements and attributes is part of the
+	// encoding and kept.
+	NoOmitEmpty bool
+
+	// ClientPackage generates the client implementation into this sub
+	// package of the service. The port type interfaces are then generated
+	// to a contract_ file next to the types, so consumers of the contract
+	// don't depend on the client and the soap package.
+	ClientPackage string
+
+	// PlainTypes generates the types as plain data, reusable with other
+	// transports: without XMLName fields and soap types, the built-in XSD
+	// types the soap package implements are generated as string. Nillable
+	// defaults and CDATA elements aren't applied and repeating choices become
+	// a field per element, losing their order. The client and the server are
+	// generated to ClientPackage, client if empty, and name the request
+	// and response elements themselves.
+	PlainTypes bool
+
+	// TransliterateNames spells the accented and other non-ASCII Latin
+	// letters of XML names in ASCII in the identifiers built from them, ä as
+	// ae and é as e for example. The XML names are kept in the tags.
+	TransliterateNames bool
+
+	// FaultErrors maps fault codes to the names of error variables generated
+	// with the service, which its methods then return for the faults of
+	// these codes, see soap.FaultErrors.
+	FaultErrors map[string]string
+
+	// Environments maps environment names, like prod and test, to the
+	// addresses of the service there. The service then gets an Environment
+	// constant per name, their Endpoints and a constructor per port type
+	// building the client for the endpoint of an environment. The type and
+	// the map are prefixed with the name of the service, as QuotesEnvironment
+	// and QuotesEndpoints.
+	Environments map[string]string
+
+	// GenericCalls makes the generated service methods call
+	// soap.CallOperationTyped instead of passing the response as interface{}.
+	// Operations without request or response, or with a root prefix, and
+	// services of PlainTypes or StrictPrefixes keep calling the Client.
+	GenericCalls bool
+
+	// FaultDetails makes the generated service methods of operations with
+	// faults decode the fault detail into the Go type of its element, which
+	// the service registers in soap.NamespaceTypes, and return the fault as
+	// soap.FaultDetailError, see soap.FaultDetails.
+	FaultDetails bool
+
+	// SecurityPolicies generates a <PortType>SecurityPolicy variable listing
+	// the security the WS-SecurityPolicy assertions attached to its binding
+	// require, see soap.SecurityPolicy. Port types requiring a UsernameToken
+	// with a plain text password also get a New<PortType>WithUsernameToken
+	// constructor setting the WS-Security header.
+	SecurityPolicies bool
+
+	// ContextOnlyInterfaces leaves the methods without context out of the
+	// port type interfaces, which then declare only the <Operation>Context
+	// methods for mocks to implement. The client keeps the methods without
+	// context.
+	ContextOnlyInterfaces bool
+
+	// DeprecationKeyword, like "deprecated", marks operations and schema
+	// declarations whose documentation has a line or sentence starting with
+	// it as a word, ignoring case, as deprecated, with the rest of its line
+	// as reason. They get a
+	// "Deprecated:" comment, which Go tools recognize. A deprecated element
+	// in an appinfo annotation marks the declaration as well.
+	DeprecationKeyword string
+
+	// AsyncOperations maps operations which submit an asynchronous request
+	// to the operations of the same port type polling for its result. A
+	// <PortType><Operation>AndWait function is generated for each, which
+	// submits the request and polls with a soap.Poller until the result is
+	// complete.
+	AsyncOperations map[string]string
+
+	// RoundTripTests generates a _roundtrip_test.go file next to each types
+	// file, testing that the struct types with a New constructor survive
+	// marshaling sample values to XML and back, see soaptest.RoundTrip.
+	RoundTripTests bool
+
+	// KeepExisting leaves generated files which already exist untouched,
+	// Generate writes the other files and then fails listing the kept ones.
+	// BackupExisting instead renames existing files with a .bak suffix
+	// before writing them.
+	KeepExisting   bool
+	BackupExisting bool
+	keptFiles      []string
+
+	// Output, if set, receives the generated code as one source file instead
+	// of the files being written to the directory. The code must generate to
+	// a single package, and can't include RoundTripTests.
+	Output    io.Writer
+	generated []generatedFile
+
+	// Diff, if set, receives a unified diff of each generated file against
+	// the existing file in the directory instead of the files being written.
+	// Generate then fails with ErrGeneratedDiffers if any file differs.
+	// DiffColor colors the removed and added lines for terminals.
+	Diff      io.Writer
+	DiffColor bool
+
+	// Visitors are walked over all schemas after the types are registered
+	// and before the code is generated, see SchemaVisitor.
+	Visitors []SchemaVisitor
+
+	// MaxConcurrentDownloads bounds the number of external schemas fetched
+	// at once, 8 if zero. 1 fetches them one after another.
+	MaxConcurrentDownloads int
+
+	// WSDLData, if set, is the WSDL document, read instead of the wsdlFile
+	// passed to NewGoWSDL, like from standard input. Its relative schema and
+	// import locations resolve against SchemaDir, a directory or URL, they
+	// fail to resolve without it.
+	WSDLData  []byte
+	SchemaDir string
+
+	// Cache keeps downloaded documents, share it between generators to fetch
+	// the schemas of several services only once.
+	Cache *DocumentCache
 }
 
+var validFileSuffix = regexp.MustCompile(`^[A-Za-z0-9.-]*$`)
+
 var cacheDir = filepath.Join(os.TempDir(), "gowsdl-cache")
 
 func init() {
@@ -76,31 +332,47 @@ func dialTimeout(network, addr string) (net.Conn, error) {
 	return net.DialTimeout(network, addr, timeout)
 }
 
-func downloadFile(url string, ignoreTLS bool) ([]byte, error) {
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: ignoreTLS,
-		},
-		Dial: dialTimeout,
+// downloadFile returns the document at url and the URL it was downloaded
+// from after redirects.
+func (g *GoWSDL) downloadFile(url string) ([]byte, string, error) {
+	client := g.HTTPClient
+	if client == nil {
+		tr := &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: g.ignoreTLS,
+			},
+			Dial: dialTimeout,
+		}
+		client = &http.Client{Transport: tr}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if g.DownloadUser != "" {
+		req.SetBasicAuth(g.DownloadUser, g.DownloadPassword)
+	}
+	for k, v := range g.DownloadHeaders {
+		req.Header.Set(k, v)
 	}
-	client := &http.Client{Transport: tr}
 
-	resp, err := client.Get(url)
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Received response code %d", resp.StatusCode)
+		return nil, "", fmt.Errorf("Received response code %d", resp.StatusCode)
 	}
 
 	data, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return data, nil
+	return data, resp.Request.URL.String(), nil
 }
 
 // NewGoWSDL initializes WSDL generator.
@@ -137,11 +409,74 @@ func NewGoWSDL(wsdlFile, filePrefix string,
 // Generate initiaties the code generation process by starting two goroutines: one
 // to generate Types and another one to generate Operations.
 func (g *GoWSDL) Generate() (err error) {
+	if g.BuildTag != "" {
+		if _, err = constraint.Parse("//go:build " + g.BuildTag); err != nil {
+			return fmt.Errorf("invalid build tag %q: %w", g.BuildTag, err)
+		}
+	}
+	if !validFileSuffix.MatchString(g.FileSuffix) {
+		return fmt.Errorf("invalid file suffix %q", g.FileSuffix)
+	}
+	if err = g.checkStructTags(); err != nil {
+		return
+	}
+	for namespace, pkg := range g.NamespacePackages {
+		if pkg == "" || !token.IsIdentifier(PackageLast(pkg)) {
+			return fmt.Errorf("invalid package %q for namespace %q", pkg, namespace)
+		}
+	}
+	for code, name := range g.FaultErrors {
+		if !token.IsIdentifier(name) {
+			return fmt.Errorf("invalid error name %q for fault code %q", name, code)
+		}
+	}
+	if _, err = g.environments(); err != nil {
+		return
+	}
+	if g.Output != nil && g.RoundTripTests {
+		return errors.New("round trip tests can't be generated to a single output")
+	}
+	if g.Output != nil && g.Diff != nil {
+		return errors.New("a single output can't be diffed against the files")
+	}
 	if err = g.unmarshal(); err != nil {
 		return
 	}
+	g.wsdl.selectBodyParts()
+	if err = g.filterOperations(); err != nil {
+		return
+	}
+	if err = g.checkAsyncOperations(); err != nil {
+		return
+	}
+	if err = g.checkBindings(); err != nil {
+		return
+	}
+	if err = g.checkUnresolvedTypes(); err != nil {
+		return
+	}
 
+	g.typeResolver.PrefixTypeNames = g.PrefixTypeNames
+	g.typeResolver.XSDBooleans = g.XSDBooleans
+	g.typeResolver.XSDTokens = g.XSDTokens
+	g.typeResolver.PlainTypes = g.PlainTypes
+	g.typeResolver.TransliterateNames = g.TransliterateNames
+	g.typeResolver.ImportBase = g.ImportPath
+	if g.PlainTypes && g.ClientPackage == "" {
+		g.ClientPackage = "client"
+	}
+	for namespace, prefix := range g.TypeNamePrefixes {
+		g.typeResolver.TypeNamePrefixes[namespace] = prefix
+	}
+	for namespace, pkg := range g.NamespacePackages {
+		g.typeResolver.NamespacePackages[namespace] = pkg
+	}
 	g.typeResolver.RegisterTypes(g.wsdl)
+	for _, visitor := range g.Visitors {
+		for _, schema := range g.wsdl.Types.Schemas {
+			WalkSchema(schema, g.wsdl.Types.Schemas, visitor)
+		}
+	}
 
 	if err = g.genTypes(); err != nil {
 		return
@@ -158,124 +493,1000 @@ func (g *GoWSDL) Generate() (err error) {
 	if err = g.genTypeResolver(); err != nil {
 		return
 	}
+
+	if g.Diff != nil {
+		return g.writeDiff()
+	}
+	if g.Output != nil {
+		return g.writeOutput()
+	}
+
+	if len(g.keptFiles) > 0 {
+		err = fmt.Errorf("didn't overwrite existing files %s", strings.Join(g.keptFiles, ", "))
+	}
+	return
+}
+
+// fetchFile reads or downloads the document at loc. base is the location
+// the references of the document resolve against, the URL a download was
+// redirected to or else loc.
+func (g *GoWSDL) fetchFile(loc *Location) (data []byte, base *Location, err error) {
+	if data, base, ok, err := g.prefetched.get(loc.String()); ok {
+		return data, base, err
+	}
+	if loc.f != "" {
+		log.Println("Reading", "file", loc.f)
+		data, err = os.ReadFile(loc.f)
+		return data, loc, err
+	}
+
+	var finalURL string
+	if data, finalURL = g.Cache.get(loc.u.String()); data == nil {
+		log.Println("Downloading", "file", loc.u.String())
+		if data, finalURL, err = g.downloadFile(loc.u.String()); err != nil {
+			return nil, nil, err
+		}
+		g.Cache.put(loc.u.String(), data, finalURL)
+	}
+	base = loc
+	if finalURL != loc.u.String() {
+		if u, parseErr := url.Parse(finalURL); parseErr == nil {
+			base = &Location{u: u}
+		}
+	}
+	return
+}
+
+// readWSDL returns WSDLData with the location of SchemaDir, or else reads
+// the WSDL file, see fetchFile.
+func (g *GoWSDL) readWSDL() (data []byte, base *Location, err error) {
+	if g.WSDLData == nil {
+		return g.fetchFile(g.location)
+	}
+	base = new(Location)
+	if g.SchemaDir != "" {
+		if base, err = parseDirLocation(g.SchemaDir); err != nil {
+			return
+		}
+	}
+	// imports of metadata resolve against the location as well
+	g.location = base
+	return g.WSDLData, base, nil
+}
+
+func (g *GoWSDL) unmarshal() error {
+	data, base, err := g.readWSDL()
+	if err != nil {
+		return err
+	}
+
+	g.wsdl = new(WSDL)
+	metadata, err := unmarshalMetadata(data)
+	if err != nil {
+		return err
+	}
+	if metadata != nil {
+		if err = g.loadMetadata(metadata); err != nil {
+			return err
+		}
+	} else {
+		err = unmarshalDocument(data, g.wsdl)
+		if err != nil {
+			return err
+		}
+		g.rawWSDL = data
+
+		if err = g.resolveWSDLImports(g.wsdl, base); err != nil {
+			return err
+		}
+	}
+
+	g.prefetchXSDExternals(g.wsdl.Types.Schemas, base)
+	for _, schema := range g.wsdl.Types.Schemas {
+		err = g.resolveXSDExternals(schema, base)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolveWSDLImports merges the documents imported by wsdl:import into the
+// generated WSDL. Imported documents may use other target namespaces and
+// prefixes, their references are rebased onto the prefixes of the WSDL.
+func (g *GoWSDL) resolveWSDLImports(wsdl *WSDL, loc *Location) error {
+	for _, imp := range wsdl.Imports {
+		if imp.Location == "" {
+			continue
+		}
+
+		location, err := loc.Parse(imp.Location)
+		if err != nil {
+			return err
+		}
+		if g.resolvedXSDExternals[location.String()] {
+			continue
+		}
+		if g.resolvedXSDExternals == nil {
+			g.resolvedXSDExternals = make(map[string]bool, maxRecursion)
+		}
+		g.resolvedXSDExternals[location.String()] = true
+
+		data, base, err := g.fetchFile(location)
+		if err != nil {
+			return err
+		}
+
+		imported := new(WSDL)
+		if err = unmarshalDocument(data, imported); err != nil {
+			return err
+		}
+		if err = g.resolveWSDLImports(imported, base); err != nil {
+			return err
+		}
+		g.prefetchXSDExternals(imported.Types.Schemas, base)
+		for _, schema := range imported.Types.Schemas {
+			if err = g.resolveXSDExternals(schema, base); err != nil {
+				return err
+			}
+		}
+
+		g.wsdl.merge(imported)
+	}
+	return nil
+}
+
+func (g *GoWSDL) resolveXSDExternals(schema *XSDSchema, loc *Location) error {
+	download := func(base *Location, ref string, namespace string, includer *XSDSchema) error {
+		err := g.resolveXSDExternal(base, ref, includer)
+		if err != nil && g.SkipUnresolvedExternals {
+			log.Println("[WARN] Skipping schema", "location", ref, "error", err)
+			g.warnings = append(g.warnings, fmt.Errorf("couldn't resolve schema %s: %w", ref, err))
+			if g.unresolvedNamespaces == nil {
+				g.unresolvedNamespaces = map[string]bool{}
+			}
+			g.unresolvedNamespaces[namespace] = true
+			return nil
+		}
+		return err
+	}
+
+	for _, impts := range schema.Imports {
+		// Download the file only if we have a hint in the form of schemaLocation.
+		if impts.SchemaLocation == "" || impts.Namespace == xmlNamespace {
+			//log.Printf("[DEBUG] Don't know where to find XSD for %s", impts.Label)
+			continue
+		}
+
+		if e := download(loc, impts.SchemaLocation, impts.Namespace, nil); e != nil {
+			return e
+		}
+	}
+
+	for _, incl := range schema.Includes {
+		if e := download(loc, incl.SchemaLocation, schema.TargetNamespace, schema); e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// resolveXSDExternal fetches the schema at ref and the schemas it includes or
+// imports. includer is the including schema for xsd:include, an included
+// schema without targetNamespace adopts the namespace of its includer.
+func (g *GoWSDL) resolveXSDExternal(base *Location, ref string, includer *XSDSchema) error {
+	location, err := base.Parse(ref)
+	if err != nil {
+		return err
+	}
+	schemaKey := location.String()
+	if includer != nil {
+		// A chameleon schema is resolved once per including namespace.
+		schemaKey += " " + includer.TargetNamespace
+	}
+	if g.resolvedXSDExternals[schemaKey] {
+		return nil
+	}
+	if g.resolvedXSDExternals == nil {
+		g.resolvedXSDExternals = make(map[string]bool, maxRecursion)
+	}
+	g.resolvedXSDExternals[schemaKey] = true
+
+	data, base, err := g.fetchFile(location)
+	if err != nil {
+		return err
+	}
+
+	newschema := new(XSDSchema)
+
+	err = unmarshalDocument(data, newschema)
+	if err != nil {
+		return err
+	}
+
+	if includer != nil && newschema.TargetNamespace == "" {
+		newschema.TargetNamespace = includer.TargetNamespace
+		for prefix, namespace := range includer.Xmlns {
+			if _, ok := newschema.Xmlns[prefix]; !ok {
+				newschema.Xmlns[prefix] = namespace
+			}
+		}
+	}
+
+	if (len(newschema.Includes) > 0 || len(newschema.Imports) > 0) &&
+		maxRecursion > g.currentRecursionLevel {
+		g.currentRecursionLevel++
+
+		err = g.resolveXSDExternals(newschema, base)
+		if err != nil {
+			return err
+		}
+	}
+
+	g.wsdl.Types.Schemas = append(g.wsdl.Types.Schemas, newschema)
+
+	return nil
+}
+
+// Warnings returns the problems generation continued past, like schemas
+// skipped because of SkipUnresolvedExternals.
+func (g *GoWSDL) Warnings() []error {
+	return g.warnings
+}
+
+// checkUnresolvedTypes fails if the loaded schemas reference types of a
+// namespace whose schema couldn't be fetched and no other schema declares them.
+func (g *GoWSDL) checkUnresolvedTypes() error {
+	if len(g.unresolvedNamespaces) == 0 {
+		return nil
+	}
+
+	var missing []string
+	seen := map[xml.Name]bool{}
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, ref := range schemaReferences(schema) {
+			if !g.unresolvedNamespaces[ref.Space] || seen[ref] {
+				continue
+			}
+			seen[ref] = true
+			if !g.wsdl.declares(ref) {
+				missing = append(missing, "{"+ref.Space+"}"+ref.Local)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("unresolved types of schemas which couldn't be fetched: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+type Context struct {
+	resolver *NsTypeResolver
+	wsdl     *GoWSDL
+	// currentType is the XSD name of the global type being generated.
+	currentType string
+}
+
+func NewContext(wsdl *GoWSDL) (ret *Context) {
+	ret = &Context{wsdl: wsdl}
+	ret.setNS(wsdl.wsdl.TargetNamespace)
+	return
+}
+
+func (o *Context) Log(messages ...string) string {
+	log.Printf("%v: %v\n", o.resolver.Schema.TargetNamespace, messages)
+	return ""
+}
+
+func (o *Context) FindTypeNillable(xsdType string, nillable bool) (ret string) {
+	return o.resolver.FindTypeNillable(xsdType, nillable)
+}
+
+func (o *Context) FindElementType(elm *XSDElement) (ret string) {
+	if o.wsdl.PlainTypes {
+		return o.resolver.FindElementType(elm)
+	}
+	if elm.Nillable && elm.Default != "" {
+		ret = "soap.Nillable[" + o.FindTypeNillable(elm.Type, false) + "]"
+		if elm.MinOccurs == "0" {
+			ret = "*" + ret
+		}
+		return
+	}
+	ret = o.resolver.FindElementType(elm)
+	if strings.TrimPrefix(ret, "*") == "string" && o.isCDATAElement(elm.Name) {
+		ret = strings.Replace(ret, "string", "soap.CDATAString", 1)
+	}
+	return
+}
+
+// nillableDefault is a field of a generated type set to the default of its
+// element by the constructor.
+type nillableDefault struct {
+	Field string
+	Value string
+}
+
+// NillableDefaults lists the fields of nillable elements with a default of
+// the sequence and all of the complex type, which the constructor sets to
+// their default. Choices are left unset, a default would select them. It
+// fails for a default which isn't valid for a type defaultLiteral knows,
+// soap.NewNillable would leave the value nil.
+func (o *Context) NillableDefaults(complexType *XSDComplexType) (ret []nillableDefault, err error) {
+	if o.wsdl.PlainTypes {
+		return
+	}
+	elements := append(append([]*XSDElement{}, complexType.Sequence...), complexType.All...)
+	for _, elm := range elements {
+		if elm.Ref != "" || elm.Type == "" || !elm.Nillable || elm.Default == "" || elm.repeated() {
+			continue
+		}
+		goType := o.FindTypeNillable(elm.Type, false)
+		if _, ok := o.defaultLiteral(elm.Type, goType, elm.Default); !ok && o.hasDefaultLiteral(elm.Type, goType) {
+			return nil, fmt.Errorf("default %q of element %s is no valid %s", elm.Default, elm.Name, elm.Type)
+		}
+		value := "soap.NewNillable[" + goType + "](" + strconv.Quote(elm.Default) + ")"
+		if elm.MinOccurs != "0" {
+			value = "*" + value
+		}
+		ret = append(ret, nillableDefault{Field: makePublic(replaceAttrReservedWords(o.wsdl.identifier(elm.Name))), Value: value})
+	}
+	return
+}
+
+// fieldDefault is a field of a generated type with a default, set to its
+// constant by the Default constructor.
+type fieldDefault struct {
+	Field   string
+	Type    string
+	Value   string
+	Pointer bool
+}
+
+// FieldDefaults lists the fields of the elements of the sequence and all
+// and of the attributes of the complex type with a default, except nillable
+// elements, which the constructor sets already. Defaults of types without Go
+// constants, like time.Time, are left out.
+func (o *Context) FieldDefaults(complexType *XSDComplexType) (ret []fieldDefault) {
+	elements := append(append([]*XSDElement{}, complexType.Sequence...), complexType.All...)
+	for _, elm := range elements {
+		if elm.Ref != "" || elm.Type == "" || elm.Nillable || elm.Default == "" || elm.repeated() {
+			continue
+		}
+		goType := o.FindElementType(elm)
+		if value, ok := o.defaultLiteral(elm.Type, strings.TrimPrefix(goType, "*"), elm.Default); ok {
+			field := makePublic(replaceAttrReservedWords(o.wsdl.identifier(elm.Name)))
+			ret = append(ret, fieldDefault{Field: field, Type: strings.TrimPrefix(goType, "*"), Value: value, Pointer: strings.HasPrefix(goType, "*")})
+		}
+	}
+	for _, attr := range complexType.Attributes {
+		if attr.Ref != "" || attr.Default == "" {
+			continue
+		}
+		goType := "string"
+		if attr.Type != "" {
+			goType = o.FindTypeNillable(attr.Type, false)
+		}
+		if value, ok := o.defaultLiteral(attr.Type, goType, attr.Default); ok {
+			ret = append(ret, fieldDefault{Field: makePublic(normalize(o.wsdl.identifier(attr.Name))), Type: goType, Value: value})
+		}
+	}
+	return
+}
+
+// requiredField is a field of a required element, set by the Required
+// constructor from its parameter.
+type requiredField struct {
+	Field string
+	Param string
+	Type  string
+}
+
+// RequiredFields lists the fields of the elements of the sequence and all of
+// the complex type which occur at least once and of its required attributes,
+// for RequiredConstructors. Elements of inline types, which have no type a
+// parameter could be declared with, are left out.
+func (o *Context) RequiredFields(complexType *XSDComplexType) (ret []requiredField) {
+	elements := append(append([]*XSDElement{}, complexType.Sequence...), complexType.All...)
+	for _, elm := range elements {
+		if elm.MinOccurs == "0" {
+			continue
+		}
+		var field, goType string
+		switch {
+		case elm.Ref != "":
+			field = makePublic(replaceReservedWords(o.wsdl.identifier(removeNS(elm.Ref))))
+			goType = o.FindRefType(elm)
+		case elm.Type != "":
+			field = makePublic(replaceAttrReservedWords(o.wsdl.identifier(elm.Name)))
+			goType = o.FindElementType(elm)
+		default:
+			continue
+		}
+		if elm.repeated() {
+			goType = "[]" + goType
+		}
+		ret = append(ret, requiredField{Field: field, Param: requiredParam(field), Type: goType})
+	}
+	for _, attr := range complexType.Attributes {
+		if attr.Ref != "" || attr.Use != "required" {
+			continue
+		}
+		goType := "string"
+		if attr.Type != "" {
+			goType = o.FindTypeNillable(attr.Type, false)
+		}
+		field := makePublic(normalize(o.wsdl.identifier(attr.Name)))
+		ret = append(ret, requiredField{Field: field, Param: requiredParam(field), Type: goType})
+	}
+	return
+}
+
+// requiredParam returns the parameter name of a field of a Required
+// constructor, which mustn't be a keyword or the receiver o.
+func requiredParam(field string) string {
+	param := makePrivate(field)
+	if token.IsKeyword(param) || param == "o" {
+		param += "_"
+	}
+	return param
+}
+
+// intBits are the sizes of the numeric types defaults are parsed for.
+var intBits = map[string]int{
+	"int": 64, "int8": 8, "int16": 16, "int32": 32, "int64": 64,
+	"byte": 8, "uint16": 16, "uint32": 32, "uint64": 64,
+	"float32": 32, "float64": 64,
+}
+
+// defaultLiteral returns the Go constant of the default of an XSD type
+// generated as goType, ok is false if there is none.
+func (o *Context) defaultLiteral(xsdType, goType, lexical string) (ret string, ok bool) {
+	if goType == "string" || o.isStringEnumeration(xsdType) {
+		return strconv.Quote(o.whiteSpace(xsdType, lexical)), true
+	}
+	lexical = strings.TrimSpace(lexical)
+	switch goType {
+	case "bool":
+		switch lexical {
+		case "true", "1":
+			return "true", true
+		case "false", "0":
+			return "false", true
+		}
+		return
+	case "int", "int8", "int16", "int32", "int64":
+		value, err := strconv.ParseInt(lexical, 10, intBits[goType])
+		return strconv.FormatInt(value, 10), err == nil
+	case "byte", "uint16", "uint32", "uint64":
+		value, err := strconv.ParseUint(lexical, 10, intBits[goType])
+		return strconv.FormatUint(value, 10), err == nil
+	case "float32", "float64":
+		value, err := strconv.ParseFloat(lexical, intBits[goType])
+		if err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+			return
+		}
+		return strconv.FormatFloat(value, 'g', -1, 64), true
+	}
+	return
+}
+
+// whiteSpace applies the whiteSpace facet of a built-in XSD type to the
+// lexical value: xsd:string preserves it, xsd:normalizedString replaces tabs
+// and line breaks by spaces and the other built-in types collapse it. The
+// values of types the schemas declare are preserved.
+func (o *Context) whiteSpace(xsdType, lexical string) string {
+	isXMLSpace := func(r rune) bool { return r == ' ' || r == '\t' || r == '\n' || r == '\r' }
+	namespace, name := o.resolver.toNamespaceAndType(xsdType)
+	if namespace != xmlschema11 {
+		return lexical
+	}
+	switch strings.ToLower(name) {
+	case "string":
+		return lexical
+	case "normalizedstring":
+		return strings.Map(func(r rune) rune {
+			if isXMLSpace(r) {
+				return ' '
+			}
+			return r
+		}, lexical)
+	}
+	return strings.Join(strings.FieldsFunc(lexical, isXMLSpace), " ")
+}
+
+// hasDefaultLiteral reports whether defaultLiteral converts the defaults of
+// the Go type of the XSD type.
+func (o *Context) hasDefaultLiteral(xsdType, goType string) bool {
+	_, isNumber := intBits[goType]
+	return isNumber || goType == "string" || goType == "bool" || o.isStringEnumeration(xsdType)
+}
+
+// isStringEnumeration reports whether the XSD type is a simple type
+// restricting a string to an enumeration, generated as string type.
+func (o *Context) isStringEnumeration(xsdType string) bool {
+	namespace, name := o.resolver.toNamespaceAndType(xsdType)
+	for _, schema := range o.wsdl.wsdl.Types.Schemas {
+		if schema.TargetNamespace != namespace {
+			continue
+		}
+		for _, simpleType := range schema.SimpleType {
+			if simpleType.Name == name {
+				return len(simpleType.Restriction.Enumeration) > 0 &&
+					o.FindTypeNillable(simpleType.Restriction.Base, true) == "string"
+			}
+		}
+	}
+	return false
+}
+
+// repeatedElements returns copies of the elements of a repeating group
+// occurring any number of times, for plain types and inline types, which have
+// a field per element of the group, and for the choices nested in a repeating
+// sequence.
+func repeatedElements(elements []*XSDElement) (ret []*XSDElement) {
+	for _, elm := range elements {
+		repeated := *elm
+		repeated.MaxOccurs = "unbounded"
+		ret = append(ret, &repeated)
+	}
+	return
+}
+
+// occursCheck is a repeated element of a complex type, whose number of
+// items the generated Validate method checks.
+type occursCheck struct {
+	Field string
+	Min   int
+	Max   int
+}
+
+// OccursChecks lists the elements of the complex type with a bounded maxOccurs
+// above one for ValidateOccurs. The minOccurs of choice elements isn't
+// checked, another element may be chosen.
+func (o *Context) OccursChecks(complexType *XSDComplexType) (ret []occursCheck) {
+	add := func(elements []*XSDElement, choice bool) {
+		for _, elm := range elements {
+			max, err := strconv.Atoi(elm.MaxOccurs)
+			if err != nil || max < 2 || (elm.Type == "" && elm.Ref == "") {
+				continue
+			}
+			min := 1
+			if elm.MinOccurs != "" {
+				if min, err = strconv.Atoi(elm.MinOccurs); err != nil {
+					continue
+				}
+			}
+			if choice {
+				min = 0
+			}
+			field := makePublic(replaceAttrReservedWords(o.wsdl.identifier(elm.Name)))
+			if elm.Ref != "" {
+				field = o.wsdl.makePublicFn(replaceReservedWords(o.wsdl.identifier(removeNS(elm.Ref))))
+			}
+			ret = append(ret, occursCheck{Field: field, Min: min, Max: max})
+		}
+	}
+	extension := complexType.ComplexContent.Extension
+	add(extension.Sequence, false)
+	add(extension.Choice, true)
+	add(extension.SequenceChoice, true)
+	add(complexType.Sequence, false)
+	add(complexType.Choice, true)
+	add(complexType.SequenceChoice, true)
+	add(complexType.All, false)
+	return
+}
+
+// FindInlineType resolves the Go type of a field for an element with an inline
+// simpleType restriction. Restrictions with enumerations get a dedicated type
+// named after the enclosing type and the element, registered by the resolver.
+func (o *Context) FindInlineType(elm *XSDElement) (ret string) {
+	if len(elm.SimpleType.Restriction.Enumeration) == 0 {
+		return o.FindTypeNillable(elm.SimpleType.Restriction.Base, true)
+	}
+	return o.resolver.getTypeName(inlineTypeKey(o.currentType, elm.Name), false)
+}
+
+// FindRefType resolves the Go type of a field for an element reference, a
+// pointer unless RequiredValues is set and the element is required.
+func (o *Context) FindRefType(elm *XSDElement) (ret string) {
+	nillable := !o.wsdl.RequiredValues || elm.MinOccurs == "0"
+	if !nillable {
+		if global := o.findGlobalElement(elm.Ref); global != nil {
+			nillable = global.Nillable
+		}
+	}
+	return o.FindTypeNillable(elm.Ref, nillable)
+}
+
+// FindBaseType resolves the Go type embedded for the base of a complex
+// content extension, a pointer unless RequiredValues is set.
+func (o *Context) FindBaseType(base string) (ret string) {
+	return o.FindTypeNillable(base, !o.wsdl.RequiredValues)
+}
+
+func (o *Context) findGlobalElement(ref string) *XSDElement {
+	namespace, name := o.resolver.toNamespaceAndType(ref)
+	for _, schema := range o.wsdl.wsdl.Types.Schemas {
+		if schema.TargetNamespace != namespace {
+			continue
+		}
+		for _, elm := range schema.Elements {
+			if elm.Name == name {
+				return elm
+			}
+		}
+	}
+	return nil
+}
+
+// IsStructType reports whether the XSD type is a complex type generated as a
+// struct, which has an XMLName to set.
+func (o *Context) IsStructType(xsdType string) bool {
+	namespace, name := o.resolver.toNamespaceAndType(xsdType)
+	for _, schema := range o.wsdl.wsdl.Types.Schemas {
+		if schema.TargetNamespace != namespace {
+			continue
+		}
+		for _, complexType := range schema.ComplexTypes {
+			if complexType.Name == name {
+				return len(complexType.SimpleContent.Extension.Attributes) > 0 ||
+					o.FindTypeNillable(complexType.SimpleContent.Extension.Base, true) != "string"
+			}
+		}
+	}
+	return false
+}
+
+// checkAsyncOperations fails for AsyncOperations without a port type
+// declaring both operations with input and output.
+func (g *GoWSDL) checkAsyncOperations() error {
+	for submit, poll := range g.AsyncOperations {
+		found := false
+		for _, portType := range g.wsdl.PortTypes {
+			submitOperation, pollOperation := portType.operation(submit), portType.operation(poll)
+			if submitOperation != nil && pollOperation != nil &&
+				submitOperation.Output.Message != "" && pollOperation.Input.Message != "" && pollOperation.Output.Message != "" {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("async operation %q: no port type declares it and its poll operation %q with input and output", submit, poll)
+		}
+	}
+	return nil
+}
+
+// checkBindings fails listing the operations of bindings with rpc style or
+// encoded use, only document/literal is supported. They are reported as
+// warnings instead if AllowUnsupportedBindings is set.
+func (g *GoWSDL) checkBindings() error {
+	var unsupported []string
+	for _, portType := range g.wsdl.PortTypes {
+		for _, operation := range portType.Operations {
+			if reason := g.unsupportedBinding(operation.Name, portType.Name); reason != "" {
+				unsupported = append(unsupported, fmt.Sprintf("operation %s: %s", operation.Name, reason))
+			}
+		}
+	}
+	if len(unsupported) == 0 {
+		return nil
+	}
+	if g.AllowUnsupportedBindings {
+		for _, problem := range unsupported {
+			g.warnings = append(g.warnings, fmt.Errorf("%s, generated as document/literal", problem))
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported bindings, only document/literal is supported: %s", strings.Join(unsupported, "; "))
+}
+
+// unsupportedBinding returns why the binding of the operation of the port
+// type isn't supported, empty if it is document/literal.
+func (g *GoWSDL) unsupportedBinding(operation, portType string) string {
+	for _, binding := range g.wsdl.Binding {
+		if !strings.EqualFold(stripns(binding.Type), portType) {
+			continue
+		}
+		for _, bound := range binding.Operations {
+			if bound.Name != operation {
+				continue
+			}
+			var reasons []string
+			if style := binding.style(bound); style != "document" {
+				reasons = append(reasons, style+" style")
+			}
+			if bound.encoded() {
+				reasons = append(reasons, "encoded use")
+			}
+			if len(reasons) > 0 {
+				return fmt.Sprintf("binding %s uses %s", binding.Name, strings.Join(reasons, " and "))
+			}
+		}
+	}
+	return ""
+}
+
+// AsyncPollOperation returns the operation of the port type polling for the
+// result of the async operation, nil if it isn't one of AsyncOperations.
+func (o *Context) AsyncPollOperation(operation, portType string) *WSDLOperation {
+	poll, ok := o.wsdl.AsyncOperations[operation]
+	if !ok {
+		return nil
+	}
+	for _, declaring := range o.wsdl.wsdl.PortTypes {
+		if declaring.Name == portType {
+			return declaring.operation(poll)
+		}
+	}
+	return nil
+}
+
+// operationInfo is an entry of the generated <Service>Operations map.
+type operationInfo struct {
+	Name     string
+	Action   string
+	Request  string
+	Response string
+}
+
+// OperationInfos lists the operations of the port types by name, the first
+// port type declaring a name wins.
+func (o *Context) OperationInfos(portTypes []*WSDLPortType) (ret []operationInfo) {
+	seen := map[string]bool{}
+	for _, portType := range portTypes {
+		for _, operation := range portType.Operations {
+			if seen[operation.Name] {
+				continue
+			}
+			seen[operation.Name] = true
+			ret = append(ret, operationInfo{
+				Name:     operation.Name,
+				Action:   o.wsdl.findSOAPAction(operation.Name, portType.Name),
+				Request:  o.FindTypeNotNillable(operation.Input.Message),
+				Response: o.FindTypeNotNillable(operation.Output.Message),
+			})
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return
+}
+
+// faultDetail is a detail element of the faults of an operation and its Go
+// type.
+type faultDetail struct {
+	Name xml.Name
+	Type string
+}
+
+// faultOperation is an entry of the generated <service>FaultDetails map.
+type faultOperation struct {
+	Name    string
+	Details []faultDetail
+}
+
+// FaultDetails returns the detail elements of the faults the operation
+// declares, nil without FaultDetails. Faults of messages without element
+// part are left out.
+func (o *Context) FaultDetails(operation *WSDLOperation) (ret []faultDetail) {
+	if !o.wsdl.FaultDetails {
+		return nil
+	}
+	seen := map[xml.Name]bool{}
+	for _, fault := range operation.Faults {
+		msg, _ := o.wsdl.wsdl.findMessage(fault.Message)
+		if msg == nil || msg.BodyPart() == nil || msg.BodyPart().Element == "" {
+			continue
+		}
+		name := o.MessageElementName(fault.Message)
+		goType := o.FindTypeNotNillable(fault.Message)
+		if seen[name] || goType == "" {
+			continue
+		}
+		seen[name] = true
+		ret = append(ret, faultDetail{Name: name, Type: goType})
+	}
 	return
 }
 
-func (g *GoWSDL) fetchFile(loc *Location) (data []byte, err error) {
-	if loc.f != "" {
-		log.Println("Reading", "file", loc.f)
-		data, err = os.ReadFile(loc.f)
-	} else {
-		log.Println("Downloading", "file", loc.u.String())
-		data, err = downloadFile(loc.u.String(), g.ignoreTLS)
+// FaultOperations lists the operations of the port types with fault
+// details by name, the first port type declaring a name wins.
+func (o *Context) FaultOperations(portTypes []*WSDLPortType) (ret []faultOperation) {
+	seen := map[string]bool{}
+	for _, portType := range portTypes {
+		for _, operation := range portType.Operations {
+			if seen[operation.Name] {
+				continue
+			}
+			seen[operation.Name] = true
+			if details := o.FaultDetails(operation); len(details) > 0 {
+				ret = append(ret, faultOperation{Name: operation.Name, Details: details})
+			}
+		}
 	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
 	return
 }
 
-func (g *GoWSDL) unmarshal() error {
-	data, err := g.fetchFile(g.location)
-	if err != nil {
-		return err
+// FaultDetailTypes lists the fault detail elements of the operations of the
+// port types once, sorted by name, for their registration in
+// soap.NamespaceTypes.
+func (o *Context) FaultDetailTypes(portTypes []*WSDLPortType) (ret []faultDetail) {
+	seen := map[xml.Name]bool{}
+	for _, operation := range o.FaultOperations(portTypes) {
+		for _, detail := range operation.Details {
+			if !seen[detail.Name] {
+				seen[detail.Name] = true
+				ret = append(ret, detail)
+			}
+		}
 	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Name.Space != ret[j].Name.Space {
+			return ret[i].Name.Space < ret[j].Name.Space
+		}
+		return ret[i].Name.Local < ret[j].Name.Local
+	})
+	return
+}
 
-	g.wsdl = new(WSDL)
-	err = xml.Unmarshal(data, g.wsdl)
-	if err != nil {
-		return err
+// AttributeName returns the name of the attribute for its xml tag, qualified
+// with the target namespace if the form of the attribute or the
+// attributeFormDefault of the schema is qualified. References to global
+// attributes are qualified with the namespace of the global attribute, those
+// of the XML namespace too, encoding/xml writes them as xml:name.
+func (o *Context) AttributeName(attr *XSDAttribute) string {
+	if local, ok := xmlAttribute(attr.Ref); ok {
+		return xmlNamespace + " " + local
 	}
-	g.rawWSDL = data
-
-	for _, schema := range g.wsdl.Types.Schemas {
-		err = g.resolveXSDExternals(schema, g.location)
-		if err != nil {
-			return err
+	if attr.Ref != "" {
+		// global attributes are in the namespace of their schema
+		namespace, name := o.resolver.toNamespaceAndType(attr.Ref)
+		if namespace == "" {
+			return name
 		}
+		return namespace + " " + name
 	}
+	form := attr.Form
+	if form == "" {
+		form = o.resolver.Schema.AttributeFormDefault
+	}
+	if form != "qualified" || o.getNS() == "" {
+		return attr.Name
+	}
+	return o.getNS() + " " + attr.Name
+}
 
-	return nil
+// RefElementName returns the name of the element an element reference
+// refers to for its xml tag, qualified with its namespace if that differs
+// from the target namespace, which the enclosing element is in.
+func (o *Context) RefElementName(elm *XSDElement) string {
+	namespace, name := o.resolver.toNamespaceAndType(elm.Ref)
+	if namespace == "" || namespace == o.getNS() {
+		return name
+	}
+	return namespace + " " + name
 }
 
-func (g *GoWSDL) resolveXSDExternals(schema *XSDSchema, loc *Location) error {
-	download := func(base *Location, ref string) error {
-		location, err := base.Parse(ref)
-		if err != nil {
-			return err
-		}
-		schemaKey := location.String()
-		if g.resolvedXSDExternals[location.String()] {
-			return nil
-		}
-		if g.resolvedXSDExternals == nil {
-			g.resolvedXSDExternals = make(map[string]bool, maxRecursion)
-		}
-		g.resolvedXSDExternals[schemaKey] = true
+// EnterType marks the global type whose fields are generated next.
+func (o *Context) EnterType(name string) string {
+	o.currentType = name
+	return ""
+}
 
-		var data []byte
-		if data, err = g.fetchFile(location); err != nil {
-			return err
+func (o *Context) isCDATAElement(name string) bool {
+	for _, cdataElement := range o.wsdl.CDATAElements {
+		if cdataElement == name || cdataElement == o.currentType+"."+name {
+			return true
 		}
+	}
+	return false
+}
+
+// ResponseHeaderTypes maps the local names of the response headers the
+// binding declares for the operation to their Go types.
+func (o *Context) ResponseHeaderTypes(operation, portType string) (ret map[string]string) {
+	ret = map[string]string{}
+	for _, header := range o.bindingHeaders(operation, portType, true) {
+		ret[header.Name] = header.Type
+	}
+	return
+}
 
-		newschema := new(XSDSchema)
+// RequestHeaders returns the request headers the binding declares for the
+// operation, in their order.
+func (o *Context) RequestHeaders(operation, portType string) []bindingHeader {
+	return o.bindingHeaders(operation, portType, false)
+}
 
-		err = xml.Unmarshal(data, newschema)
-		if err != nil {
-			return err
-		}
+// bindingHeader is a soap:header element of a binding operation.
+type bindingHeader struct {
+	// Name is the local name of the element.
+	Name    string
+	Type    string
+	Element xml.Name
+}
 
-		if (len(newschema.Includes) > 0 || len(newschema.Imports) > 0) &&
-			maxRecursion > g.currentRecursionLevel {
-			g.currentRecursionLevel++
+// serviceHeader is a request header of a port type, which the generated
+// service sets on its Client with the Setter method.
+type serviceHeader struct {
+	bindingHeader
+	Setter string
+}
 
-			err = g.resolveXSDExternals(newschema, location)
-			if err != nil {
-				return err
+// ServiceHeaders returns the request headers the binding declares for any
+// operation of the port type, in the order of their first declaration.
+func (o *Context) ServiceHeaders(portType *WSDLPortType) (ret []serviceHeader) {
+	seen := map[xml.Name]bool{}
+	for _, operation := range portType.Operations {
+		for _, header := range o.bindingHeaders(operation.Name, portType.Name, false) {
+			if seen[header.Element] {
+				continue
 			}
+			seen[header.Element] = true
+			setter := "With" + makePublic(normalize(o.wsdl.identifier(strings.TrimSuffix(header.Name, "Header")))) + "Header"
+			ret = append(ret, serviceHeader{bindingHeader: header, Setter: setter})
 		}
-
-		g.wsdl.Types.Schemas = append(g.wsdl.Types.Schemas, newschema)
-
-		return nil
 	}
+	return
+}
 
-	for _, impts := range schema.Imports {
-		// Download the file only if we have a hint in the form of schemaLocation.
-		if impts.SchemaLocation == "" {
-			//log.Printf("[DEBUG] Don't know where to find XSD for %s", impts.Label)
+func (o *Context) bindingHeaders(operation, portType string, output bool) (ret []bindingHeader) {
+	for _, binding := range o.wsdl.wsdl.Binding {
+		if strings.ToUpper(stripns(binding.Type)) != strings.ToUpper(portType) {
 			continue
 		}
-
-		if e := download(loc, impts.SchemaLocation); e != nil {
-			return e
-		}
-	}
-
-	for _, incl := range schema.Includes {
-		if e := download(loc, incl.SchemaLocation); e != nil {
-			return e
+		for _, soapOp := range binding.Operations {
+			if soapOp.Name != operation {
+				continue
+			}
+			headers := soapOp.Input.SOAPHeader
+			if output {
+				headers = soapOp.Output.SOAPHeader
+			}
+			for _, header := range headers {
+				message, doc := o.wsdl.wsdl.findMessage(header.Message)
+				if message == nil {
+					continue
+				}
+				for _, part := range message.Parts {
+					if part.Name == header.Part && part.Element != "" {
+						element := o.wsdl.wsdl.rebaseQName(part.Element, doc.Xmlns)
+						ret = append(ret, bindingHeader{Name: stripns(element), Type: o.FindTypeNotNillable(element), Element: doc.qname(part.Element)})
+					}
+				}
+			}
 		}
 	}
-
-	return nil
-}
-
-type Context struct {
-	resolver *NsTypeResolver
-	wsdl     *GoWSDL
-}
-
-func NewContext(wsdl *GoWSDL) (ret *Context) {
-	ret = &Context{wsdl: wsdl}
-	ret.setNS(wsdl.wsdl.TargetNamespace)
 	return
 }
 
-func (o *Context) Log(messages ...string) string {
-	log.Printf("%v: %v\n", o.resolver.Schema.TargetNamespace, messages)
-	return ""
+// MessageElement returns the local name of the wrapper element of the
+// message, the root of its body, or "" for a message of a type.
+func (o *Context) MessageElement(message string) string {
+	msg, _ := o.wsdl.wsdl.findMessage(message)
+	if msg == nil || msg.BodyPart() == nil {
+		return ""
+	}
+	return stripns(msg.BodyPart().Element)
 }
 
-func (o *Context) FindTypeNillable(xsdType string, nillable bool) (ret string) {
-	return o.resolver.FindTypeNillable(xsdType, nillable)
+// MessageElementName returns the qualified name of the body element of the
+// message, which the client and the server name themselves for PlainTypes.
+func (o *Context) MessageElementName(message string) (ret xml.Name) {
+	msg, doc := o.wsdl.wsdl.findMessage(message)
+	if msg == nil || msg.BodyPart() == nil {
+		return
+	}
+	part := msg.BodyPart()
+	if part.Element != "" {
+		return doc.qname(part.Element)
+	}
+	return doc.qname(part.Type)
 }
 
 func (o *Context) FindTypeNotNillable(xsdType string) (ret string) {
@@ -303,6 +1514,11 @@ func (o *Context) setNS(ns string) string {
 	return o.getNS()
 }
 
+// setSchema activates the resolver of the schema, which resolves prefixes with its declarations.
+func (o *Context) setSchema(schema *XSDSchema) {
+	o.resolver = o.wsdl.typeResolver.GetResolverForSchema(schema)
+}
+
 // Method setNS returns the currently active XML namespace.
 func (o *Context) getNS() string {
 	return o.resolver.Schema.TargetNamespace
@@ -323,11 +1539,52 @@ func (o *Context) goImports() (ret string) {
 	return o.resolver.GetGoImports()
 }
 
+// contractType qualifies a Go type of the current package with its package
+// name, for the client generated to ClientPackage.
+func (o *Context) contractType(goType string) string {
+	name := strings.TrimPrefix(goType, "*")
+	if o.wsdl.ClientPackage == "" || name == "" || strings.Contains(name, ".") || isBasicType(name) {
+		return goType
+	}
+	return strings.TrimSuffix(goType, name) + o.goPackage() + "." + name
+}
+
+// clientImports returns the imports of the client generated to
+// ClientPackage, which include the package of the contract.
+func (o *Context) clientImports() string {
+	imports := o.goImports()
+	if o.wsdl.PlainTypes {
+		imports += "\"github.com/hooklift/gowsdl/soap\"\n"
+	}
+	return imports + o.wsdl.typeResolver.importSpec(o.getNS()) + "\n"
+}
+
+// namespaceConst names the generated constant of the current target namespace.
+func (o *Context) namespaceConst() string {
+	namespace := o.getNS()
+	return "Namespace" + normalize(strcase.ToCamel(o.wsdl.typeResolver.NamespaceToFileName[namespace]))
+}
+
 func (g *GoWSDL) genTypes() (err error) {
 	context := NewContext(g)
 	funcMap := template.FuncMap{
 		"log":                      context.Log,
 		"findTypeNillable":         context.FindTypeNillable,
+		"findElementType":          context.FindElementType,
+		"findInlineType":           context.FindInlineType,
+		"nillableDefaults":         context.NillableDefaults,
+		"fieldDefaults":            context.FieldDefaults,
+		"isStructType":             context.IsStructType,
+		"findRefType":              context.FindRefType,
+		"findBaseType":             context.FindBaseType,
+		"attributeName":            context.AttributeName,
+		"refElementName":           context.RefElementName,
+		"deprecated":               g.deprecated,
+		"deprecation":              g.deprecation,
+		"structTag":                g.structTag,
+		"enterType":                context.EnterType,
+		"isBasicType":              isBasicType,
+		"generateGetters":          func() bool { return g.GenerateGetters },
 		"findType":                 context.FindTypeNotNillable,
 		"findTypeName":             context.FindTypeName,
 		"stripns":                  stripns,
@@ -343,15 +1600,26 @@ func (g *GoWSDL) genTypes() (err error) {
 		"getNS":                    context.getNS,
 		"GoPackage":                context.goPackage,
 		"GoImports":                context.goImports,
+		"namespaceConst":           context.namespaceConst,
+		"plainTypes":               func() bool { return g.PlainTypes },
+		"repeatedElements":         repeatedElements,
+		"isRepeated":               func(elm *XSDElement) bool { return elm.repeated() },
+		"validateOccurs":           func() bool { return g.ValidateOccurs },
+		"requiredConstructors":     func() bool { return g.RequiredConstructors },
+		"requiredFields":           context.RequiredFields,
+		"occursChecks":             context.OccursChecks,
 	}
+	g.identifierFuncs(funcMap)
 
 	schemaToContent := map[string]*bytes.Buffer{}
+	schemaToElements := map[string][]string{}
 
 	tmplHeader := template.Must(template.New("TypesHeader").Funcs(funcMap).Parse(schemaHeader))
+	tmplFooter := template.Must(template.New("TypesFooter").Funcs(funcMap).Parse(schemaFooter))
 	tmplBody := template.Must(template.New("TypesBody").Funcs(sprig.FuncMap()).Funcs(funcMap).Parse(schemaTmpl))
 
 	for _, schema := range g.wsdl.Types.Schemas {
-		context.setNS(schema.TargetNamespace)
+		context.setSchema(schema)
 
 		data := schemaToContent[schema.TargetNamespace]
 		if data == nil {
@@ -361,26 +1629,90 @@ func (g *GoWSDL) genTypes() (err error) {
 				return
 			}
 		}
-		if err = tmplBody.Execute(data, schema); err != nil {
+		if err = tmplBody.Execute(data, sortedSchema(schema)); err != nil {
 			return
 		}
+		for _, element := range schema.Elements {
+			schemaToElements[schema.TargetNamespace] = append(schemaToElements[schema.TargetNamespace], element.Name)
+		}
 	}
 
 	for namespace, data := range schemaToContent {
-		if err = g.writeFile("types_", namespace, g.formatSource(data), ""); err != nil {
+		context.setNS(namespace)
+		sort.Strings(schemaToElements[namespace])
+		if err = tmplFooter.Execute(data, schemaToElements[namespace]); err != nil {
+			return
+		}
+		source := g.formatSource(data)
+		if err = g.writeFile("types_", namespace, source, ""); err != nil {
 			return
 		}
+		if g.RoundTripTests {
+			if err = g.genRoundTripTests(namespace, source); err != nil {
+				return
+			}
+		}
 	}
 	return
 }
 
+// sortedSchema returns a copy of schema with its simple types, elements and
+// complex types sorted by name, so the generated types don't move around
+// with the order of the schema.
+func sortedSchema(schema *XSDSchema) *XSDSchema {
+	sorted := *schema
+	sorted.SimpleType = append([]*XSDSimpleType(nil), schema.SimpleType...)
+	sort.SliceStable(sorted.SimpleType, func(i, j int) bool {
+		return sorted.SimpleType[i].Name < sorted.SimpleType[j].Name
+	})
+	sorted.Elements = append([]*XSDElement(nil), schema.Elements...)
+	sort.SliceStable(sorted.Elements, func(i, j int) bool {
+		return sorted.Elements[i].Name < sorted.Elements[j].Name
+	})
+	sorted.ComplexTypes = append([]*XSDComplexType(nil), schema.ComplexTypes...)
+	sort.SliceStable(sorted.ComplexTypes, func(i, j int) bool {
+		return sorted.ComplexTypes[i].Name < sorted.ComplexTypes[j].Name
+	})
+	return &sorted
+}
+
 func (g *GoWSDL) writeFile(localFilePrefix string, targetNamespace string, source []byte, subDir string) (err error) {
-	targetFolder := filepath.Join(g.dir, g.typeResolver.NamespaceToPackageRelative[targetNamespace], subDir)
+	return g.writeFileSuffix(localFilePrefix, targetNamespace, source, subDir, ".go")
+}
+
+// writeFileSuffix is writeFile for a file name ending with suffix instead of .go.
+// FileSuffix goes before the extension, and before _test.go of test files.
+func (g *GoWSDL) writeFileSuffix(localFilePrefix string, targetNamespace string, source []byte, subDir string, suffix string) (err error) {
+	packageDir := filepath.Join(g.typeResolver.NamespaceToPackageRelative[targetNamespace], subDir)
+	extension := ".go"
+	if strings.HasSuffix(suffix, "_test.go") {
+		extension = "_test.go"
+	}
+	suffix = strings.TrimSuffix(suffix, extension) + g.FileSuffix + extension
+	fileName := g.filePrefix + localFilePrefix + g.typeResolver.NamespaceToFileName[targetNamespace] + suffix
+	if g.Output != nil || g.Diff != nil {
+		g.generated = append(g.generated, generatedFile{dir: packageDir, name: fileName, source: source})
+		return nil
+	}
+
+	targetFolder := filepath.Join(g.dir, packageDir)
 	err = os.MkdirAll(targetFolder, 0744)
 
 	var file *os.File
-	targetFile := filepath.Join(targetFolder,
-		g.filePrefix+localFilePrefix+g.typeResolver.NamespaceToFileName[targetNamespace]+".go")
+	targetFile := filepath.Join(targetFolder, fileName)
+
+	if g.KeepExisting || g.BackupExisting {
+		if _, statErr := os.Stat(targetFile); statErr == nil {
+			if !g.BackupExisting {
+				log.Printf("keep existing : %v, %v\n", targetNamespace, targetFile)
+				g.keptFiles = append(g.keptFiles, targetFile)
+				return nil
+			}
+			if err = os.Rename(targetFile, targetFile+".bak"); err != nil {
+				return
+			}
+		}
+	}
 
 	log.Printf("generate : %v, %v\n", targetNamespace, targetFile)
 	if file, err = os.Create(targetFile); err != nil {
@@ -425,28 +1757,114 @@ func NamespaceToFileName(namespace string) (ret string) {
 func (g *GoWSDL) genService() (err error) {
 	context := NewContext(g)
 	funcMap := template.FuncMap{
-		"findTypeNillable":     context.FindTypeNillable,
-		"findType":             context.FindTypeNotNillable,
-		"findTypeName":         context.FindTypeName,
-		"stripns":              stripns,
-		"replaceReservedWords": replaceReservedWords,
-		"normalize":            normalize,
-		"makePublic":           g.makePublicFn,
-		"makePrivate":          makePrivate,
-		"findSOAPAction":       g.findSOAPAction,
-		"findServiceAddress":   g.findServiceAddress,
-		"comment":              comment,
-		"GoPackage":            context.goPackage,
-		"GoImports":            context.goImports,
+		"findTypeNillable":      context.FindTypeNillable,
+		"findType":              context.FindTypeNotNillable,
+		"findTypeName":          context.FindTypeName,
+		"stripns":               stripns,
+		"replaceReservedWords":  replaceReservedWords,
+		"normalize":             normalize,
+		"makePublic":            g.makePublicFn,
+		"makePrivate":           makePrivate,
+		"findSOAPAction":        g.findSOAPAction,
+		"findServiceAddress":    g.findServiceAddress,
+		"soap12":                g.soap12,
+		"unsupportedBinding":    g.unsupportedBinding,
+		"responseHeaderTypes":   context.ResponseHeaderTypes,
+		"requestHeaders":        context.RequestHeaders,
+		"serviceHeaders":        context.ServiceHeaders,
+		"asyncPollOperation":    context.AsyncPollOperation,
+		"genericCalls":          func() bool { return g.GenericCalls },
+		"contextOnlyInterfaces": func() bool { return g.ContextOnlyInterfaces },
+		"deprecated":            g.deprecated,
+		"deprecation":           g.deprecation,
+		"serviceName":           g.serviceName,
+		"serviceIdentifier":     g.serviceIdentifier,
+		"operationInfos":        context.OperationInfos,
+		"faultDetails":          context.FaultDetails,
+		"faultOperations":       context.FaultOperations,
+		"faultDetailTypes":      context.FaultDetailTypes,
+		"securityPolicy":        g.generatedSecurityPolicy,
+		"hoistedNamespaces":     g.hoistedNamespaces,
+		"rootPrefix":            g.rootPrefix,
+		"strictPrefixes":        func() bool { return g.StrictPrefixes },
+		"namespacePrefixes":     g.namespacePrefixes,
+		"faultErrors":           func() map[string]string { return g.FaultErrors },
+		"faultErrorVars":        g.faultErrorVars,
+		"environments":          g.environments,
+		"goString":              goString,
+		"clientPackage":         func() string { return g.ClientPackage },
+		"plainTypes":            func() bool { return g.PlainTypes },
+		"messageElementName":    context.MessageElementName,
+		"contractType":          context.contractType,
+		"comment":               comment,
+		"GoPackage":             context.goPackage,
+		"GoImports":             context.goImports,
+	}
+	g.identifierFuncs(funcMap)
+
+	if g.ClientPackage != "" {
+		data := new(bytes.Buffer)
+		tmpl := template.Must(template.New("Contract").Funcs(funcMap).Parse(contract))
+		template.Must(tmpl.Parse(serviceInterface))
+		if err = tmpl.Execute(data, g.wsdl.PortTypes); err != nil {
+			return
+		}
+		if err = g.writeFile("contract_", g.wsdl.TargetNamespace, g.formatSource(data), ""); err != nil {
+			return
+		}
+
+		// the client refers to the types of the contract from its own package
+		funcMap["findType"] = func(xsdType string) string {
+			return context.contractType(context.FindTypeNotNillable(xsdType))
+		}
+		funcMap["responseHeaderTypes"] = func(operation, portType string) map[string]string {
+			ret := context.ResponseHeaderTypes(operation, portType)
+			for name, goType := range ret {
+				ret[name] = context.contractType(goType)
+			}
+			return ret
+		}
+		funcMap["requestHeaders"] = func(operation, portType string) []bindingHeader {
+			ret := context.RequestHeaders(operation, portType)
+			for i := range ret {
+				ret[i].Type = context.contractType(ret[i].Type)
+			}
+			return ret
+		}
+		funcMap["serviceHeaders"] = func(portType *WSDLPortType) []serviceHeader {
+			ret := context.ServiceHeaders(portType)
+			for i := range ret {
+				ret[i].Type = context.contractType(ret[i].Type)
+			}
+			return ret
+		}
+		funcMap["operationInfos"] = func(portTypes []*WSDLPortType) []operationInfo {
+			ret := context.OperationInfos(portTypes)
+			for i := range ret {
+				ret[i].Request = context.contractType(ret[i].Request)
+				ret[i].Response = context.contractType(ret[i].Response)
+			}
+			return ret
+		}
+		funcMap["faultDetailTypes"] = func(portTypes []*WSDLPortType) []faultDetail {
+			ret := context.FaultDetailTypes(portTypes)
+			for i := range ret {
+				ret[i].Type = context.contractType(ret[i].Type)
+			}
+			return ret
+		}
+		funcMap["GoPackage"] = func() string { return PackageLast(g.ClientPackage) }
+		funcMap["GoImports"] = context.clientImports
 	}
 
 	data := new(bytes.Buffer)
 	tmpl := template.Must(template.New("Service").Funcs(funcMap).Parse(service))
+	template.Must(tmpl.Parse(serviceInterface))
 	if err = tmpl.Execute(data, g.wsdl.PortTypes); err != nil {
 		return
 	}
 
-	err = g.writeFile("service_", g.wsdl.TargetNamespace, g.formatSource(data), "")
+	err = g.writeFile("service_", g.wsdl.TargetNamespace, g.formatSource(data), g.ClientPackage)
 
 	return
 }
@@ -457,6 +1875,7 @@ func (g *GoWSDL) genServer() (err error) {
 		"findTypeNillable":     context.FindTypeNillable,
 		"findType":             context.FindTypeNotNillable,
 		"findTypeName":         context.FindTypeName,
+		"messageElement":       context.MessageElement,
 		"stripns":              stripns,
 		"replaceReservedWords": replaceReservedWords,
 		"makePublic":           g.makePublicFn,
@@ -465,6 +1884,19 @@ func (g *GoWSDL) genServer() (err error) {
 		"comment":              comment,
 		"GoPackage":            context.goPackage,
 		"GoImports":            context.goImports,
+		"plainTypes":           func() bool { return g.PlainTypes },
+		"messageElementName":   context.MessageElementName,
+		"serviceIdentifier":    g.serviceIdentifier,
+	}
+	g.identifierFuncs(funcMap)
+	if g.PlainTypes {
+		// the server is SOAP transport, it goes with the client and refers to
+		// the plain types from there
+		funcMap["findType"] = func(xsdType string) string {
+			return context.contractType(context.FindTypeNotNillable(xsdType))
+		}
+		funcMap["GoPackage"] = func() string { return PackageLast(g.ClientPackage) }
+		funcMap["GoImports"] = context.clientImports
 	}
 
 	data := new(bytes.Buffer)
@@ -476,17 +1908,25 @@ func (g *GoWSDL) genServer() (err error) {
 	tmpl = template.Must(template.New("Server").Funcs(funcMap).Parse(serverTmpl))
 	err = tmpl.Execute(data, g.wsdl.PortTypes)
 
-	err = g.writeFile("server_", g.wsdl.TargetNamespace, g.formatSource(data), "")
+	subDir := ""
+	if g.PlainTypes {
+		subDir = g.ClientPackage
+	}
+	err = g.writeFile("server_", g.wsdl.TargetNamespace, g.formatSource(data), subDir)
 	return
 }
 
 func (g *GoWSDL) genTypeResolver() (err error) {
+	if g.PlainTypes {
+		// the resolvers return the XMLName of the types, plain types have none
+		return
+	}
 	context := NewContext(g)
 	namespaceTypes := g.buildNamespaceTypes(context.goPackage())
 	if len(namespaceTypes) > 0 {
 		funcMap := template.FuncMap{
 			"goPackage":     context.goPackage,
-			"goPackageBase": func() string { return fmt.Sprintf("%v/ws", g.pkg) },
+			"goPackageBase": func() string { return fmt.Sprintf("%v/ws", g.importPath()) },
 			"goImports":     context.goImports,
 		}
 
@@ -509,7 +1949,8 @@ func (g *GoWSDL) buildNamespaceTypes(goPackage string) (ret map[string]map[strin
 
 		types := map[string]string{}
 		for name, goType := range typeResolver.NameToGoType {
-			if len(name) > 0 && !strings.HasPrefix(name, "ArrayOf") && unicode.IsUpper(rune(name[0])) {
+			// the inline enumerations of local elements are no elements to resolve
+			if len(name) > 0 && !strings.HasPrefix(name, "ArrayOf") && unicode.IsUpper(rune(name[0])) && !strings.Contains(name, "/") {
 				types[name] = goType
 			}
 		}
@@ -521,14 +1962,41 @@ func (g *GoWSDL) buildNamespaceTypes(goPackage string) (ret map[string]map[strin
 }
 
 func (g *GoWSDL) formatSource(data *bytes.Buffer) (ret []byte) {
+	// the templates start with a line break, which would otherwise be kept
+	// before the generated code comment if formatting fails
+	data = bytes.NewBuffer(bytes.TrimLeft(data.Bytes(), "\n"))
+	if g.NoLint {
+		data = bytes.NewBuffer(noLintDirective(data.Bytes()))
+	}
+	if g.BuildTag != "" {
+		tagged := bytes.NewBufferString("//go:build " + g.BuildTag + "\n\n")
+		tagged.Write(data.Bytes())
+		data = tagged
+	}
+
 	var err error
-	if ret, err = format.Source(data.Bytes()); err != nil {
-		log.Printf("format err: %v\n", err)
-		ret = data.Bytes()
+	if ret, err = formatGoSource(data.Bytes()); err != nil {
+		log.Printf("organize imports err: %v\n", err)
+		if ret, err = format.Source(data.Bytes()); err != nil {
+			log.Printf("format err: %v\n", err)
+			ret = data.Bytes()
+		}
 	}
 	return
 }
 
+// noLintDirective inserts a //nolint:all line right before the package
+// clause, where golangci-lint applies it to the whole file.
+func noLintDirective(source []byte) []byte {
+	index := bytes.Index(source, []byte("\npackage "))
+	if index < 0 {
+		return source
+	}
+	ret := append([]byte{}, source[:index+1]...)
+	ret = append(ret, "//nolint:all\n"...)
+	return append(ret, source[index+1:]...)
+}
+
 var reservedWords = map[string]string{
 	"break":       "break_",
 	"default":     "default_",
@@ -624,30 +2092,31 @@ func goString(s string) string {
 }
 
 var xsd2GoTypes = map[string]string{
-	"string":        "string",
-	"token":         "string",
-	"float":         "float32",
-	"double":        "float64",
-	"decimal":       "float64",
-	"integer":       "int32",
-	"int":           "int32",
-	"short":         "int16",
-	"byte":          "int8",
-	"long":          "int64",
-	"boolean":       "bool",
-	"datetime":      "soap.XSDDateTime",
-	"date":          "soap.XSDDate",
-	"time":          "soap.XSDTime",
-	"base64binary":  "[]byte",
-	"hexbinary":     "[]byte",
-	"unsignedint":   "uint32",
-	"unsignedshort": "uint16",
-	"unsignedbyte":  "byte",
-	"unsignedlong":  "uint64",
-	"anytype":       "soap.AnyType",
-	"ncname":        "soap.NCName",
-	"anyuri":        "soap.AnyURI",
-	"qname":         "soap.QName",
+	"string":           "string",
+	"token":            "string",
+	"normalizedstring": "string",
+	"float":            "float32",
+	"double":           "float64",
+	"decimal":          "float64",
+	"integer":          "int32",
+	"int":              "int32",
+	"short":            "int16",
+	"byte":             "int8",
+	"long":             "int64",
+	"boolean":          "bool",
+	"datetime":         "soap.XSDDateTime",
+	"date":             "soap.XSDDate",
+	"time":             "soap.XSDTime",
+	"base64binary":     "[]byte",
+	"hexbinary":        "soap.HexBinary",
+	"unsignedint":      "uint32",
+	"unsignedshort":    "uint16",
+	"unsignedbyte":     "byte",
+	"unsignedlong":     "uint64",
+	"anytype":          "soap.AnyType",
+	"ncname":           "soap.NCName",
+	"anyuri":           "soap.AnyURI",
+	"qname":            "soap.QName",
 }
 
 func removeNS(xsdType string) string {
@@ -675,24 +2144,251 @@ func (g *GoWSDL) findSOAPAction(operation, portType string) string {
 
 		for _, soapOp := range binding.Operations {
 			if soapOp.Name == operation {
-				return soapOp.SOAPOperation.SOAPAction
+				if soapOp.SOAPOperation.SOAPAction != "" {
+					return soapOp.SOAPOperation.SOAPAction
+				}
+				return soapOp.SOAP12Operation.SOAPAction
 			}
 		}
 	}
 	return ""
 }
 
+// soap12 reports whether the port type is bound by SOAP 1.2 bindings only,
+// its clients then send SOAP 1.2 envelopes.
+func (g *GoWSDL) soap12(portType string) bool {
+	bound := false
+	for _, binding := range g.wsdl.Binding {
+		if strings.ToUpper(stripns(binding.Type)) != strings.ToUpper(portType) {
+			continue
+		}
+		if binding.SOAP12Binding == nil {
+			return false
+		}
+		bound = true
+	}
+	return bound
+}
+
+// findServiceAddress returns the address of the port named like the port
+// type, else of the first port of a binding of the port type.
 func (g *GoWSDL) findServiceAddress(name string) string {
+	var bound string
 	for _, service := range g.wsdl.Service {
 		for _, port := range service.Ports {
-			if port.Name == name {
-				return port.SOAPAddress.Location
+			address := port.address()
+			if port.Name == name && address != "" {
+				return address
+			}
+			if bound == "" && g.bindingPortType(port.Binding) == name {
+				bound = address
 			}
 		}
 	}
+	return bound
+}
+
+// bindingPortType returns the name of the port type of the binding.
+func (g *GoWSDL) bindingPortType(binding string) string {
+	for _, b := range g.wsdl.Binding {
+		if b.Name == stripns(binding) {
+			return stripns(b.Type)
+		}
+	}
 	return ""
 }
 
+// serviceName returns the name of the first service of the WSDL, empty if
+// it has none.
+func (g *GoWSDL) serviceName() string {
+	if len(g.wsdl.Service) == 0 {
+		return ""
+	}
+	return g.wsdl.Service[0].Name
+}
+
+// serviceIdentifier returns the identifier the package level declarations
+// of the generated service are prefixed with, so that the services of
+// several WSDLs can share a package. It is the name of the first service,
+// else of the first port type.
+func (g *GoWSDL) serviceIdentifier() string {
+	name := g.serviceName()
+	if name == "" && len(g.wsdl.PortTypes) > 0 {
+		name = g.wsdl.PortTypes[0].Name
+	}
+	if name = normalize(g.identifier(name)); name == "" {
+		return ""
+	}
+	return makePublic(name)
+}
+
+// rootPrefix returns the namespace prefix for the request root element of the operation.
+func (g *GoWSDL) rootPrefix(operation string) string {
+	if prefix, ok := g.OperationRootPrefixes[operation]; ok {
+		return prefix
+	}
+	return g.RootPrefix
+}
+
+// faultErrorVar is an error variable generated for FaultErrors.
+type faultErrorVar struct {
+	Name string
+	// Codes are the fault codes mapped to the variable, comma separated.
+	Codes string
+}
+
+// faultErrorVars returns the error variables of FaultErrors sorted by name.
+func (g *GoWSDL) faultErrorVars() (ret []faultErrorVar) {
+	codes := map[string][]string{}
+	for code, name := range g.FaultErrors {
+		codes[name] = append(codes[name], code)
+	}
+	for name, nameCodes := range codes {
+		sort.Strings(nameCodes)
+		ret = append(ret, faultErrorVar{Name: name, Codes: strings.Join(nameCodes, ", ")})
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return
+}
+
+// environment is an entry of Environments and its generated constant,
+// without the prefix of the service.
+type environment struct {
+	Name  string
+	Const string
+	URL   string
+}
+
+// environments returns the Environments sorted by name, failing for names
+// without or with the same constant.
+func (g *GoWSDL) environments() (ret []environment, err error) {
+	var names []string
+	for name := range g.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	consts := map[string]string{}
+	for _, name := range names {
+		constName := "Environment" + normalize(strcase.ToCamel(g.identifier(name)))
+		if constName == "Environment" || !token.IsIdentifier(constName) {
+			return nil, fmt.Errorf("invalid environment name %q", name)
+		}
+		if other, ok := consts[constName]; ok {
+			return nil, fmt.Errorf("environments %q and %q both generate %s", other, name, constName)
+		}
+		consts[constName] = name
+		ret = append(ret, environment{Name: name, Const: constName, URL: g.Environments[name]})
+	}
+	return
+}
+
+// hoistedNamespaces returns the prefix to namespace declarations the generated
+// clients add to the SOAP Envelope, empty unless HoistNamespaces is set.
+func (g *GoWSDL) hoistedNamespaces() (ret map[string]string) {
+	ret = map[string]string{}
+	if !g.HoistNamespaces {
+		return
+	}
+
+	namespaces := map[string]bool{g.wsdl.TargetNamespace: true}
+	for _, schema := range g.wsdl.Types.Schemas {
+		namespaces[schema.TargetNamespace] = true
+	}
+
+	addPrefixes := func(xmlns map[string]string) {
+		for prefix, namespace := range xmlns {
+			if prefix != "" && prefix != "soap" && namespaces[namespace] {
+				if _, ok := ret[prefix]; !ok {
+					ret[prefix] = namespace
+				}
+			}
+		}
+	}
+	addPrefixes(g.wsdl.Xmlns)
+	for _, schema := range g.wsdl.Types.Schemas {
+		addPrefixes(schema.Xmlns)
+	}
+	return
+}
+
+// reservedPrefixes are the prefixes of the envelopes and of XML itself,
+// which the namespaces of the requests can't be bound to.
+var reservedPrefixes = map[string]bool{"soap": true, "env": true, "xml": true, "xmlns": true}
+
+// namespacePrefixes returns the prefixes of the target namespaces the
+// generated service registers, empty unless StrictPrefixes is set.
+func (g *GoWSDL) namespacePrefixes() (ret map[string]string, err error) {
+	ret = map[string]string{}
+	if !g.StrictPrefixes {
+		return
+	}
+
+	configured := make([]string, 0, len(g.NamespacePrefixes))
+	for namespace := range g.NamespacePrefixes {
+		configured = append(configured, namespace)
+	}
+	sort.Strings(configured)
+	bound := map[string]string{}
+	for _, namespace := range configured {
+		prefix := g.NamespacePrefixes[namespace]
+		if reservedPrefixes[prefix] {
+			return nil, fmt.Errorf("prefix %s of namespace %s is reserved", prefix, namespace)
+		}
+		if other, ok := bound[prefix]; ok {
+			return nil, fmt.Errorf("prefix %s is set for namespaces %s and %s", prefix, other, namespace)
+		}
+		bound[prefix] = namespace
+	}
+
+	namespaces := []string{g.wsdl.TargetNamespace}
+	for _, schema := range g.wsdl.Types.Schemas {
+		namespaces = append(namespaces, schema.TargetNamespace)
+	}
+
+	taken := map[string]bool{}
+	assign := func(namespace, prefix string) {
+		if namespace == "" || prefix == "" || taken[prefix] || reservedPrefixes[prefix] {
+			return
+		}
+		if _, ok := ret[namespace]; !ok {
+			ret[namespace] = prefix
+			taken[prefix] = true
+		}
+	}
+	for _, namespace := range configured {
+		assign(namespace, g.NamespacePrefixes[namespace])
+	}
+
+	declared := func(xmlns map[string]string) {
+		prefixes := make([]string, 0, len(xmlns))
+		for prefix := range xmlns {
+			prefixes = append(prefixes, prefix)
+		}
+		sort.Strings(prefixes)
+		for _, namespace := range namespaces {
+			for _, prefix := range prefixes {
+				if xmlns[prefix] == namespace {
+					assign(namespace, prefix)
+				}
+			}
+		}
+	}
+	declared(g.wsdl.Xmlns)
+	for _, schema := range g.wsdl.Types.Schemas {
+		declared(schema.Xmlns)
+	}
+
+	n := 0
+	for _, namespace := range namespaces {
+		for namespace != "" && ret[namespace] == "" {
+			n++
+			assign(namespace, fmt.Sprintf("ns%d", n))
+		}
+	}
+	return
+}
+
 // TODO(c4milo): Add namespace support instead of stripping it
 func stripns(xsdType string) string {
 	r := strings.Split(xsdType, ":")
@@ -718,26 +2414,34 @@ func makePublic(identifier string) string {
 	}
 
 	field[0] = unicode.ToUpper(field[0])
+	if unicode.IsLetter(field[0]) && !unicode.IsUpper(field[0]) {
+		// letters of scripts without case can't start an exported identifier
+		return "X" + string(field)
+	}
 	return string(field)
 }
 
 var basicTypes = map[string]string{
-	"string":      "string",
-	"float32":     "float32",
-	"float64":     "float64",
-	"int":         "int",
-	"int8":        "int8",
-	"int16":       "int16",
-	"int32":       "int32",
-	"int64":       "int64",
-	"bool":        "bool",
-	"time.Time":   "time.Time",
-	"[]byte":      "[]byte",
-	"byte":        "byte",
-	"uint16":      "uint16",
-	"uint32":      "uint32",
-	"uinit64":     "uint64",
-	"interface{}": "interface{}",
+	"string":                "string",
+	"float32":               "float32",
+	"float64":               "float64",
+	"int":                   "int",
+	"int8":                  "int8",
+	"int16":                 "int16",
+	"int32":                 "int32",
+	"int64":                 "int64",
+	"bool":                  "bool",
+	"time.Time":             "time.Time",
+	"[]byte":                "[]byte",
+	"soap.HexBinary":        "soap.HexBinary",
+	"soap.XSDBoolean":       "soap.XSDBoolean",
+	"soap.Token":            "soap.Token",
+	"soap.NormalizedString": "soap.NormalizedString",
+	"byte":                  "byte",
+	"uint16":                "uint16",
+	"uint32":                "uint32",
+	"uinit64":               "uint64",
+	"interface{}":           "interface{}",
 }
 
 func isBasicType(identifier string) bool {
@@ -0,0 +1,2014 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// generateFixture generates code for the WSDL fixture and returns the content of the generated files by their base name.
+func generateFixture(t *testing.T, fixture string, configure func(g *GoWSDL)) map[string]string {
+	dir := t.TempDir()
+	g, err := NewGoWSDL(filepath.Join("fixtures", fixture), "", dir, "gen", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configure != nil {
+		configure(g)
+	}
+	if err = g.Generate(); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	ret := map[string]string{}
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		ret[info.Name()] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ret
+}
+
+// assertMatches fails unless the generated source matches all expressions, ignoring whitespace differences.
+func assertMatches(t *testing.T, source string, expressions ...string) {
+	normalized := strings.Join(strings.Fields(source), " ")
+	for _, expression := range expressions {
+		if !regexp.MustCompile(expression).MatchString(normalized) {
+			t.Errorf("generated code doesn't match %q", expression)
+		}
+	}
+}
+
+func TestDownloadWithAuthentication(t *testing.T) {
+	documents := map[string]string{
+		"/service.wsdl": `<definitions name="Service" targetNamespace="http://example.com/service.wsdl"
+				xmlns="http://schemas.xmlsoap.org/wsdl/" xmlns:xsd="http://www.w3.org/2001/XMLSchema">
+			<types>
+				<xsd:schema targetNamespace="http://example.com/service.wsdl">
+					<xsd:import namespace="http://example.com/types.xsd" schemaLocation="types.xsd"/>
+				</xsd:schema>
+			</types>
+		</definitions>`,
+		"/types.xsd": `<xsd:schema targetNamespace="http://example.com/types.xsd" xmlns:xsd="http://www.w3.org/2001/XMLSchema">
+			<xsd:element name="Ping" type="xsd:string"/>
+		</xsd:schema>`,
+	}
+
+	var requested []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "usr" || pass != "psw" || r.Header.Get("X-Api-Key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		requested = append(requested, r.URL.Path)
+		w.Write([]byte(documents[r.URL.Path]))
+	}))
+	defer ts.Close()
+
+	g, err := NewGoWSDL(ts.URL+"/service.wsdl", "", t.TempDir(), "gen", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.DownloadUser = "usr"
+	g.DownloadPassword = "psw"
+	g.DownloadHeaders = map[string]string{"X-Api-Key": "key"}
+
+	if err = g.unmarshal(); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if len(requested) != 2 {
+		t.Errorf("got requests %v wanted the WSDL and its schema", requested)
+	}
+	if len(g.wsdl.Types.Schemas) != 2 {
+		t.Errorf("got %d schemas wanted 2", len(g.wsdl.Types.Schemas))
+	}
+}
+
+func TestDownloadFollowsRedirects(t *testing.T) {
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/schemas/types.xsd":
+			w.Write([]byte(`<xsd:schema targetNamespace="http://example.com/types.xsd" xmlns:xsd="http://www.w3.org/2001/XMLSchema">
+				<xsd:import namespace="http://example.com/common.xsd" schemaLocation="common.xsd"/>
+				<xsd:element name="Ping" type="xsd:string"/>
+			</xsd:schema>`))
+		case "/schemas/common.xsd":
+			w.Write([]byte(`<xsd:schema targetNamespace="http://example.com/common.xsd" xmlns:xsd="http://www.w3.org/2001/XMLSchema">
+				<xsd:element name="Id" type="xsd:string"/>
+			</xsd:schema>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer cdn.Close()
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/service.wsdl":
+			w.Write([]byte(`<definitions name="Service" targetNamespace="http://example.com/service.wsdl"
+					xmlns="http://schemas.xmlsoap.org/wsdl/" xmlns:xsd="http://www.w3.org/2001/XMLSchema">
+				<types>
+					<xsd:schema targetNamespace="http://example.com/service.wsdl">
+						<xsd:import namespace="http://example.com/types.xsd" schemaLocation="/types.xsd"/>
+					</xsd:schema>
+				</types>
+			</definitions>`))
+		case "/types.xsd":
+			http.Redirect(w, r, cdn.URL+"/schemas/types.xsd", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer origin.Close()
+
+	for _, downloads := range []int{1, 0} {
+		g, err := NewGoWSDL(origin.URL+"/service.wsdl", "", t.TempDir(), "gen", false, true, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.MaxConcurrentDownloads = downloads
+		if err = g.unmarshal(); err != nil {
+			t.Fatalf("unmarshal with %d downloads failed: %v", downloads, err)
+		}
+		if len(g.wsdl.Types.Schemas) != 3 {
+			t.Errorf("got %d schemas with %d downloads wanted 3", len(g.wsdl.Types.Schemas), downloads)
+		}
+	}
+}
+
+func TestConcurrentSchemaDownloads(t *testing.T) {
+	const siblings = 30
+	documents := map[string]string{
+		"/common.xsd": `<xsd:schema targetNamespace="http://example.com/common.xsd" xmlns:xsd="http://www.w3.org/2001/XMLSchema">
+			<xsd:element name="Id" type="xsd:string"/>
+		</xsd:schema>`,
+	}
+	var imports strings.Builder
+	for i := 0; i < siblings; i++ {
+		fmt.Fprintf(&imports, `<xsd:import namespace="http://example.com/part%d.xsd" schemaLocation="part%d.xsd"/>`, i, i)
+		documents[fmt.Sprintf("/part%d.xsd", i)] = fmt.Sprintf(`<xsd:schema targetNamespace="http://example.com/part%d.xsd" xmlns:xsd="http://www.w3.org/2001/XMLSchema">
+			<xsd:import namespace="http://example.com/common.xsd" schemaLocation="common.xsd"/>
+			<xsd:element name="Part%d" type="xsd:string"/>
+		</xsd:schema>`, i, i)
+	}
+	documents["/service.wsdl"] = `<definitions name="Service" targetNamespace="http://example.com/service.wsdl"
+			xmlns="http://schemas.xmlsoap.org/wsdl/" xmlns:xsd="http://www.w3.org/2001/XMLSchema">
+		<types>
+			<xsd:schema targetNamespace="http://example.com/service.wsdl">` + imports.String() + `</xsd:schema>
+		</types>
+	</definitions>`
+
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	requested := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested[r.URL.Path]++
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+		w.Write([]byte(documents[r.URL.Path]))
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	resolve := func(limit int) []string {
+		g, err := NewGoWSDL(ts.URL+"/service.wsdl", "", t.TempDir(), "gen", false, true, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.MaxConcurrentDownloads = limit
+		if err = g.unmarshal(); err != nil {
+			t.Fatalf("unmarshal failed: %v", err)
+		}
+		var namespaces []string
+		for _, schema := range g.wsdl.Types.Schemas {
+			namespaces = append(namespaces, schema.TargetNamespace)
+		}
+		return namespaces
+	}
+
+	concurrent := resolve(4)
+	if len(concurrent) != siblings+2 {
+		t.Errorf("got %d schemas wanted %d", len(concurrent), siblings+2)
+	}
+	for path := range documents {
+		if requested[path] != 1 {
+			t.Errorf("requested %s %d times", path, requested[path])
+		}
+	}
+	if maxInFlight < 2 || maxInFlight > 4 {
+		t.Errorf("got %d concurrent downloads wanted 2 to 4", maxInFlight)
+	}
+
+	maxInFlight = 0
+	sequential := resolve(1)
+	if maxInFlight != 1 {
+		t.Errorf("got %d concurrent downloads wanted 1", maxInFlight)
+	}
+	if strings.Join(concurrent, " ") != strings.Join(sequential, " ") {
+		t.Errorf("got schemas %v wanted the sequential order %v", concurrent, sequential)
+	}
+}
+
+func TestFormatGoSourceOrganizesImports(t *testing.T) {
+	src := []byte(`package gen
+
+import (
+	"github.com/hooklift/gowsdl/soap"
+	"context"
+	"time"
+)
+
+type Ping struct {
+	XMLName xml.Name
+	At      soap.XSDDateTime
+}
+`)
+	ret, err := formatGoSource(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `package gen
+
+import (
+	"encoding/xml"
+
+	"github.com/hooklift/gowsdl/soap"
+)
+
+type Ping struct {
+	XMLName xml.Name
+	At      soap.XSDDateTime
+}
+`
+	if string(ret) != expected {
+		t.Errorf("got\n%s\nwanted\n%s", ret, expected)
+	}
+
+	// identifiers the file declares are no package names, wherever declared
+	src = []byte(`package gen
+
+func Timeout(http struct{ Timeout int }) int {
+	return http.Timeout + time.Minutes
+}
+
+func Kind(v interface{}) string {
+	switch reflect := v.(type) {
+	case fmt.Stringer:
+		return reflect.String()
+	}
+	return ""
+}
+
+var time = struct{ Minutes int }{}
+`)
+	if ret, err = formatGoSource(src); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(ret), "package gen\n\nimport (\n\t\"fmt\"\n)\n") {
+		t.Errorf("got imports other than fmt\n%s", ret)
+	}
+}
+
+func TestGenerateElementNillable(t *testing.T) {
+	files := generateFixture(t, "nillable.wsdl", nil)
+
+	assertMatches(t, files["types_nillable.go"],
+		`Home Address `+"`",
+		`Work \*Address `+"`",
+		`Billing \*Address `+"`",
+		`Age int32 `+"`",
+		`Children \*int32 `+"`",
+		`Name \*string `+"`",
+	)
+}
+
+func TestGenerateCDATAElements(t *testing.T) {
+	files := generateFixture(t, "nillable.wsdl", func(g *GoWSDL) {
+		g.CDATAElements = []string{"GetCustomerResponse.Name", "Street"}
+	})
+
+	assertMatches(t, files["types_nillable.go"],
+		`Name \*soap.CDATAString `+"`",
+		`Street soap.CDATAString `+"`",
+	)
+}
+
+func TestGenerateWSDLImportsAcrossNamespaces(t *testing.T) {
+	files := generateFixture(t, "dotnet/service.wsdl", nil)
+
+	assertMatches(t, files["service_service.go"],
+		`Add\(request \*contract.Add, responseHeader map\[string\]interface\{\}, headers map\[string\]string\) \(\*contract.AddResponse, error\)`,
+		`"http://example.com/calculator/contract/ICalculator/Add"`,
+	)
+	assertMatches(t, files["types_contract.go"], `type AddResponse struct`)
+}
+
+func TestGenerateMetadataExchange(t *testing.T) {
+	files := generateFixture(t, "dotnet/mex.xml", nil)
+
+	assertMatches(t, files["service_service.go"],
+		`Add\(request \*contract.Add, responseHeader map\[string\]interface\{\}, headers map\[string\]string\) \(\*contract.AddResponse, error\)`,
+		`"http://example.com/calculator/contract/ICalculator/Add"`,
+	)
+	assertMatches(t, files["types_contract.go"], `type AddResponse struct`)
+	assertMatches(t, files["server_service.go"], "var wsdl = `<wsdl:definitions name=\"CalculatorService\"")
+	if strings.Count(files["types_contract.go"], "type Add struct") != 1 {
+		t.Error("generated the embedded contract twice")
+	}
+}
+
+func TestGenerateGetters(t *testing.T) {
+	files := generateFixture(t, "nillable.wsdl", func(g *GoWSDL) {
+		g.GenerateGetters = true
+	})
+
+	assertMatches(t, files["types_nillable.go"],
+		`func \(o \*GetCustomer\) GetWork\(\) \*Address \{ if o == nil \{ var zero \*Address return zero \} return o.Work \}`,
+		`func \(o \*GetCustomer\) GetChildren\(\) int32 \{ if o == nil \|\| o.Children == nil \{ var zero int32 return zero \} return \*o.Children \}`,
+		`func \(o \*Address\) GetStreet\(\) string `,
+	)
+}
+
+func TestGenerateRootPrefix(t *testing.T) {
+	files := generateFixture(t, "nillable.wsdl", func(g *GoWSDL) {
+		g.RootPrefix = "tns"
+	})
+	assertMatches(t, files["service_nillable.go"],
+		`service.Client.CallOperationContext\(ctx, CustomerOperationGetCustomer, "http://example.com/nillable/GetCustomer", soap.NewPrefixedContent\("tns", request\), responseHeader, response, headers\)`,
+	)
+
+	files = generateFixture(t, "nillable.wsdl", func(g *GoWSDL) {
+		g.RootPrefix = "tns"
+		g.OperationRootPrefixes = map[string]string{"GetCustomer": ""}
+	})
+	assertMatches(t, files["service_nillable.go"],
+		`service.Client.CallOperationContext\(ctx, CustomerOperationGetCustomer, "http://example.com/nillable/GetCustomer", request, responseHeader, response, headers\)`,
+	)
+}
+
+func TestSkipUnresolvedExternals(t *testing.T) {
+	service := func(elementType string) string {
+		return `<definitions name="Service" targetNamespace="http://example.com/service.wsdl"
+				xmlns="http://schemas.xmlsoap.org/wsdl/" xmlns:xsd="http://www.w3.org/2001/XMLSchema"
+				xmlns:ext="http://example.com/missing.xsd">
+			<types>
+				<xsd:schema targetNamespace="http://example.com/service.wsdl">
+					<xsd:import namespace="http://example.com/missing.xsd" schemaLocation="missing.xsd"/>
+					<xsd:element name="Ping" type="` + elementType + `"/>
+				</xsd:schema>
+			</types>
+		</definitions>`
+	}
+
+	tests := []struct {
+		name     string
+		document string
+		skip     bool
+		wantErr  string
+	}{
+		{name: "fails without skipping", document: service("xsd:string"), wantErr: "404"},
+		{name: "unneeded schema", document: service("xsd:string"), skip: true},
+		{name: "needed schema", document: service("ext:Missing"), skip: true, wantErr: "{http://example.com/missing.xsd}Missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/service.wsdl" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Write([]byte(tt.document))
+			}))
+			defer ts.Close()
+
+			g, err := NewGoWSDL(ts.URL+"/service.wsdl", "", t.TempDir(), "gen", false, true, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.SkipUnresolvedExternals = tt.skip
+
+			err = g.Generate()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("generate failed: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("got error %v wanted %q", err, tt.wantErr)
+			}
+			if tt.skip && len(g.Warnings()) != 1 {
+				t.Errorf("got warnings %v wanted the missing schema", g.Warnings())
+			}
+		})
+	}
+}
+
+func TestGenerateOperationsSubset(t *testing.T) {
+	files := generateFixture(t, "operations.wsdl", func(g *GoWSDL) {
+		g.Operations = []string{"PlaceOrder"}
+	})
+
+	service := files["service_orders.go"]
+	assertMatches(t, service, `PlaceOrderContext\(ctx context.Context, request \*PlaceOrder,`)
+	types := files["types_orders.go"]
+	assertMatches(t, types, `type PlaceOrder struct`, `type OrderLine struct`, `type Item struct`)
+	for _, unreachable := range []string{"CancelOrder", "Reason", "Unused"} {
+		if strings.Contains(service, unreachable) || strings.Contains(types, unreachable) {
+			t.Errorf("generated code contains unreachable %s", unreachable)
+		}
+	}
+
+	g, err := NewGoWSDL(filepath.Join("fixtures", "operations.wsdl"), "", t.TempDir(), "gen", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Operations = []string{"PlaceOrder", "Refund"}
+	if err = g.Generate(); err == nil || !strings.Contains(err.Error(), "Refund") {
+		t.Errorf("got error %v wanted unknown operation Refund", err)
+	}
+}
+
+func TestGenerateClientConfig(t *testing.T) {
+	files := generateFixture(t, "operations.wsdl", func(g *GoWSDL) {
+		g.Operations = []string{"PlaceOrder"}
+	})
+
+	assertMatches(t, files["service_orders.go"],
+		`type OrderSoapClientConfig struct \{ .*Endpoint string .*Timeout time.Duration .*Username string Password string .*InsecureSkipVerify bool \}`,
+		`func NewOrderSoapFromConfig\(config OrderSoapClientConfig\) OrderSoap \{`,
+		`endpoint = "http://example.com/orders/service.asmx"`,
+		`"crypto/tls" "reflect" "time"`,
+	)
+}
+
+func TestGenerateChameleonInclude(t *testing.T) {
+	files := generateFixture(t, "chameleon/service.wsdl", nil)
+
+	assertMatches(t, files["types_prices.go"],
+		`type GetPriceResponse struct \{ XMLName xml.Name Price Money `,
+		`type Currency string`,
+		`type Money struct \{ XMLName xml.Name Amount float64 .* Currency Currency `,
+		`func NewMoneyAs\(tagName string\) \*Money \{ return &Money\{XMLName: xml.Name\{Space: NamespacePrices, Local: tagName\}\} \}`,
+	)
+}
+
+func TestGenerateNamespaceConstants(t *testing.T) {
+	files := generateFixture(t, "chameleon/service.wsdl", nil)
+
+	assertMatches(t, files["types_prices.go"],
+		`const NamespacePrices = "http://example.com/prices"`,
+		`func NewGetPriceAs\(tagName string\) \*GetPrice \{ return &GetPrice\{XMLName: xml.Name\{Space: NamespacePrices, Local: tagName\}\} \}`,
+		`var NamespacePricesElements = map\[string\]string\{ "GetPrice": NamespacePrices, "GetPriceResponse": NamespacePrices, \}`,
+	)
+}
+
+func TestGenerateResponseHeaders(t *testing.T) {
+	files := generateFixture(t, "operations.wsdl", func(g *GoWSDL) {
+		g.Operations = []string{"PlaceOrder"}
+	})
+
+	assertMatches(t, files["service_orders.go"],
+		`func NewOrderSoapPlaceOrderResponseHeader\(\) map\[string\]interface\{\} \{ return map\[string\]interface\{\}\{ "Session": new\(Session\), \} \}`,
+	)
+	assertMatches(t, files["types_orders.go"], `type Session struct`)
+}
+
+func TestGenerateBuildTag(t *testing.T) {
+	files := generateFixture(t, "nillable.wsdl", func(g *GoWSDL) {
+		g.BuildTag = "soap && !test"
+	})
+
+	for name, source := range files {
+		if !strings.HasPrefix(source, "//go:build soap && !test\n\n") {
+			t.Errorf("%s doesn't start with the build constraint:\n%s", name, source)
+		}
+	}
+
+	g, err := NewGoWSDL(filepath.Join("fixtures", "nillable.wsdl"), "", t.TempDir(), "gen", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.BuildTag = "soap &&"
+	if err = g.Generate(); err == nil {
+		t.Error("expected an error for an invalid build tag")
+	}
+}
+
+func TestGenerateNoLint(t *testing.T) {
+	files := generateFixture(t, "nillable.wsdl", func(g *GoWSDL) {
+		g.BuildTag = "soap"
+		g.NoLint = true
+	})
+
+	generated := regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`)
+	for name, source := range files {
+		if !generated.MatchString(source) {
+			t.Errorf("%s lacks the generated code comment:\n%s", name, source)
+		}
+		if !strings.Contains(source, "\n//nolint:all\npackage ") {
+			t.Errorf("%s lacks the nolint directive before the package clause:\n%s", name, source)
+		}
+	}
+}
+
+func TestGenerateCodeGeneratedComment(t *testing.T) {
+	generated := regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+	assertGenerated := func(name, source string) {
+		lines := strings.Split(source, "\n")
+		if strings.HasPrefix(lines[0], "//go:build ") {
+			lines = lines[2:]
+		}
+		if !generated.MatchString(lines[0]) {
+			t.Errorf("%s doesn't start with the generated code comment:\n%s", name, source)
+		}
+	}
+
+	for _, buildTag := range []string{"", "soap"} {
+		files := generateFixture(t, "operations.wsdl", func(g *GoWSDL) {
+			g.BuildTag = buildTag
+			g.ClientPackage = "client"
+			g.RoundTripTests = true
+		})
+		for name, source := range files {
+			assertGenerated(name, source)
+		}
+	}
+
+	g, err := NewGoWSDL(filepath.Join("fixtures", "operations.wsdl"), "", t.TempDir(), "gen", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := new(bytes.Buffer)
+	g.Output = output
+	if err = g.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	assertGenerated("output", output.String())
+}
+
+func TestGenerateStructTags(t *testing.T) {
+	files := generateFixture(t, "defaults.wsdl", func(g *GoWSDL) {
+		g.StructTags = []string{"json", "xml", "yaml"}
+	})
+	assertMatches(t, files["types_search.go"],
+		`Format string `+"`"+`json:"Format,omitempty" xml:"Format,omitempty" yaml:"Format,omitempty"`+"`",
+		`Exact bool `+"`"+`json:"Exact" xml:"Exact" yaml:"Exact"`+"`",
+		`Version string `+"`"+`json:"version,omitempty" xml:"version,attr,omitempty" yaml:"version,omitempty"`+"`",
+	)
+
+	files = generateFixture(t, "defaults.wsdl", func(g *GoWSDL) {
+		g.StructTags = []string{"xml"}
+	})
+	assertMatches(t, files["types_search.go"], `Format string `+"`"+`xml:"Format,omitempty"`+"`")
+
+	files = generateFixture(t, "defaults.wsdl", func(g *GoWSDL) {
+		g.NoOmitEmpty = true
+	})
+	assertMatches(t, files["types_search.go"],
+		`Format string `+"`"+`xml:"Format,omitempty" json:"Format"`+"`",
+		`Version string `+"`"+`xml:"version,attr,omitempty" json:"version"`+"`",
+	)
+
+	for _, tags := range [][]string{{"json"}, {"xml", "xml"}, {"xml", "my tag"}} {
+		g, err := NewGoWSDL(filepath.Join("fixtures", "defaults.wsdl"), "", t.TempDir(), "gen", false, true, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.StructTags = tags
+		if err = g.Generate(); err == nil {
+			t.Errorf("expected an error for the struct tags %q", tags)
+		}
+	}
+}
+
+func TestGenerateRequiredConstructors(t *testing.T) {
+	files := generateFixture(t, "required.wsdl", func(g *GoWSDL) {
+		g.RequiredConstructors = true
+	})
+	assertMatches(t, files["types_orders.go"],
+		`func NewOrderRequired\(id int64, kind string, line \[\]Line, signature \*Signature, channel string\) \*Order \{ o := NewOrder\(\) o.Id = id o.Kind = kind o.Line = line o.Signature = signature o.Channel = channel return o \}`,
+		`func NewLineRequired\(sku string, quantity int32\) \*Line \{`,
+		`func NewPlaceOrderRequired\(order Order\) \*PlaceOrder \{`,
+		`func \(o \*Order\) WithNote\(note string\) \*Order`,
+	)
+	if strings.Contains(files["types_orders.go"], "NewOptionsRequired") {
+		t.Error("generated a Required constructor for a type without required elements")
+	}
+
+	files = generateFixture(t, "required.wsdl", nil)
+	if strings.Contains(files["types_orders.go"], "Required(") {
+		t.Error("generated Required constructors without RequiredConstructors")
+	}
+
+	testGenerated(t, "required.wsdl", "example.com/orders", func(g *GoWSDL) {
+		g.RequiredConstructors = true
+	}, "required_test.go")
+}
+
+func TestGenerateContextOnlyInterfaces(t *testing.T) {
+	files := generateFixture(t, "operations.wsdl", func(g *GoWSDL) {
+		g.ContextOnlyInterfaces = true
+	})
+	assertMatches(t, files["service_orders.go"],
+		`type OrderSoap interface \{ `+
+			`PlaceOrderContext\(ctx context.Context, request \*PlaceOrder, responseHeader map\[string\]interface\{\}, headers map\[string\]string\) \(\*PlaceOrderResponse, error\) `+
+			`CancelOrderContext\(ctx context.Context, request \*CancelOrder, responseHeader map\[string\]interface\{\}, headers map\[string\]string\) \(\*CancelOrderResponse, error\) \}`,
+		`func \(service \*orderSoap\) PlaceOrder\(request \*PlaceOrder, responseHeader map\[string\]interface\{\}, headers map\[string\]string\) \(\*PlaceOrderResponse, error\)`,
+	)
+
+	files = generateFixture(t, "operations.wsdl", func(g *GoWSDL) {
+		g.ContextOnlyInterfaces = true
+		g.ClientPackage = "client"
+	})
+	assertMatches(t, files["contract_orders.go"], `type OrderSoap interface \{ PlaceOrderContext\(ctx context.Context, `)
+	if strings.Contains(files["contract_orders.go"], "PlaceOrder(request") {
+		t.Error("generated the method without context in the contract interface")
+	}
+}
+
+func TestGenerateDeprecations(t *testing.T) {
+	files := generateFixture(t, "deprecated.wsdl", func(g *GoWSDL) {
+		g.DeprecationKeyword = "deprecated"
+	})
+	assertMatches(t, files["service_catalog.go"],
+		`Deprecated: use GetItemV2. \*/ // // Deprecated: use GetItemV2. GetItem\(request \*GetItem,`,
+		`// Deprecated: use GetItemV2. GetItemContext\(ctx context.Context,`,
+		`/\* Returns an item by SKU. Replaces the deprecated GetItem. \*/ GetItemV2\(request`,
+	)
+	assertMatches(t, files["types_catalog.go"],
+		`// Color of an item. DEPRECATED // // Deprecated: the service documents it as deprecated. type Color string`,
+		`// Internal code of the item. Deprecated - use Sku. // // Deprecated: use Sku. Code string`,
+		`// Deprecated: the service documents it as deprecated. Legacy bool`,
+		`// Deprecated: Use Item. type LegacyItem struct`,
+		`// Stock keeping unit, undeprecated since version 2. Sku string`,
+	)
+	// the keyword only marks declarations at the start of a line or sentence
+	if strings.Contains(files["service_catalog.go"], "Deprecated: GetItem") {
+		t.Error("GetItemV2 is marked as deprecated by the GetItem it replaces")
+	}
+
+	files = generateFixture(t, "deprecated.wsdl", nil)
+	assertMatches(t, files["types_catalog.go"],
+		`// Deprecated: Use Item. type LegacyItem struct`,
+		`// Color of an item. DEPRECATED type Color string`,
+	)
+	for _, name := range []string{"service_catalog.go", "types_catalog.go"} {
+		if strings.Contains(files[name], "// Deprecated: use") {
+			t.Errorf("%s marks documentation as deprecated without DeprecationKeyword", name)
+		}
+	}
+}
+
+func TestGenerateFaultDetails(t *testing.T) {
+	files := generateFixture(t, "faults.wsdl", func(g *GoWSDL) {
+		g.FaultDetails = true
+		g.GenericCalls = true
+	})
+	assertMatches(t, files["service_orders.go"],
+		`soap.NamespaceTypes.Register\(xml.Name\{Space: "http://example.com/orders", Local: "InvalidSku"\}, func\(\) interface\{\} \{ return new\(InvalidSku\) \}\)`,
+		`soap.NamespaceTypes.Register\(xml.Name\{Space: "http://example.com/orders", Local: "OutOfStock"\}, func\(\) interface\{\} \{ return new\(OutOfStock\) \}\)`,
+		`OrdersOperationPlaceOrder: \{ \{Space: "http://example.com/orders", Local: "InvalidSku"\}, \{Space: "http://example.com/orders", Local: "OutOfStock"\}, \},`,
+		`err := service.Client.CallOperationContextWithFaultDetails\(ctx, OrdersOperationPlaceOrder, "http://example.com/orders/PlaceOrder", request, responseHeader, response, ordersFaultDetails\[OrdersOperationPlaceOrder\], headers\)`,
+	)
+
+	files = generateFixture(t, "faults.wsdl", nil)
+	if strings.Contains(files["service_orders.go"], "NamespaceTypes") || strings.Contains(files["service_orders.go"], "WithFaultDetails") {
+		t.Error("fault details generated without FaultDetails")
+	}
+
+	testGenerated(t, "faults.wsdl", "example.com/orders", func(g *GoWSDL) {
+		g.FaultDetails = true
+	}, "faultdetails_test.go")
+}
+
+func TestGenerateBlockFinal(t *testing.T) {
+	files := generateFixture(t, "blockfinal.wsdl", nil)
+	assertMatches(t, files["types_shapes.go"],
+		`type Unit string`,
+		`type Figure Shape`,
+		`type GetShape struct \{ XMLName xml.Name Id int32 `+"`"+`xml:"Id,omitempty" json:"Id,omitempty"`+"`"+` \}`,
+		`type GetShapeResponse struct \{ XMLName xml.Name Circle Circle `,
+		`type Circle struct \{ XMLName xml.Name \*Shape Radius float64 `+"`"+`xml:"Radius,omitempty" json:"Radius,omitempty"`+"`"+` Unit Unit `,
+		`type Shape struct \{ XMLName xml.Name Name string `,
+	)
+
+	data, err := os.ReadFile(filepath.Join("fixtures", "blockfinal.wsdl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wsdl WSDL
+	if err = unmarshalDocument(data, &wsdl); err != nil {
+		t.Fatal(err)
+	}
+	schema := wsdl.Types.Schemas[0]
+	if element := schema.Elements[0]; !element.Abstract || element.Block != "extension" || element.Final != "restriction" {
+		t.Errorf("element %s: abstract %v, block %q, final %q", element.Name, element.Abstract, element.Block, element.Final)
+	}
+	if simpleType := schema.SimpleType[0]; simpleType.Final != "list union" {
+		t.Errorf("simple type %s: final %q", simpleType.Name, simpleType.Final)
+	}
+}
+
+func TestGenerateHexBinary(t *testing.T) {
+	files := generateFixture(t, "nillable.wsdl", nil)
+
+	assertMatches(t, files["types_nillable.go"],
+		`Checksum soap.HexBinary `,
+		`type Fingerprint soap.HexBinary`,
+		`func \(hb Fingerprint\) MarshalXML\(e \*xml.Encoder, start xml.StartElement\) error \{ return soap.HexBinary\(hb\).MarshalXML\(e, start\) \}`,
+	)
+}
+
+func TestGenerateInlineEnumerations(t *testing.T) {
+	files := generateFixture(t, "enums.wsdl", nil)
+
+	assertMatches(t, files["types_enums.go"],
+		`const \( ChannelWeb Channel = "web" ChannelInstore Channel = "in-store" \)`,
+		`type Ticket struct \{ XMLName xml.Name Status TicketStatus .* Priority TicketPriority .* Summary string `,
+		`type TicketStatus string const \( TicketStatusOpen TicketStatus = "open" TicketStatusClosed TicketStatus = "closed" \)`,
+		`type TicketPriority string const \( TicketPriorityLow TicketPriority = "low" TicketPriorityHigh TicketPriority = "high" \)`,
+		`Result OpenTicketResponseResult `,
+		`type OpenTicketResponseResult string`,
+	)
+	// the inline enumerations of local elements are registered under the names generated for them
+	for _, name := range []string{"NewStatus", "NewPriority", "NewResult", "NewSummary"} {
+		if strings.Contains(files["typesresolver_enums.go"], name) {
+			t.Errorf("types resolver refers to the inline type of an element with %s", name)
+		}
+	}
+}
+
+func TestGenerateServerFaults(t *testing.T) {
+	testGenerated(t, "operations.wsdl", "example.com/orders", nil, "serverfaults_test.go")
+}
+
+func TestGenerateNoNamespaceSchema(t *testing.T) {
+	files := generateFixture(t, "nonamespace.wsdl", nil)
+
+	assertMatches(t, files["types_gen.go"],
+		`package gen`,
+		`const NamespaceGen = ""`,
+		`type Lookup struct`,
+		`Record Record `+"`"+`xml:"Record,omitempty"`,
+	)
+
+	module := testModule(t)
+	dir := filepath.Join(module, "ws")
+	g, err := NewGoWSDL(filepath.Join("fixtures", "nonamespace.wsdl"), "", dir, "gen", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.ImportPath = "example.com/app/ws"
+	if err = g.Generate(); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "example.com", "legacy", "service_legacy.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertMatches(t, string(data),
+		`import \( .*gen "example.com/app/ws" .*\)`,
+		`LookupContext\(ctx context.Context, request \*gen.Lookup, .*\) \(\*gen.LookupResponse, error\)`,
+	)
+
+	runGo(t, module, "build", "./...")
+}
+
+func TestGenerateRequiredValues(t *testing.T) {
+	files := generateFixture(t, "operations.wsdl", nil)
+	assertMatches(t, files["types_orders.go"],
+		`Customer \*Customer `+"`",
+		`Coupon \*Coupon `+"`",
+		`type OrderLine struct \{ XMLName xml.Name \*Item`,
+	)
+
+	files = generateFixture(t, "operations.wsdl", func(g *GoWSDL) {
+		g.RequiredValues = true
+	})
+	assertMatches(t, files["types_orders.go"],
+		`Customer Customer `+"`",
+		`func \(o \*PlaceOrder\) WithCustomer\(customer Customer\) \*PlaceOrder`,
+		`Coupon \*Coupon `+"`",
+		`type OrderLine struct \{ XMLName xml.Name Item`,
+		`func \(o \*OrderLine\) WithItem\(item Item\) \*OrderLine`,
+	)
+
+	// the XML of the types generated with and without RequiredValues is the same
+	module := testModule(t)
+	for name, requiredValues := range map[string]bool{"pointers": false, "values": true} {
+		g, err := NewGoWSDL(filepath.Join("fixtures", "operations.wsdl"), "", filepath.Join(module, "ws", name), "gen", false, true, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.ImportPath = "example.com/app/ws/" + name
+		g.RequiredValues = requiredValues
+		if err = g.Generate(); err != nil {
+			t.Fatalf("generate failed: %v", err)
+		}
+	}
+	copyGeneratedTest(t, filepath.Join(module, "ws", "wire"), "requiredvalues_test.go")
+	runGo(t, module, "test", "./ws/wire")
+}
+
+func TestGenerateSplitNamespace(t *testing.T) {
+	files := generateFixture(t, "split.wsdl", nil)
+
+	assertMatches(t, files["types_crm.go"],
+		`import \( "encoding/xml" "fmt" "gen/example.com/common" \)`,
+		`type Address struct \{ XMLName xml.Name Street string .* Country Country .* Phone common.Phone `+"`",
+		`type GetCustomerResponse struct \{ XMLName xml.Name Address Address `+"`",
+	)
+	assertMatches(t, files["service_crm.go"],
+		`GetCustomerContext\(ctx context.Context, request \*GetCustomer, .*\) \(\*GetCustomerResponse, error\)`,
+	)
+}
+
+func TestGenerateOperationsMap(t *testing.T) {
+	files := generateFixture(t, "operations.wsdl", nil)
+
+	assertMatches(t, files["service_orders.go"],
+		`var OrderOperations = map\[string\]soap.OperationInfo\{ "CancelOrder": \{ .* \}, "PlaceOrder": \{ Action: "http://example.com/orders/PlaceOrder", `+
+			`Request: reflect.TypeOf\(\(\*PlaceOrder\)\(nil\)\).Elem\(\), Response: reflect.TypeOf\(\(\*PlaceOrderResponse\)\(nil\)\).Elem\(\), \}, \}`,
+		`import \( "context" "crypto/tls" "reflect" "time"`,
+	)
+}
+
+func TestGenerateAttributeUse(t *testing.T) {
+	files := generateFixture(t, "operations.wsdl", nil)
+
+	assertMatches(t, files["types_orders.go"],
+		`Currency string `+"`"+`xml:"Currency,attr" json:"Currency"`+"`",
+		`Note string `+"`"+`xml:"Note,attr,omitempty" json:"Note,omitempty"`+"`",
+		`Discount float64 `+"`"+`xml:"Discount,attr,omitempty" json:"Discount,omitempty"`+"`",
+	)
+}
+
+func TestGenerateXSDBooleans(t *testing.T) {
+	files := generateFixture(t, "operations.wsdl", nil)
+	assertMatches(t, files["types_orders.go"],
+		`type Express bool`,
+		`Gift bool `+"`"+`xml:"Gift,attr" json:"Gift"`+"`",
+		`Confirmed bool `+"`"+`xml:"Confirmed" json:"Confirmed"`+"`",
+	)
+
+	files = generateFixture(t, "operations.wsdl", func(g *GoWSDL) {
+		g.XSDBooleans = true
+	})
+	assertMatches(t, files["types_orders.go"],
+		`type Express soap.XSDBoolean func \(xb Express\) MarshalXML\(e \*xml.Encoder, start xml.StartElement\) error \{ return soap.XSDBoolean\(xb\).MarshalXML\(e, start\) \}`,
+		`Gift soap.XSDBoolean `+"`"+`xml:"Gift,attr" json:"Gift"`+"`",
+		`Confirmed soap.XSDBoolean `+"`"+`xml:"Confirmed" json:"Confirmed"`+"`",
+		`Express Express `+"`"+`xml:"Express,omitempty" json:"Express,omitempty"`+"`",
+	)
+}
+
+func TestGenerateXSDTokens(t *testing.T) {
+	files := generateFixture(t, "tokens.wsdl", nil)
+	assertMatches(t, files["types_registry.go"],
+		`type CountryCode string`,
+		`Name string `+"`"+`xml:"Name,omitempty" json:"Name,omitempty"`+"`",
+		`Address string `+"`"+`xml:"Address,omitempty" json:"Address,omitempty"`+"`",
+		`Code string `+"`"+`xml:"Code,attr,omitempty" json:"Code,omitempty"`+"`",
+	)
+
+	files = generateFixture(t, "tokens.wsdl", func(g *GoWSDL) {
+		g.XSDTokens = true
+	})
+	assertMatches(t, files["types_registry.go"],
+		`type CountryCode soap.Token func \(v CountryCode\) MarshalXML\(e \*xml.Encoder, start xml.StartElement\) error \{ return soap.Token\(v\).MarshalXML\(e, start\) \}`,
+		`func \(v \*CountryCode\) UnmarshalXMLAttr\(attr xml.Attr\) error \{ return \(\*soap.Token\)\(v\).UnmarshalXMLAttr\(attr\) \}`,
+		`CountryCodeDE CountryCode = "DE"`,
+		`Name soap.Token `+"`"+`xml:"Name,omitempty" json:"Name,omitempty"`+"`",
+		`Address soap.NormalizedString `+"`"+`xml:"Address,omitempty" json:"Address,omitempty"`+"`",
+		`Code soap.Token `+"`"+`xml:"Code,attr,omitempty" json:"Code,omitempty"`+"`",
+	)
+}
+
+func TestGenerateValidateOccurs(t *testing.T) {
+	files := generateFixture(t, "occurs.wsdl", nil)
+	assertMatches(t, files["types_contacts.go"],
+		`Phone \[\]string `+"`"+`xml:"Phone,omitempty" json:"Phone,omitempty"`+"`",
+		`Email \[\]string `+"`"+`xml:"Email,omitempty" json:"Email,omitempty"`+"`",
+		`Tag \[\]\*Tag `+"`"+`xml:"Tag,omitempty" json:"Tag,omitempty"`+"`",
+	)
+	if strings.Contains(files["types_contacts.go"], "Validate()") {
+		t.Error("generated Validate without ValidateOccurs")
+	}
+
+	files = generateFixture(t, "occurs.wsdl", func(g *GoWSDL) {
+		g.ValidateOccurs = true
+	})
+	assertMatches(t, files["types_contacts.go"],
+		`func \(o \*Contact\) Validate\(\) error \{ if n := len\(o.Phone\); n < 1 \|\| n > 3 \{ return fmt.Errorf\("invalid Phone of Contact: %d items, expected 1 to 3", n\) \} if n := len\(o.Email\); n > 2 \{`,
+		`func \(o \*UpdateContacts\) Validate\(\) error \{ if n := len\(o.Tag\); n > 4 \{`,
+	)
+	if strings.Contains(files["types_contacts.go"], "func (o *UpdateContactsResponse) Validate") {
+		t.Error("generated Validate for a type without bounded elements")
+	}
+}
+
+func TestGenerateClientPackage(t *testing.T) {
+	files := generateFixture(t, "operations.wsdl", nil)
+	if _, ok := files["contract_orders.go"]; ok {
+		t.Error("generated a contract file without ClientPackage")
+	}
+
+	files = generateFixture(t, "operations.wsdl", func(g *GoWSDL) {
+		g.ClientPackage = "client"
+	})
+	assertMatches(t, files["contract_orders.go"],
+		`package orders import \( "context" \) type OrderSoap interface \{`,
+		`PlaceOrderContext\(ctx context.Context, request \*PlaceOrder, .*\) \(\*PlaceOrderResponse, error\)`,
+	)
+	assertMatches(t, files["service_orders.go"],
+		`package client import \( "context" "crypto/tls" "gen/example.com/orders" "reflect" "time"`,
+		`func NewOrderSoap\(client \*soap.Client\) orders.OrderSoap \{`,
+		`func \(service \*orderSoap\) PlaceOrderContext\(ctx context.Context, request \*orders.PlaceOrder, .*\) \(\*orders.PlaceOrderResponse, error\) \{ response := new\(orders.PlaceOrderResponse\)`,
+		`Request: reflect.TypeOf\(\(\*orders.PlaceOrder\)\(nil\)\).Elem\(\)`,
+	)
+	if strings.Contains(files["service_orders.go"], "interface {") {
+		t.Error("client declares the port type interface")
+	}
+	if strings.Contains(files["types_orders.go"], "gowsdl/soap") {
+		t.Error("contract package imports soap")
+	}
+
+	testGenerated(t, "operations.wsdl", "example.com/orders/client", func(g *GoWSDL) {
+		g.ClientPackage = "client"
+	}, "clientpackage_test.go")
+}
+
+func TestGeneratePlainTypes(t *testing.T) {
+	files := generateFixture(t, "operations.wsdl", func(g *GoWSDL) {
+		g.PlainTypes = true
+		g.XSDBooleans = true
+	})
+	types := files["types_orders.go"]
+	assertMatches(t, types,
+		`type PlaceOrder struct \{ Lines \[\]OrderLine`,
+		`func NewPlaceOrder\(\) \*PlaceOrder \{ return &PlaceOrder\{\} \}`,
+		`type Express bool`,
+		`Gift bool `+"`"+`xml:"Gift,attr" json:"Gift"`+"`",
+	)
+	for _, unwanted := range []string{"gowsdl/soap", "XMLName", "soap.", "NewPlaceOrderAs"} {
+		if strings.Contains(types, unwanted) {
+			t.Errorf("plain types contain %s", unwanted)
+		}
+	}
+	if _, ok := files["typesresolver_orders.go"]; ok {
+		t.Error("generated type resolvers for plain types")
+	}
+
+	assertMatches(t, files["service_orders.go"],
+		`package client import \(`,
+		`"gen/example.com/orders" "reflect" "time" "github.com/hooklift/gowsdl/soap" \)`,
+		`service.Client.CallOperationContext\(ctx, OrderOperationPlaceOrder, "http://example.com/orders/PlaceOrder", soap.NewElement\("http://example.com/orders", "PlaceOrder", request\), responseHeader, response, headers\)`,
+	)
+	assertMatches(t, files["server_orders.go"],
+		`package client`,
+		`PlaceOrder \*orders.PlaceOrderResponse `+"`"+`xml:"http://example.com/orders PlaceOrderResponse,omitempty"`+"`",
+		`func \(service \*SOAPBodyRequest\) PlaceOrderFunc\(request \*orders.PlaceOrder\) \(\*orders.PlaceOrderResponse, error\)`,
+	)
+
+	testGenerated(t, "operations.wsdl", "example.com/orders/client", func(g *GoWSDL) {
+		g.PlainTypes = true
+		g.XSDBooleans = true
+	}, "plaintypes_test.go")
+}
+
+func TestGenerateEnumTextMarshalers(t *testing.T) {
+	files := generateFixture(t, "operations.wsdl", nil)
+	assertMatches(t, files["types_orders.go"],
+		`func \(v Reason\) MarshalText\(\) \(\[\]byte, error\) \{ return \[\]byte\(v\), nil \}`,
+		`func \(v \*Reason\) UnmarshalText\(text \[\]byte\) error \{ switch value := Reason\(text\); value \{ case ReasonDuplicate, ReasonFraud: \*v = value return nil \} return fmt.Errorf\("invalid Reason %q", text\) \}`,
+		`func \(v \*Reason\) UnmarshalXML\(d \*xml.Decoder, start xml.StartElement\) error \{ return d.DecodeElement\(\(\*string\)\(v\), &start\) \}`,
+	)
+	if strings.Contains(files["types_orders.go"], "func (v Express) MarshalText") {
+		t.Error("generated text marshalers for a restriction without enumeration")
+	}
+}
+
+// declarationRecorder records the names of the declarations visited.
+type declarationRecorder struct {
+	visited []string
+}
+
+func (r *declarationRecorder) OnComplexType(item *XSDComplexType) {
+	r.visited = append(r.visited, "complexType "+item.Name)
+}
+
+func (r *declarationRecorder) OnSimpleType(item *XSDSimpleType) {
+	r.visited = append(r.visited, "simpleType "+item.Name)
+}
+
+func (r *declarationRecorder) OnElement(item *XSDElement) {
+	r.visited = append(r.visited, "element "+item.Name)
+}
+
+func (r *declarationRecorder) OnAttribute(item *XSDAttribute) {
+	r.visited = append(r.visited, "attribute "+item.Name+" "+item.Type)
+}
+
+func TestGenerateVisitors(t *testing.T) {
+	recorder := &declarationRecorder{}
+	generateFixture(t, "operations.wsdl", func(g *GoWSDL) {
+		g.Visitors = append(g.Visitors, recorder)
+	})
+
+	visited := strings.Join(recorder.visited, ", ")
+	for _, expected := range []string{
+		"element Sku, attribute Currency s:string, attribute Note s:string, attribute Discount s:decimal, attribute Gift s:boolean, complexType Item",
+		"simpleType Reason",
+		"element Lines, element , element , complexType , element PlaceOrder",
+	} {
+		if !strings.Contains(visited, expected) {
+			t.Errorf("got visits %q wanted %q", visited, expected)
+		}
+	}
+}
+
+func TestGenerateQualifiedAttributes(t *testing.T) {
+	files := generateFixture(t, "qualifiedattributes.wsdl", nil)
+
+	assertMatches(t, files["types_pricing.go"],
+		`Region string `+"`"+`xml:"http://example.com/pricing Region,attr,omitempty" json:"Region,omitempty"`+"`",
+		`Currency string `+"`"+`xml:"http://example.com/pricing Currency,attr" json:"Currency"`+"`",
+		`Scale int32 `+"`"+`xml:"Scale,attr,omitempty" json:"Scale,omitempty"`+"`",
+	)
+	assertMatches(t, files["types_audit.go"],
+		`Author string `+"`"+`xml:"Author,attr,omitempty" json:"Author,omitempty"`+"`",
+		`Reviewer string `+"`"+`xml:"http://example.com/pricing/audit Reviewer,attr,omitempty" json:"Reviewer,omitempty"`+"`",
+		`Tier string `+"`"+`xml:"http://example.com/pricing Tier,attr,omitempty" json:"Tier,omitempty"`+"`",
+	)
+}
+
+func TestGenerateFaultErrors(t *testing.T) {
+	faultErrors := map[string]string{"NotAuthorized": "ErrNotAuthorized", "tns:TokenExpired": "ErrNotAuthorized", "OutOfStock": "ErrOutOfStock"}
+	files := generateFixture(t, "operations.wsdl", func(g *GoWSDL) {
+		g.FaultErrors = faultErrors
+	})
+	assertMatches(t, files["service_orders.go"],
+		`var \( ErrNotAuthorized = errors.New\("NotAuthorized, tns:TokenExpired fault"\) ErrOutOfStock = errors.New\("OutOfStock fault"\) \)`,
+		`var faultErrors = soap.FaultErrors\{ "NotAuthorized": ErrNotAuthorized, "OutOfStock": ErrOutOfStock, "tns:TokenExpired": ErrNotAuthorized, \}`,
+		`if err != nil \{ return nil, faultErrors.Map\(err\) \}`,
+	)
+
+	files = generateFixture(t, "operations.wsdl", func(g *GoWSDL) {
+		g.FaultErrors = faultErrors
+		g.ClientPackage = "client"
+	})
+	assertMatches(t, files["contract_orders.go"],
+		`import \( "context" "errors" \) // Errors .* var \( ErrNotAuthorized = errors.New`,
+	)
+	assertMatches(t, files["service_orders.go"],
+		`var faultErrors = soap.FaultErrors\{ "NotAuthorized": orders.ErrNotAuthorized,`,
+	)
+
+	files = generateFixture(t, "operations.wsdl", nil)
+	if strings.Contains(files["service_orders.go"], "faultErrors") {
+		t.Error("generated fault errors without FaultErrors")
+	}
+}
+
+func TestGenerateFaultErrors_InvalidName(t *testing.T) {
+	g, err := NewGoWSDL(filepath.Join("fixtures", "operations.wsdl"), "", t.TempDir(), "gen", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.FaultErrors = map[string]string{"NotAuthorized": "Err-NotAuthorized"}
+	if err = g.Generate(); err == nil || !strings.Contains(err.Error(), "Err-NotAuthorized") {
+		t.Errorf("got error %v wanted invalid error name", err)
+	}
+}
+
+func TestGenerateNamespacePackages(t *testing.T) {
+	dir := t.TempDir()
+	g, err := NewGoWSDL(filepath.Join("fixtures", "split.wsdl"), "", dir, "gen", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.NamespacePackages = map[string]string{
+		"http://example.com/crm":    "customers",
+		"http://example.com/common": "shared/contact",
+	}
+	if err = g.Generate(); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	read := func(path string) string {
+		data, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	assertMatches(t, read("customers/types_customers.go"),
+		`package customers import \( "encoding/xml" "fmt" "gen/shared/contact" \)`,
+		`const NamespaceCustomers = "http://example.com/crm"`,
+		`Phone contact.Phone `+"`",
+	)
+	assertMatches(t, read("customers/service_customers.go"), `package customers`)
+	assertMatches(t, read("shared/contact/types_contact.go"), `package contact`)
+
+	g.NamespacePackages = map[string]string{"http://example.com/crm": "crm-v2"}
+	if err = g.Generate(); err == nil || !strings.Contains(err.Error(), "crm-v2") {
+		t.Errorf("got error %v wanted invalid package", err)
+	}
+}
+
+func TestModuleImportPath(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("// app\nmodule example.com/app // main module\n\ngo 1.20\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for dir, want := range map[string]string{
+		root:                               "example.com/app",
+		filepath.Join(root, "internal/ws"): "example.com/app/internal/ws",
+	} {
+		got, err := ModuleImportPath(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("got %q wanted %q for %s", got, want, dir)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module \"example.com/quoted\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := ModuleImportPath(filepath.Join(root, "ws")); got != "example.com/quoted/ws" {
+		t.Errorf("got %q wanted the quoted module path", got)
+	}
+}
+
+// testModule creates a module requiring this repository, which generated
+// code builds in without network access. It skips the test without the go
+// tool.
+func testModule(t *testing.T) string {
+	if _, err := exec.LookPath("go"); err != nil || testing.Short() {
+		t.Skip("building the generated code needs the go tool")
+	}
+	repo, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	module := t.TempDir()
+	goMod := "module example.com/app\n\ngo 1.20\n\nrequire github.com/hooklift/gowsdl v0.0.0\n\nreplace github.com/hooklift/gowsdl => " + repo + "\n"
+	if err = os.WriteFile(filepath.Join(module, "go.mod"), []byte(goMod), 0644); err != nil {
+		t.Fatal(err)
+	}
+	goSum, err := os.ReadFile("go.sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(module, "go.sum"), goSum, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return module
+}
+
+// runGo runs the go tool with the arguments in the module, failing the test
+// with its output if it fails.
+func runGo(t *testing.T, module string, args ...string) {
+	// the type resolvers import a ws package which isn't generated
+	filepath.Walk(module, func(path string, info os.FileInfo, err error) error {
+		if err == nil && strings.Contains(info.Name(), "typesresolver_") {
+			os.Remove(path)
+		}
+		return nil
+	})
+	cmd := exec.Command("go", args...)
+	cmd.Dir = module
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GOWORK=off")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("go %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+}
+
+// testGenerated generates the fixture into a test module and runs the tests
+// of the file in testdata/generated with the package generated to pkgDir,
+// like example.com/orders.
+func testGenerated(t *testing.T, fixture, pkgDir string, configure func(g *GoWSDL), test string) {
+	module := testModule(t)
+	dir := filepath.Join(module, "ws")
+	g, err := NewGoWSDL(filepath.Join("fixtures", fixture), "", dir, "gen", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.ImportPath = "example.com/app/ws"
+	if configure != nil {
+		configure(g)
+	}
+	if err = g.Generate(); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	copyGeneratedTest(t, filepath.Join(dir, pkgDir), test)
+	runGo(t, module, "test", "./ws/"+pkgDir)
+}
+
+// copyGeneratedTest copies the test file of testdata/generated to dir.
+func copyGeneratedTest(t *testing.T, dir, test string) {
+	source, err := os.ReadFile(filepath.Join("testdata", "generated", test))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, test), source, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateIntoModule(t *testing.T) {
+	module := testModule(t)
+	dir := filepath.Join(module, "internal", "ws")
+	g, err := NewGoWSDL(filepath.Join("fixtures", "split.wsdl"), "", dir, "gen", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.ImportPath, err = ModuleImportPath(dir); err != nil {
+		t.Fatal(err)
+	}
+	g.NamespacePackages = map[string]string{"http://example.com/common": "shared/contact"}
+	if err = g.Generate(); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "example.com", "crm", "types_crm.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertMatches(t, string(data), `package crm import \( "encoding/xml" "fmt" "example.com/app/internal/ws/shared/contact" \)`)
+
+	runGo(t, module, "build", "./...")
+}
+
+// The package of the schemas without a target namespace is generated to the
+// module root, its name differs from the last element of the import path.
+func TestGenerateNamedImports(t *testing.T) {
+	module := testModule(t)
+	g, err := NewGoWSDL(filepath.Join("fixtures", "nonamespace.wsdl"), "", module, "gen", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.ImportPath, err = ModuleImportPath(module); err != nil {
+		t.Fatal(err)
+	}
+	if err = g.Generate(); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	for _, name := range []string{"service_legacy.go", "server_legacy.go"} {
+		data, err := os.ReadFile(filepath.Join(module, "example.com", "legacy", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertMatches(t, string(data), `import \( .* gen "example.com/app" .*\)`)
+	}
+
+	runGo(t, module, "build", "./...")
+}
+
+func TestGenerateHeaderSetterReplaces(t *testing.T) {
+	testGenerated(t, "headerparts.wsdl", "example.com/ledger", nil, "headers_test.go")
+}
+
+func TestGenerateBodyParts(t *testing.T) {
+	files := generateFixture(t, "headerparts.wsdl", nil)
+	assertMatches(t, files["service_ledger.go"],
+		`PostEntryContext\(ctx context.Context, request \*PostEntry,`,
+		`func WithLedgerSoapPostEntryRequestHeader\(ctx context.Context, authToken \*AuthToken, tenant \*Tenant\) context.Context \{ return soap.WithSOAPHeaders\(ctx, authToken, tenant\) \}`,
+		`type LedgerSoap interface \{ .* WithAuthTokenHeader\(header \*AuthToken\) error .* WithTenantHeader\(header \*Tenant\) error \}`,
+		`func \(service \*ledgerSoap\) WithAuthTokenHeader\(header \*AuthToken\) error \{ name := xml.Name\{Space: "http://example.com/ledger", Local: "AuthToken"\} if header == nil \{ return service.Client.SetHeader\(name, nil\) \} return service.Client.SetHeader\(name, soap.NewElement\(name.Space, name.Local, header\)\) \}`,
+	)
+
+	files = generateFixture(t, "headerparts.wsdl", func(g *GoWSDL) {
+		g.PlainTypes = true
+	})
+	assertMatches(t, files["service_ledger.go"],
+		`return service.Client.SetHeader\(name, soap.NewElement\(name.Space, name.Local, header\)\)`,
+	)
+}
+
+func TestGenerateTypeOrder(t *testing.T) {
+	files := generateFixture(t, "operations.wsdl", nil)
+	if again := generateFixture(t, "operations.wsdl", nil); again["types_orders.go"] != files["types_orders.go"] {
+		t.Error("types differ between runs")
+	}
+	assertMatches(t, files["types_orders.go"],
+		`type Express .* type Reason .* type CancelOrder struct .* type CancelOrderResponse struct .* type Coupon struct .* type Customer struct .* type PlaceOrder struct .* type PlaceOrderResponse struct .* type Session struct .* type Item struct .* type OrderLine struct .* type Unused struct`,
+	)
+}
+
+func TestGenerateOperationNames(t *testing.T) {
+	files := generateFixture(t, "operations.wsdl", nil)
+	assertMatches(t, files["service_orders.go"],
+		`const OrderServiceName = "Order"`,
+		`const \( OrderOperationCancelOrder = "CancelOrder" OrderOperationPlaceOrder = "PlaceOrder" \)`,
+		`var OrderOperations = map\[string\]soap.OperationInfo\{`,
+		`service.Client.CallOperationContext\(ctx, OrderOperationPlaceOrder, "http://example.com/orders/PlaceOrder", request,`,
+	)
+}
+
+func TestGenerateRepeatingCompositors(t *testing.T) {
+	files := generateFixture(t, "repeatingchoice.wsdl", nil)
+	assertMatches(t, files["types_shipping.go"],
+		`type Ship struct \{ XMLName xml.Name Id string .* Choice \[\]ShipChoiceItem `+"`"+`xml:",any" json:"Choice,omitempty"`+"`"+` \}`,
+		`type ShipChoiceItem struct \{ Parcel Parcel .* Pallet Pallet .* Note string .* \}`,
+		`func \(o \*ShipChoiceItem\) UnmarshalXML\(d \*xml.Decoder, start xml.StartElement\) error \{ return soap.UnmarshalChoice\(d, start, o\) \}`,
+		`type Manifest struct \{ XMLName xml.Name Choice \[\]ManifestChoiceItem`,
+		`type Route struct \{ XMLName xml.Name Sequence RouteSequenceItems `+"`"+`xml:",any" json:"Sequence,omitempty"`+"`"+` \}`,
+		`type RouteSequenceItem struct \{ Stop string .* Eta \*soap.XSDDateTime .* \}`,
+		`type RouteSequenceItems \[\]RouteSequenceItem`,
+		`func \(o \*RouteSequenceItems\) UnmarshalXML\(d \*xml.Decoder, start xml.StartElement\) error \{ return soap.UnmarshalSequence\(d, start, o\) \}`,
+		`type Consignment struct \{ XMLName xml.Name \*Parcel Sequence ConsignmentSequenceItems `+"`"+`xml:",any" json:"Sequence,omitempty"`+"`"+` \}`,
+		`type ConsignmentSequenceItem struct \{ Leg string .* Carrier string .* Courier string .* Scan \[\]string .* \}`,
+		`type Bundle struct \{ XMLName xml.Name Choice \[\]BundleChoiceItem `+"`"+`xml:",any" json:"Choice,omitempty"`+"`"+` \}`,
+		`type BundleChoiceItem struct \{ Parcel Parcel .* Note string .* Items \[\]string `+"`"+`xml:",any" json:"items,omitempty"`+"`"+` \}`,
+		`type Extensions struct \{ XMLName xml.Name Items \[\]string `+"`"+`xml:",any" json:"items,omitempty"`+"`"+` \}`,
+	)
+}
+
+func TestGenerateRepeatingSequences(t *testing.T) {
+	testGenerated(t, "repeatingchoice.wsdl", "example.com/shipping", nil, "sequence_test.go")
+}
+
+func TestGenerateGenericCalls(t *testing.T) {
+	files := generateFixture(t, "operations.wsdl", func(g *GoWSDL) {
+		g.GenericCalls = true
+		g.OperationRootPrefixes = map[string]string{"CancelOrder": "ord"}
+	})
+	assertMatches(t, files["service_orders.go"],
+		`response, err := soap.CallOperationTyped\[PlaceOrder, PlaceOrderResponse\]\(ctx, service.Client, OrderOperationPlaceOrder, "http://example.com/orders/PlaceOrder", request, responseHeader, headers\)`,
+		`err := service.Client.CallOperationContext\(ctx, OrderOperationCancelOrder, "http://example.com/orders/CancelOrder", soap.NewPrefixedContent\("ord", request\)`,
+	)
+
+	files = generateFixture(t, "operations.wsdl", nil)
+	if strings.Contains(files["service_orders.go"], "CallOperationTyped") {
+		t.Error("generated typed calls without GenericCalls")
+	}
+}
+
+func TestGenerateStrictPrefixes(t *testing.T) {
+	files := generateFixture(t, "split.wsdl", func(g *GoWSDL) {
+		g.StrictPrefixes = true
+		g.GenericCalls = true
+	})
+	assertMatches(t, files["service_crm.go"],
+		`if err := client.RegisterNamespacePrefix\("http://example.com/common", "x"\); err != nil \{ .* panic\(err\) \} `+
+			`if err := client.RegisterNamespacePrefix\("http://example.com/crm", "tns"\); err != nil \{`,
+		`err := service.Client.CallOperationContext\(ctx, CrmOperationGetCustomer, ".*", soap.NewStrictPrefixedContent\(request\),`,
+	)
+
+	files = generateFixture(t, "split.wsdl", func(g *GoWSDL) {
+		g.StrictPrefixes = true
+		g.NamespacePrefixes = map[string]string{"http://example.com/common": "cmn", "http://example.com/crm": "x"}
+	})
+	assertMatches(t, files["service_crm.go"],
+		`client.RegisterNamespacePrefix\("http://example.com/common", "cmn"\)`,
+		`client.RegisterNamespacePrefix\("http://example.com/crm", "x"\)`,
+	)
+
+	for _, prefixes := range []map[string]string{
+		{"http://example.com/crm": "soap"},
+		{"http://example.com/common": "x", "http://example.com/crm": "x"},
+	} {
+		g, err := NewGoWSDL(filepath.Join("fixtures", "split.wsdl"), "", t.TempDir(), "gen", false, true, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.StrictPrefixes = true
+		g.NamespacePrefixes = prefixes
+		if err = g.Generate(); err == nil || !strings.Contains(err.Error(), "prefix") {
+			t.Errorf("got error %v wanted rejected prefixes %v", err, prefixes)
+		}
+	}
+
+	files = generateFixture(t, "split.wsdl", nil)
+	if strings.Contains(files["service_crm.go"], "RegisterNamespacePrefix") {
+		t.Error("registered prefixes without StrictPrefixes")
+	}
+}
+
+func TestGenerateUnsupportedBindings(t *testing.T) {
+	dir := t.TempDir()
+	g, err := NewGoWSDL(filepath.Join("fixtures", "bindingstyles.wsdl"), "", dir, "gen", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.Generate()
+	if err == nil {
+		t.Fatal("generated rpc and encoded bindings")
+	}
+	for _, want := range []string{
+		"operation GetQuote: binding QuoteSoap uses rpc style and encoded use",
+		"operation GetHistory: binding QuoteSoap uses rpc style",
+		"operation GetPrice: binding QuoteSoap12 uses encoded use",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't contain %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "GetStatus") {
+		t.Errorf("error %q reports the document/literal operation GetStatus", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+		t.Errorf("wrote %d files before failing", len(entries))
+	}
+
+	var allowed *GoWSDL
+	files := generateFixture(t, "bindingstyles.wsdl", func(g *GoWSDL) {
+		g.AllowUnsupportedBindings = true
+		allowed = g
+	})
+	if len(allowed.Warnings()) != 3 {
+		t.Errorf("got warnings %v, wanted one per unsupported operation", allowed.Warnings())
+	}
+	assertMatches(t, files["service_quotes.go"],
+		`// Unsupported: binding QuoteSoap uses rpc style and encoded use, the method is generated as // document/literal .* expects. GetQuote\(`,
+		`// Unsupported: binding QuoteSoap uses rpc style, the method is generated as // document/literal .* expects. GetHistory\(`,
+		`// Unsupported: binding QuoteSoap12 uses encoded use, the method is generated as // document/literal .* expects. GetPrice\(`,
+		`GetHistoryContext\(ctx context.Context, request \*GetHistory, .*\) \(\*GetHistoryResponse, error\) GetStatus\(`,
+	)
+
+	g, err = NewGoWSDL(filepath.Join("fixtures", "operations.wsdl"), "", t.TempDir(), "gen", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = g.Generate(); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if len(g.Warnings()) > 0 {
+		t.Errorf("got warnings %v for document/literal bindings", g.Warnings())
+	}
+}
+
+func TestGenerateMixedOperationStyles(t *testing.T) {
+	g, err := NewGoWSDL(filepath.Join("fixtures", "mixedstyles.wsdl"), "", t.TempDir(), "gen", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.Generate()
+	if err == nil {
+		t.Fatal("generated the rpc operations")
+	}
+	for _, want := range []string{
+		"operation CancelOrder: binding OrdersSoap uses rpc style",
+		"operation Ping: binding OrdersSoap12 uses rpc style",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't contain %q", err, want)
+		}
+	}
+	// document bindings and soap12:operation styles override the binding
+	for _, operation := range []string{"ListOrders", "GetOrder", "Track"} {
+		if strings.Contains(err.Error(), operation) {
+			t.Errorf("error %q reports the document operation %s", err, operation)
+		}
+	}
+
+	var allowed *GoWSDL
+	files := generateFixture(t, "mixedstyles.wsdl", func(g *GoWSDL) {
+		g.AllowUnsupportedBindings = true
+		allowed = g
+	})
+	if len(allowed.Warnings()) != 2 {
+		t.Errorf("got warnings %v, wanted one per rpc operation", allowed.Warnings())
+	}
+	assertMatches(t, files["service_orders.go"],
+		`type OrdersSoap interface \{ ListOrders\(request \*ListOrders,`,
+		`ListOrdersContext\(ctx context.Context, request \*ListOrders, .*\) \(\*ListOrdersResponse, error\) // Unsupported: binding OrdersSoap uses rpc style, the method is generated as // document/literal .* expects. CancelOrder\(`,
+		`CancelOrderContext\(ctx context.Context, request \*CancelOrder, .*\) \(\*CancelOrderResponse, error\) GetOrder\(`,
+		`type OrdersSoap12 interface \{ Track\(request \*Track,`,
+		`TrackContext\(ctx context.Context, request \*Track, .*\) \(\*TrackResponse, error\) // Unsupported: binding OrdersSoap12 uses rpc style, the method is generated as // document/literal .* expects. Ping\(`,
+	)
+}
+
+func TestGenerateXMLNamespaceAttributes(t *testing.T) {
+	// The fixture imports the XML namespace from www.w3.org, which is never fetched.
+	files := generateFixture(t, "xmllang.wsdl", nil)
+	assertMatches(t, files["types_articles.go"],
+		`type Article struct \{ .* Lang string `+"`"+`xml:"http://www.w3.org/XML/1998/namespace lang,attr" json:"lang"`+"`"+` `+
+			`Base string `+"`"+`xml:"http://www.w3.org/XML/1998/namespace base,attr,omitempty" json:"base,omitempty"`+"`"+` `+
+			`Space string `+"`"+`xml:"http://www.w3.org/XML/1998/namespace space,attr,omitempty" json:"space,omitempty"`+"`"+` `+
+			`Id string `+"`"+`xml:"Id,attr,omitempty"`,
+		`type Title struct \{ XMLName xml.Name Value string .* Lang string `+"`"+`xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`,
+	)
+}
+
+func TestGenerateTransliteratedNames(t *testing.T) {
+	files := generateFixture(t, "unicode.wsdl", nil)
+	assertMatches(t, files["types_bestellung.go"],
+		`Prénom string `+"`"+`xml:"Prénom,omitempty"`,
+		`X名前 string `+"`"+`xml:"名前,omitempty"`,
+	)
+
+	files = generateFixture(t, "unicode.wsdl", func(g *GoWSDL) { g.TransliterateNames = true })
+	assertMatches(t, files["types_bestellung.go"],
+		`EtatCreee Etat = "Créée"`,
+		`type Groesse struct \{ XMLName xml.Name Laenge float64 `+"`"+`xml:"Länge,omitempty"`,
+		`Masseinheit string `+"`"+`xml:"Maßeinheit,attr,omitempty"`,
+		`type CommandeDetaillee struct \{ XMLName xml.Name Prenom string `+"`"+`xml:"Prénom,omitempty" json:"Prénom,omitempty"`+"`"+` `+
+			`Strasse string .* Quantite int32 .* Groesse Groesse .* Etat Etat .* X名前 string`,
+		`return NewCommandeDetailleeAs\("CommandeDétaillée"\)`,
+	)
+	assertMatches(t, files["service_bestellung.go"],
+		`BestellungPruefenContext\(ctx context.Context, request \*BestellungPruefen,`,
+	)
+}
+
+func TestGenerateOutput(t *testing.T) {
+	output := new(bytes.Buffer)
+	files := generateFixture(t, "xmllang.wsdl", func(g *GoWSDL) {
+		g.BuildTag = "soap"
+		g.Output = output
+	})
+	if len(files) != 0 {
+		t.Errorf("wrote files %v with Output set", files)
+	}
+	source := output.String()
+	if _, err := parser.ParseFile(token.NewFileSet(), "", source, 0); err != nil {
+		t.Fatalf("output doesn't parse: %v", err)
+	}
+	if strings.Count(source, "package articles") != 1 || strings.Count(source, "import (") != 1 {
+		t.Error("output isn't a single source file")
+	}
+	assertMatches(t, source,
+		`^//go:build soap // Code generated by gowsdl DO NOT EDIT. package articles import \( "context" .* "github.com/hooklift/gowsdl/soap" \)`,
+		`type Article struct`,
+		`type ArticleSoap interface`,
+		`types := ws.NamespacesTypes.Register\("http://example.com/articles"\)`,
+	)
+
+	g, err := NewGoWSDL(filepath.Join("fixtures", "xmllang.wsdl"), "", t.TempDir(), "gen", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.ClientPackage = "client"
+	g.Output = new(bytes.Buffer)
+	if err = g.Generate(); err == nil || !strings.Contains(err.Error(), `"example.com/articles/client"`) {
+		t.Errorf("got error %v wanted the packages the code spans", err)
+	}
+}
+
+func TestGenerateServiceAddresses(t *testing.T) {
+	// The ports are named unlike their port types, the address is found by the binding.
+	files := generateFixture(t, "addresses.wsdl", nil)
+	assertMatches(t, files["service_quotes.go"],
+		`func NewQuoteSoapFromConfig\(config QuoteSoapClientConfig\) QuoteSoap \{ .* endpoint = "http://example.com/quotes/soap" `,
+		`func NewQuoteSoap12FromConfig\(config QuoteSoap12ClientConfig\) QuoteSoap12 \{ .* endpoint = "http://example.com/quotes/soap12" `,
+		`func NewQuoteHttpGetFromConfig\(config QuoteHttpGetClientConfig\) QuoteHttpGet \{ .* endpoint = "http://example.com/quotes/http" `,
+	)
+}
+
+func TestGenerateEnvironments(t *testing.T) {
+	files := generateFixture(t, "addresses.wsdl", func(g *GoWSDL) {
+		g.Environments = map[string]string{"prod": "https://quotes.example.com/ws", "pre-prod": "https://quotes.pre.example.com/ws"}
+	})
+	assertMatches(t, files["service_quotes.go"],
+		`type QuotesEnvironment string const \( QuotesEnvironmentPreProd QuotesEnvironment = "pre-prod" QuotesEnvironmentProd QuotesEnvironment = "prod" \)`,
+		`var QuotesEndpoints = map\[QuotesEnvironment\]string\{ QuotesEnvironmentPreProd: "https://quotes.pre.example.com/ws", QuotesEnvironmentProd: "https://quotes.example.com/ws", \}`,
+		`func NewQuoteSoapForEnvironment\(env QuotesEnvironment, opts \*soap.Options\) \(QuoteSoap, error\) \{ endpoint, ok := QuotesEndpoints\[env\] .* return NewQuoteSoap\(soap.NewClient\(endpoint, opts\)\), nil \}`,
+		`func NewQuoteSoap12ForEnvironment\(env QuotesEnvironment, opts \*soap.Options\) \(QuoteSoap12, error\) \{ .* options.Version = soap.SOAP12 opts = &options`,
+	)
+	testGenerated(t, "addresses.wsdl", "example.com/quotes", func(g *GoWSDL) {
+		g.Environments = map[string]string{"pre-prod": "https://quotes.pre.example.com/ws"}
+	}, "environments_test.go")
+
+	for _, environments := range []map[string]string{{"-": "https://a"}, {"prod": "https://a", "Prod": "https://b"}} {
+		g, err := NewGoWSDL(filepath.Join("fixtures", "addresses.wsdl"), "", t.TempDir(), "gen", false, true, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.Environments = environments
+		if err = g.Generate(); err == nil {
+			t.Errorf("generated environments %v", environments)
+		}
+	}
+}
+
+func TestGenerateCrossNamespaceReferences(t *testing.T) {
+	files := generateFixture(t, "crossnamespace.wsdl", nil)
+	assertMatches(t, files["types_shipping.go"],
+		`Address \*addresses.Address `+"`"+`xml:"http://example.com/addresses Address,omitempty" json:"Address,omitempty"`+"`",
+		`Label \*Label `+"`"+`xml:"Label,omitempty" json:"Label,omitempty"`+"`",
+	)
+}
+
+func TestGenerateMultipleTypesSections(t *testing.T) {
+	files := generateFixture(t, "multipletypes.wsdl", nil)
+	assertMatches(t, files["types_inventory.go"],
+		`type GetStock struct`,
+		`type Stock struct`,
+		`type GetStockResponse struct \{ XMLName xml.Name Stock Stock `,
+	)
+}
+
+func TestGenerateInternalDTD(t *testing.T) {
+	files := generateFixture(t, "dtd.wsdl", nil)
+	assertMatches(t, files["types_inventory.go"],
+		`const NamespaceInventory = "http://example.com/inventory"`,
+		`type GetStock struct \{ XMLName xml.Name Sku string `,
+		`Quantity int32 `,
+	)
+	assertMatches(t, files["service_inventory.go"], `NewInventorySoap\(client \*soap.Client\) InventorySoap`)
+}
+
+func TestGenerateAsyncOperations(t *testing.T) {
+	files := generateFixture(t, "asyncreport.wsdl", func(g *GoWSDL) {
+		g.AsyncOperations = map[string]string{"SubmitReport": "GetReportStatus"}
+	})
+	assertMatches(t, files["service_reports.go"],
+		`func ReportSoapSubmitReportAndWait\(ctx context.Context, service ReportSoap, poller soap.Poller, request \*SubmitReport, pollRequest func\(\*SubmitReportResponse\) \*GetReportStatus, done func\(\*GetReportStatusResponse\) bool\) \(\*GetReportStatusResponse, error\)`,
+		`submitted, err := service.SubmitReportContext\(ctx, request, nil, nil\)`,
+		`polled, err := service.GetReportStatusContext\(ctx, next, nil, nil\)`,
+	)
+	if strings.Contains(files["service_reports.go"], "GetReportStatusAndWait") {
+		t.Error("generated AndWait for the poll operation")
+	}
+
+	g, err := NewGoWSDL(filepath.Join("fixtures", "asyncreport.wsdl"), "", t.TempDir(), "gen", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.AsyncOperations = map[string]string{"SubmitReport": "GetReport"}
+	if err = g.Generate(); err == nil || !strings.Contains(err.Error(), "GetReport") {
+		t.Errorf("got error %v wanted unknown poll operation", err)
+	}
+}
+
+func TestGenerateWrapperElement(t *testing.T) {
+	files := generateFixture(t, "wrapperelement.wsdl", nil)
+	assertMatches(t, files["types_billing.go"],
+		`type SubmitInvoice InvoiceData`,
+		`func NewSubmitInvoice\(\) \*SubmitInvoice \{ return NewSubmitInvoiceAs\("SubmitInvoice"\) \}`,
+		`return &SubmitInvoice\{XMLName: xml.Name\{Space: NamespaceBilling, Local: tagName\}\}`,
+		`type InvoiceAccepted Receipt`,
+	)
+	assertMatches(t, files["service_billing.go"],
+		`Submit\(request \*SubmitInvoice, responseHeader map\[string\]interface\{\}, headers map\[string\]string\) \(\*InvoiceAccepted, error\)`,
+	)
+	assertMatches(t, files["server_billing.go"],
+		`SubmitInvoice \*SubmitInvoice `+"`"+`xml:"SubmitInvoice,omitempty"`+"`",
+	)
+	if strings.Contains(files["service_billing.go"], "InvoiceRequestMessage") {
+		t.Error("request type named after the message")
+	}
+}
+
+func TestGenerateNillableDefaults(t *testing.T) {
+	files := generateFixture(t, "nillabledefault.wsdl", nil)
+	assertMatches(t, files["types_profiles.go"],
+		`Newsletter \*soap.Nillable\[bool\] `,
+		`Language soap.Nillable\[string\] `,
+		`PageSize \*soap.Nillable\[int32\] `,
+		`Theme \*string `,
+		`Timezone string `,
+		`return &UpdateProfile\{XMLName: xml.Name\{Space: NamespaceProfiles, Local: tagName\}, Newsletter: soap.NewNillable\[bool\]\("true"\)\}`,
+		`return &Preferences\{XMLName: xml.Name\{Space: NamespaceProfiles, Local: tagName\}, Language: \*soap.NewNillable\[string\]\("en"\), PageSize: soap.NewNillable\[int32\]\("20"\)\}`,
+	)
+
+	data, err := os.ReadFile(filepath.Join("fixtures", "nillabledefault.wsdl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGoWSDL("-", "", t.TempDir(), "gen", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.WSDLData = []byte(strings.Replace(string(data), `default="20"`, `default="many"`, 1))
+	if err = g.Generate(); err == nil || !strings.Contains(err.Error(), `default "many" of element PageSize`) {
+		t.Errorf("got error %v wanted invalid default", err)
+	}
+}
+
+func TestGenerateFieldDefaults(t *testing.T) {
+	files := generateFixture(t, "defaults.wsdl", nil)
+	assertMatches(t, files["types_search.go"],
+		`const \( SearchOffsetDefault int64 = 0 SearchTraceDefault bool = true \)`,
+		`const \( SearchOptionsFormatDefault string = "json" SearchOptionsPageSizeDefault int32 = 50 SearchOptionsTimeoutDefault float64 = 2.5 `+
+			`SearchOptionsExactDefault bool = false SearchOptionsPriorityDefault Priority = "Normal" SearchOptionsFallbackDefault Priority = "Low" `+
+			`SearchOptionsSeparatorDefault string = " \| " SearchOptionsSortDefault string = "by date" SearchOptionsVersionDefault string = "1.0" SearchOptionsMaxResultsDefault uint16 = 100 \)`,
+		`func DefaultSearchOptions\(\) \*SearchOptions \{ o := NewSearchOptions\(\) o.Format = SearchOptionsFormatDefault .* `+
+			`defaultFallback := SearchOptionsFallbackDefault o.Fallback = &defaultFallback .* o.MaxResults = SearchOptionsMaxResultsDefault return o \}`,
+	)
+	for _, skipped := range []string{"SinceDefault", "LocaleDefault", "TagDefault", "ModeDefault"} {
+		if strings.Contains(files["types_search.go"], skipped) {
+			t.Errorf("generated %s", skipped)
+		}
+	}
+
+	testGenerated(t, "defaults.wsdl", "example.com/search", nil, "defaults_test.go")
+}
+
+// appInfoRecorder records the appinfo annotations of the declarations visited.
+type appInfoRecorder struct {
+	hints []string
+}
+
+func (r *appInfoRecorder) record(kind, name string, appInfo []*XSDAppInfo) {
+	for _, info := range appInfo {
+		r.hints = append(r.hints, kind+" "+name+" "+info.Source+" "+strings.TrimSpace(info.Content))
+	}
+}
+
+func (r *appInfoRecorder) OnComplexType(item *XSDComplexType) {
+	r.record("complexType", item.Name, item.AppInfo)
+}
+
+func (r *appInfoRecorder) OnSimpleType(item *XSDSimpleType) {
+	r.record("simpleType", item.Name, item.AppInfo)
+}
+
+func (r *appInfoRecorder) OnElement(item *XSDElement) {
+	r.record("element", item.Name, item.AppInfo)
+}
+
+func (r *appInfoRecorder) OnAttribute(item *XSDAttribute) {
+	r.record("attribute", item.Name, item.AppInfo)
+}
+
+func TestGenerateAppInfo(t *testing.T) {
+	recorder := &appInfoRecorder{}
+	var g *GoWSDL
+	files := generateFixture(t, "appinfo.wsdl", func(generator *GoWSDL) {
+		g = generator
+		g.Visitors = append(g.Visitors, recorder)
+	})
+
+	assertMatches(t, files["types_appinfo.go"],
+		`// Color of an item. type Color string`,
+		`type Item struct \{ XMLName xml.Name // Display name. Name string `,
+	)
+	for _, ghost := range []string{"Ghost", "Phantom"} {
+		if strings.Contains(files["types_appinfo.go"], ghost) {
+			t.Errorf("generated %s declared in an appinfo", ghost)
+		}
+	}
+
+	hints := strings.Join(recorder.hints, ", ")
+	for _, expected := range []string{
+		`simpleType Color http://example.com/codegen <hint:enum/>`,
+		`element Name http://example.com/codegen <hint:field name="Label"/>`,
+		`attribute Code http://example.com/codegen key`,
+		`element GetItem  <jaxb:class name="GetItemRequest"/>`,
+	} {
+		if !strings.Contains(hints, expected) {
+			t.Errorf("got appinfo %q wanted %q", hints, expected)
+		}
+	}
+
+	schema := g.wsdl.Types.Schemas[0]
+	if schema.ID != "appinfo-schema" || schema.Version != "2.3" {
+		t.Errorf("got schema id %q version %q", schema.ID, schema.Version)
+	}
+	if len(schema.AppInfo) != 1 || !strings.Contains(schema.AppInfo[0].Content, `<jaxb:package name="com.example.appinfo"/>`) {
+		t.Errorf("got schema appinfo %v", schema.AppInfo)
+	}
+	if values := schema.SimpleType[0].Restriction.Enumeration; len(values[0].AppInfo) != 1 || len(values[1].AppInfo) != 0 {
+		t.Errorf("got enumeration appinfo %v", values)
+	}
+}
+
+func TestGenerateKeepExisting(t *testing.T) {
+	dir := t.TempDir()
+	generate := func(configure func(g *GoWSDL)) error {
+		g, err := NewGoWSDL(filepath.Join("fixtures", "test.wsdl"), "", dir, "gen", false, true, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		configure(g)
+		return g.Generate()
+	}
+	if err := generate(func(g *GoWSDL) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	types, err := filepath.Glob(filepath.Join(dir, "www.mnb.hu", "s", "types_*.go"))
+	if err != nil || len(types) != 1 {
+		t.Fatalf("got types files %v, %v", types, err)
+	}
+	edited := []byte("// edited\n")
+	if err = os.WriteFile(types[0], edited, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = generate(func(g *GoWSDL) { g.KeepExisting = true })
+	if err == nil || !strings.Contains(err.Error(), types[0]) {
+		t.Errorf("got error %v wanted the kept %s", err, types[0])
+	}
+	if data, _ := os.ReadFile(types[0]); string(data) != string(edited) {
+		t.Error("overwrote an existing file")
+	}
+
+	if err = generate(func(g *GoWSDL) { g.BackupExisting = true }); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(types[0] + ".bak"); string(data) != string(edited) {
+		t.Errorf("got backup %q", data)
+	}
+	if data, _ := os.ReadFile(types[0]); string(data) == string(edited) {
+		t.Error("didn't overwrite the backed up file")
+	}
+}
+
+func TestGenerateDiff(t *testing.T) {
+	dir := t.TempDir()
+	generate := func(configure func(g *GoWSDL)) error {
+		g, err := NewGoWSDL(filepath.Join("fixtures", "faults.wsdl"), "", dir, "gen", false, true, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		configure(g)
+		return g.Generate()
+	}
+	if err := generate(func(g *GoWSDL) {}); err != nil {
+		t.Fatal(err)
+	}
+	service := filepath.Join(dir, "example.com", "orders", "service_orders.go")
+	written, err := os.ReadFile(service)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff := new(bytes.Buffer)
+	if err = generate(func(g *GoWSDL) { g.Diff = diff }); err != nil {
+		t.Fatalf("got error %v for unchanged files", err)
+	}
+	if diff.Len() > 0 {
+		t.Errorf("got diff for unchanged files:\n%s", diff)
+	}
+
+	err = generate(func(g *GoWSDL) {
+		g.Diff = diff
+		g.FaultDetails = true
+	})
+	if !errors.Is(err, ErrGeneratedDiffers) {
+		t.Errorf("got error %v wanted ErrGeneratedDiffers", err)
+	}
+	assertMatches(t, diff.String(),
+		`^--- `+regexp.QuoteMeta(service)+` \+\+\+ `+regexp.QuoteMeta(service)+` @@ `,
+		`\+var ordersFaultDetails = map\[string\]\[\]xml.Name\{`,
+		`- err := service.Client.CallOperationContext\(ctx, OrdersOperationPlaceOrder,`,
+		`\+ err := service.Client.CallOperationContextWithFaultDetails\(ctx, OrdersOperationPlaceOrder,`,
+	)
+	if data, _ := os.ReadFile(service); string(data) != string(written) {
+		t.Error("wrote a file with Diff")
+	}
+
+	if err = os.Remove(service); err != nil {
+		t.Fatal(err)
+	}
+	diff.Reset()
+	err = generate(func(g *GoWSDL) {
+		g.Diff = diff
+		g.DiffColor = true
+	})
+	if !errors.Is(err, ErrGeneratedDiffers) {
+		t.Errorf("got error %v wanted ErrGeneratedDiffers", err)
+	}
+	if !strings.HasPrefix(diff.String(), "--- "+os.DevNull+"\n+++ "+service+"\n\x1b[36m@@ -0,0 +1,") ||
+		!strings.Contains(diff.String(), "\x1b[32m+package orders\x1b[0m\n") {
+		t.Errorf("got diff of a missing file:\n%s", diff)
+	}
+}
+
+func TestGenerateSOAP12WithoutActions(t *testing.T) {
+	files := generateFixture(t, "soap12noaction.wsdl", nil)
+	assertMatches(t, files["service_weather.go"],
+		`opts := soap.DefaultOptions\(\) opts.Version = soap.SOAP12`,
+		`endpoint = "http://example.com/weather.asmx"`,
+		`CallOperationContext\(ctx, WeatherOperationGetForecast, "", request,`,
+		`CallOperationContext\(ctx, WeatherOperationGetAlerts, "http://example.com/weather/GetAlerts", request,`,
+	)
+
+	files = generateFixture(t, "operations.wsdl", nil)
+	if strings.Contains(files["service_orders.go"], "soap.SOAP12") {
+		t.Error("generated a SOAP 1.2 client for a SOAP 1.1 binding")
+	}
+}
+
+func TestGenerateRoundTripTests(t *testing.T) {
+	files := generateFixture(t, "operations.wsdl", func(g *GoWSDL) {
+		g.RoundTripTests = true
+	})
+	assertMatches(t, files["types_orders_roundtrip_test.go"],
+		`package orders import \( "testing" "github.com/hooklift/gowsdl/soap/soaptest" \)`,
+		`func TestRoundTripItem\(t \*testing.T\) \{ soaptest.RoundTrip\(t, func\(\) interface\{\} \{ return NewItem\(\) \}\) \}`,
+		`func TestRoundTripPlaceOrder\(t \*testing.T\)`,
+	)
+	if strings.Contains(files["types_orders_roundtrip_test.go"], "TestRoundTripReason") {
+		t.Error("generated a round trip test for a simple type")
+	}
+
+	files = generateFixture(t, "operations.wsdl", nil)
+	if _, ok := files["types_orders_roundtrip_test.go"]; ok {
+		t.Error("generated round trip tests without RoundTripTests")
+	}
+}
+
+func TestGenerateFileSuffix(t *testing.T) {
+	files := generateFixture(t, "operations.wsdl", func(g *GoWSDL) {
+		g.FileSuffix = ".gen"
+		g.RoundTripTests = true
+	})
+	for _, name := range []string{"types_orders.gen.go", "service_orders.gen.go", "types_orders_roundtrip.gen_test.go"} {
+		assertMatches(t, files[name], `^// Code generated by gowsdl DO NOT EDIT\. package orders `)
+	}
+	if _, ok := files["types_orders.go"]; ok {
+		t.Error("generated types_orders.go with a file suffix")
+	}
+
+	for _, suffix := range []string{"_test", "_linux", "/gen", ".gen go"} {
+		g, err := NewGoWSDL(filepath.Join("fixtures", "operations.wsdl"), "", t.TempDir(), "gen", false, true, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.FileSuffix = suffix
+		if err = g.Generate(); err == nil || !strings.Contains(err.Error(), "invalid file suffix") {
+			t.Errorf("file suffix %q: got %v", suffix, err)
+		}
+	}
+}
+
+func TestGenerateSecurityPolicies(t *testing.T) {
+	files := generateFixture(t, "policy.wsdl", func(g *GoWSDL) {
+		g.SecurityPolicies = true
+	})
+	service := files["service_accounts.go"]
+	assertMatches(t, service,
+		`var AccountsSoapSecurityPolicy = soap.SecurityPolicy\{ TransportBinding: true, HttpsToken: true, RequireClientCertificate: true, Tokens: \[\]string\{"UsernameToken"\}, IncludeTimestamp: true, Signed: true, \}`,
+		`func NewAccountsSoapWithUsernameToken\(client \*soap.Client, username, password string\) \(AccountsSoap, error\) \{ if err := client.SetSecurityHeader\(soap.NewWSSSecurityHeader\(username, password, "", "1"\)\); err != nil \{`,
+		`var DigestSoapSecurityPolicy = soap.SecurityPolicy\{ Tokens: \[\]string\{"UsernameToken"\}, HashPassword: true, \}`,
+	)
+	for _, unexpected := range []string{"AsymmetricBinding", "X509Token", "NewDigestSoapWithUsernameToken", "OpenSoapSecurityPolicy"} {
+		if strings.Contains(service, unexpected) {
+			t.Errorf("generated %s", unexpected)
+		}
+	}
+
+	files = generateFixture(t, "policy.wsdl", nil)
+	if strings.Contains(files["service_accounts.go"], "SecurityPolicy") {
+		t.Error("generated security policies without SecurityPolicies")
+	}
+
+	testGenerated(t, "policy.wsdl", "example.com/accounts", func(g *GoWSDL) {
+		g.SecurityPolicies = true
+	}, "policy_test.go")
+}
+
+func TestGenerateWSDLDataWithSchemaDir(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("fixtures", "chameleon", "service.wsdl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := generateFixture(t, "-", func(g *GoWSDL) {
+		g.WSDLData = data
+		g.SchemaDir = filepath.Join("fixtures", "chameleon")
+	})
+	assertMatches(t, files["types_prices.go"],
+		`type GetPriceResponse struct \{ XMLName xml.Name Price Money `,
+		`type Money struct \{ XMLName xml.Name Amount float64 .* Currency Currency `,
+	)
+	assertMatches(t, files["server_prices.go"], "var wsdl = `<\\?xml")
+
+	g, err := NewGoWSDL("-", "", t.TempDir(), "gen", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.WSDLData = data
+	if err = g.Generate(); err == nil || !strings.Contains(err.Error(), `no base location to resolve "base.xsd"`) {
+		t.Errorf("generated without SchemaDir: %v", err)
+	}
+}
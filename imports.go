@@ -0,0 +1,151 @@
+package gowsdl
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// knownImports are the packages generated code may reference, by package name.
+var knownImports = map[string]string{
+	"context": "context",
+	"errors":  "errors",
+	"fmt":     "fmt",
+	"http":    "net/http",
+	"reflect": "reflect",
+	"strings": "strings",
+	"time":    "time",
+	"tls":     "crypto/tls",
+	"xml":     "encoding/xml",
+	"soap":    "github.com/hooklift/gowsdl/soap",
+}
+
+// formatGoSource organizes the imports of the generated source and formats it,
+// like goimports does for the packages generated code uses.
+//
+// Imports the source does not reference are dropped, missing known imports are
+// added and the standard library imports are grouped before all others.
+func formatGoSource(src []byte) (ret []byte, err error) {
+	fset := token.NewFileSet()
+	var file *ast.File
+	if file, err = parser.ParseFile(fset, "", src, parser.ParseComments); err != nil {
+		return
+	}
+
+	used := usedPackageNames(file)
+	imports := map[string]*ast.ImportSpec{}
+	var decls []ast.Decl
+	for _, decl := range file.Decls {
+		if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.IMPORT {
+			for _, spec := range genDecl.Specs {
+				importSpec := spec.(*ast.ImportSpec)
+				if used[importName(importSpec)] {
+					imports[importSpec.Path.Value] = importSpec
+				}
+			}
+			continue
+		}
+		decls = append(decls, decl)
+	}
+	file.Decls = decls
+	file.Imports = nil
+
+	imported := map[string]bool{}
+	for _, spec := range imports {
+		imported[importName(spec)] = true
+	}
+	for name := range used {
+		if importPath, ok := knownImports[name]; ok && !imported[name] {
+			imports[strconv.Quote(importPath)] = &ast.ImportSpec{Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(importPath)}}
+		}
+	}
+
+	buffer := new(bytes.Buffer)
+	if err = format.Node(buffer, fset, file); err != nil {
+		return
+	}
+	return format.Source(insertImports(buffer.Bytes(), imports))
+}
+
+// insertImports writes the import block after the package clause, standard
+// library imports first.
+func insertImports(src []byte, imports map[string]*ast.ImportSpec) []byte {
+	if len(imports) == 0 {
+		return src
+	}
+
+	var std, others []string
+	for _, spec := range imports {
+		line := spec.Path.Value
+		if spec.Name != nil {
+			line = spec.Name.Name + " " + line
+		}
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		if strings.Contains(strings.Split(importPath, "/")[0], ".") {
+			others = append(others, line)
+		} else {
+			std = append(std, line)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(others)
+
+	block := new(bytes.Buffer)
+	block.WriteString("\nimport (\n")
+	for _, line := range std {
+		block.WriteString("\t" + line + "\n")
+	}
+	if len(std) > 0 && len(others) > 0 {
+		block.WriteString("\n")
+	}
+	for _, line := range others {
+		block.WriteString("\t" + line + "\n")
+	}
+	block.WriteString(")\n")
+
+	ret := new(bytes.Buffer)
+	inserted := false
+	for _, line := range strings.SplitAfter(string(src), "\n") {
+		ret.WriteString(line)
+		if !inserted && strings.HasPrefix(line, "package ") {
+			ret.Write(block.Bytes())
+			inserted = true
+		}
+	}
+	return ret.Bytes()
+}
+
+// usedPackageNames collects the identifiers used as package qualifiers, blank
+// and dot imports are always considered used. Qualifiers the file declares,
+// in its scope or locally, are no package names, only the identifiers the
+// parser left unresolved are.
+func usedPackageNames(file *ast.File) (ret map[string]bool) {
+	ret = map[string]bool{"_": true, ".": true}
+	unresolved := map[*ast.Ident]bool{}
+	for _, ident := range file.Unresolved {
+		unresolved[ident] = true
+	}
+	ast.Inspect(file, func(node ast.Node) bool {
+		if selector, ok := node.(*ast.SelectorExpr); ok {
+			if ident, ok := selector.X.(*ast.Ident); ok && unresolved[ident] && file.Scope.Lookup(ident.Name) == nil {
+				ret[ident.Name] = true
+			}
+		}
+		return true
+	})
+	return
+}
+
+func importName(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+	importPath, _ := strconv.Unquote(spec.Path.Value)
+	return path.Base(importPath)
+}
@@ -5,8 +5,10 @@
 package gowsdl
 
 import (
+	"fmt"
 	"net/url"
 	"path/filepath"
+	"strings"
 )
 
 // A Location encapsulate information about the location of WSDL/XSD.
@@ -35,8 +37,28 @@ func ParseLocation(rawloc string) (*Location, error) {
 	return &Location{f: absURI}, nil
 }
 
+// parseDirLocation parses a directory or URL as the location of a document
+// in it, which references of the document resolve against.
+func parseDirLocation(rawloc string) (*Location, error) {
+	loc, err := ParseLocation(rawloc)
+	if err != nil {
+		return nil, err
+	}
+	if loc.isURL() {
+		if !strings.HasSuffix(loc.u.Path, "/") {
+			u := *loc.u
+			u.Path += "/"
+			u.RawPath = ""
+			loc.u = &u
+		}
+		return loc, nil
+	}
+	return &Location{f: loc.f + string(filepath.Separator)}, nil
+}
+
 // Parse parses path in the context of the receiver. The provided path may be relative or absolute.
-// Parse returns nil, err on parse failure.
+// Parse returns nil, err on parse failure, and for a relative path if the receiver is empty,
+// the location of a document read from memory.
 func (r *Location) Parse(ref string) (*Location, error) {
 	if r.u != nil {
 		u, err := r.u.Parse(ref)
@@ -56,6 +78,9 @@ func (r *Location) Parse(ref string) (*Location, error) {
 		}
 	}
 
+	if r.f == "" {
+		return nil, fmt.Errorf("no base location to resolve %q against", ref)
+	}
 	return &Location{f: filepath.Join(filepath.Dir(r.f), ref)}, nil
 }
 
@@ -145,3 +145,38 @@ func TestLocation_Parse_FileToURL(t *testing.T) {
 		}
 	}
 }
+
+func TestLocation_ParseDirLocation(t *testing.T) {
+	tests := []struct {
+		dir      string
+		expected string
+	}{
+		{"http://example.org/schemas", "http://example.org/schemas/some.xsd"},
+		{"http://example.org/schemas/", "http://example.org/schemas/some.xsd"},
+		{"fixtures/chameleon", filepath.Join(mustAbs(t, "fixtures/chameleon"), "some.xsd")},
+	}
+	for _, test := range tests {
+		r, err := parseDirLocation(test.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r, err = r.Parse("some.xsd"); err != nil {
+			t.Fatal(err)
+		}
+		if r.String() != test.expected {
+			t.Errorf("%s: got %s wanted %s", test.dir, r.String(), test.expected)
+		}
+	}
+
+	if _, err := new(Location).Parse("some.xsd"); err == nil {
+		t.Error("resolved a relative location without base")
+	}
+}
+
+func mustAbs(t *testing.T, path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return abs
+}
@@ -0,0 +1,124 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Namespaces of WS-MetadataExchange, the 2004 version WCF uses and the W3C
+// recommendation.
+var mexNamespaces = map[string]bool{
+	"http://schemas.xmlsoap.org/ws/2004/09/mex": true,
+	"http://www.w3.org/2011/03/ws-mex":          true,
+}
+
+// mexMetadata is the Metadata of a WS-MetadataExchange response, the
+// documents describing a service as a list of sections.
+type mexMetadata struct {
+	Sections []*mexSection `xml:"MetadataSection"`
+}
+
+// mexSection is a metadata section, which embeds a WSDL or schema or refers
+// to one elsewhere.
+type mexSection struct {
+	Dialect    string     `xml:"Dialect,attr"`
+	Identifier string     `xml:"Identifier,attr"`
+	WSDL       *WSDL      `xml:"http://schemas.xmlsoap.org/wsdl/ definitions"`
+	Schema     *XSDSchema `xml:"http://www.w3.org/2001/XMLSchema schema"`
+	Content    []byte     `xml:",innerxml"`
+}
+
+// unmarshalMetadata decodes data as a WS-MetadataExchange response, either a
+// SOAP envelope with the Metadata as body or the bare Metadata. It returns nil
+// for any other document, like a plain WSDL.
+func unmarshalMetadata(data []byte) (*mexMetadata, error) {
+	d := newDocumentDecoder(data)
+	inBody := false
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case !inBody && start.Name.Local == "Envelope":
+		case !inBody && start.Name.Local == "Header":
+			if err = d.Skip(); err != nil {
+				return nil, err
+			}
+		case !inBody && start.Name.Local == "Body":
+			inBody = true
+		case start.Name.Local == "Metadata" && mexNamespaces[start.Name.Space]:
+			metadata := new(mexMetadata)
+			if err = d.DecodeElement(metadata, &start); err != nil {
+				return nil, err
+			}
+			return metadata, nil
+		default:
+			return nil, nil
+		}
+	}
+}
+
+// loadMetadata loads the WSDL and schema documents embedded in the sections
+// of a WS-MetadataExchange response. The WSDL declaring the services becomes
+// the generated WSDL, the other WSDLs are merged into it like wsdl:imports,
+// which are left out when the response embeds the imported namespace.
+func (g *GoWSDL) loadMetadata(metadata *mexMetadata) error {
+	var wsdls []*WSDL
+	var main *mexSection
+	embedded := map[string]bool{}
+	for _, section := range metadata.Sections {
+		switch {
+		case section.WSDL != nil:
+			wsdls = append(wsdls, section.WSDL)
+			embedded[section.WSDL.TargetNamespace] = true
+			if main == nil || len(main.WSDL.Service) == 0 && len(section.WSDL.Service) > 0 {
+				main = section
+			}
+		case section.Schema != nil:
+			g.wsdl.Types.Schemas = append(g.wsdl.Types.Schemas, section.Schema)
+		case section.Dialect == wsdlNamespace || section.Dialect == xmlschema11:
+			g.warnings = append(g.warnings, fmt.Errorf("metadata section %s %s isn't embedded", section.Dialect, section.Identifier))
+		}
+	}
+	if main == nil {
+		return fmt.Errorf("metadata exchange response without WSDL")
+	}
+
+	schemas := g.wsdl.Types.Schemas
+	g.wsdl = main.WSDL
+	g.wsdl.Types.Schemas = append(g.wsdl.Types.Schemas, schemas...)
+	g.rawWSDL = bytes.TrimSpace(main.Content)
+	for _, wsdl := range wsdls {
+		var imports []*WSDLImport
+		for _, imp := range wsdl.Imports {
+			if !embedded[imp.Namespace] {
+				imports = append(imports, imp)
+			}
+		}
+		wsdl.Imports = imports
+		if wsdl != g.wsdl {
+			g.wsdl.merge(wsdl)
+		}
+	}
+	for _, wsdl := range wsdls {
+		if err := g.resolveWSDLImports(wsdl, g.location); err != nil {
+			return err
+		}
+	}
+	return nil
+}
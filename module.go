@@ -0,0 +1,83 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ModuleImportPath returns the import path of dir, the module path in the
+// go.mod of dir or its closest parent joined with the path of dir in the
+// module. It returns "" if no go.mod is found, dir needn't exist yet.
+func ModuleImportPath(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for root := dir; ; {
+		data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+		if err == nil {
+			module, err := modulePath(data)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", filepath.Join(root, "go.mod"), err)
+			}
+			relative, err := filepath.Rel(root, dir)
+			if err != nil {
+				return "", err
+			}
+			return path.Join(module, filepath.ToSlash(relative)), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			return "", nil
+		}
+		root = parent
+	}
+}
+
+// modulePath returns the path of the module directive of a go.mod file.
+func modulePath(data []byte) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if !strings.HasPrefix(line, "module") {
+			continue
+		}
+		module := strings.TrimSpace(strings.TrimPrefix(line, "module"))
+		if module == line || module == "" {
+			continue
+		}
+		if strings.HasPrefix(module, `"`) || strings.HasPrefix(module, "`") {
+			return strconv.Unquote(module)
+		}
+		return module, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("no module directive")
+}
+
+// importPath returns the import path of the directory generated to.
+func (g *GoWSDL) importPath() string {
+	if g.ImportPath != "" {
+		return g.ImportPath
+	}
+	return g.pkg
+}
@@ -4,18 +4,19 @@
 
 package gowsdl
 
-var service = `
-// Code generated by gowsdl DO NOT EDIT.
-
-package {{GoPackage}}
-
-import (
-	"context"
-	"time"
-	{{GoImports}}
-)
+var serviceInterface = `
+{{define "FaultErrors"}}
+	{{with faultErrorVars}}
+		// Errors the service methods return for the faults of these codes,
+		// errors.As still finds the *soap.Fault.
+		var (
+			{{range .}}{{.Name}} = errors.New("{{goString .Codes}} fault")
+			{{end}}
+		)
+	{{end}}
+{{end}}
 
-{{range .}}
+{{define "Interface"}}
 	{{$privateType := .Name | makePrivate}}
 	{{$exportType := .Name | makePublic}}
 
@@ -31,38 +32,317 @@ import (
 			// Error can be either of the following Types:
 			// {{range .Faults}}
 			//   - {{.Name}} {{.Doc}}{{end}}{{end}}
-			{{if ne .Doc ""}}/* {{.Doc}} */{{end}}
+			{{if ne .Doc ""}}/* {{.Doc}} */{{end}}{{deprecated .Doc nil}}
+			{{with unsupportedBinding .Name $privateType}}// Unsupported: {{.}}, the method is generated as
+			// document/literal and its messages likely don't match what the service expects.
+			{{end -}}
+			{{if not contextOnlyInterfaces -}}
 			{{makePublic .Name | replaceReservedWords}} ({{if ne $requestType ""}}request *{{$requestType}}{{end}}, responseHeader map[string]interface{}, headers map[string]string) ({{if ne $responseType ""}}*{{$responseType}}, {{end}}error)
+			{{- end}}
 			{{/*end*/}}
+			{{if not contextOnlyInterfaces}}{{with deprecation .Doc nil}}// Deprecated: {{.}}{{end}}{{end}}
 			{{makePublic .Name | replaceReservedWords}}Context (ctx context.Context, {{if ne $requestType ""}}request *{{$requestType}}{{end}}, responseHeader map[string]interface{}, headers map[string]string) ({{if ne $responseType ""}}*{{$responseType}}, {{end}}error)
 			{{/*end*/}}
 		{{end}}
+		{{range serviceHeaders .}}
+			// {{.Setter}} sends header as {{.Name}} in the SOAP Header of every
+			// request of the service, replacing the one set before, nil removes it.
+			{{.Setter}}(header *{{.Type}}) error
+		{{end}}
+	}
+{{end}}
+`
+
+var contract = `
+// Code generated by gowsdl DO NOT EDIT.
+
+package {{GoPackage}}
+
+import (
+	"context"
+	{{GoImports}}
+)
+
+{{template "FaultErrors"}}
+
+{{range .}}
+	{{template "Interface" .}}
+{{end}}
+`
+
+var service = `
+// Code generated by gowsdl DO NOT EDIT.
+
+package {{GoPackage}}
+
+import (
+	"context"
+	{{GoImports}}
+)
+
+{{if eq clientPackage ""}}
+	{{template "FaultErrors"}}
+{{end}}
+
+{{with faultErrors}}
+	var faultErrors = soap.FaultErrors{
+		{{range $code, $name := .}}"{{goString $code}}": {{contractType $name}},
+		{{end}}
+	}
+{{end}}
+
+{{with faultDetailTypes .}}
+	func init() {
+		{{range .}}soap.NamespaceTypes.Register(xml.Name{Space: "{{goString .Name.Space}}", Local: "{{goString .Name.Local}}"}, func() interface{} { return new({{.Type}}) })
+		{{end}}
+	}
+{{end}}
+
+{{with faultOperations .}}
+	// {{serviceIdentifier | makePrivate}}FaultDetails are the detail elements of the faults
+	// of the operations, which decode to the types registered in
+	// soap.NamespaceTypes.
+	var {{serviceIdentifier | makePrivate}}FaultDetails = map[string][]xml.Name{
+		{{range .}}{{serviceIdentifier}}Operation{{makePublic .Name | replaceReservedWords}}: {
+			{{range .Details}}{Space: "{{goString .Name.Space}}", Local: "{{goString .Name.Local}}"},
+			{{end}}
+		},
+		{{end}}
+	}
+{{end}}
+
+{{with environments}}
+	{{$service := serviceIdentifier}}
+	// {{$service}}Environment names a deployment of the service, see {{$service}}Endpoints.
+	type {{$service}}Environment string
+
+	const (
+		{{range .}}{{$service}}{{.Const}} {{$service}}Environment = "{{goString .Name}}"
+		{{end}}
+	)
+
+	// {{$service}}Endpoints are the addresses of the service by environment.
+	var {{$service}}Endpoints = map[{{$service}}Environment]string{
+		{{range .}}{{$service}}{{.Const}}: "{{goString .URL}}",
+		{{end}}
 	}
+{{end}}
+
+{{range .}}
+	{{$portType := .Name}}
+	{{$privateType := .Name | makePrivate}}
+	{{$exportType := .Name | makePublic}}
+
+	{{if eq clientPackage ""}}
+		{{template "Interface" .}}
+	{{end}}
 
 	type {{$privateType}} struct {
 		Client *soap.Client
 	}
 
-	func New{{$exportType}}(client *soap.Client) {{$exportType}} {
+	func New{{$exportType}}(client *soap.Client) {{contractType $exportType}} {
+		{{range $prefix, $namespace := hoistedNamespaces}}
+			client.AddNamespace("{{$prefix}}", "{{$namespace}}")
+		{{end}}
+		{{range $namespace, $prefix := namespacePrefixes}}
+			if err := client.RegisterNamespacePrefix("{{$namespace}}", "{{$prefix}}"); err != nil {
+				// another service shares the client with other prefixes
+				panic(err)
+			}
+		{{end}}
 		return &{{$privateType}}{
 			Client: client,
 		}
 	}
 
+	{{with securityPolicy .Name}}
+		// {{$exportType}}SecurityPolicy is the security the WS-SecurityPolicy
+		// of the binding of {{$exportType}} requires.
+		var {{$exportType}}SecurityPolicy = soap.SecurityPolicy{
+			{{- if .TransportBinding}}
+			TransportBinding: true,
+			{{- end}}
+			{{- if .HttpsToken}}
+			HttpsToken: true,
+			{{- end}}
+			{{- if .RequireClientCertificate}}
+			RequireClientCertificate: true,
+			{{- end}}
+			{{- if .SymmetricBinding}}
+			SymmetricBinding: true,
+			{{- end}}
+			{{- if .AsymmetricBinding}}
+			AsymmetricBinding: true,
+			{{- end}}
+			{{- if .Tokens}}
+			Tokens: []string{ {{- range $i, $token := .Tokens}}{{if $i}}, {{end}}"{{$token}}"{{end -}} },
+			{{- end}}
+			{{- if .HashPassword}}
+			HashPassword: true,
+			{{- end}}
+			{{- if .IncludeTimestamp}}
+			IncludeTimestamp: true,
+			{{- end}}
+			{{- if .Signed}}
+			Signed: true,
+			{{- end}}
+			{{- if .Encrypted}}
+			Encrypted: true,
+			{{- end}}
+		}
+
+		{{if and (.RequiresToken "UsernameToken") (not .HashPassword)}}
+			// New{{$exportType}}WithUsernameToken is New{{$exportType}} sending the
+			// WS-Security UsernameToken header its policy requires with every
+			// request, the password in plain text.
+			func New{{$exportType}}WithUsernameToken(client *soap.Client, username, password string) ({{contractType $exportType}}, error) {
+				if err := client.SetSecurityHeader(soap.NewWSSSecurityHeader(username, password, "", "1")); err != nil {
+					return nil, err
+				}
+				return New{{$exportType}}(client), nil
+			}
+		{{end}}
+	{{end}}
+
+	{{range serviceHeaders .}}
+		func (service *{{$privateType}}) {{.Setter}}(header *{{.Type}}) error {
+			name := xml.Name{Space: "{{.Element.Space}}", Local: "{{.Element.Local}}"}
+			if header == nil {
+				return service.Client.SetHeader(name, nil)
+			}
+			return service.Client.SetHeader(name, soap.NewElement(name.Space, name.Local, header))
+		}
+	{{end}}
+
+	// {{$exportType}}ClientConfig holds the common client settings for
+	// New{{$exportType}}FromConfig.
+	type {{$exportType}}ClientConfig struct {
+		// Endpoint is the URL of the service, the address from the WSDL if empty.
+		Endpoint string
+		// Timeout limits the duration of each request, the soap default applies if zero.
+		Timeout time.Duration
+		// Username and Password are sent as HTTP Basic auth if Username is set.
+		Username string
+		Password string
+		// InsecureSkipVerify disables the verification of the server certificate.
+		InsecureSkipVerify bool
+	}
+
+	// New{{$exportType}}FromConfig builds the soap.Client for the config, use
+	// New{{$exportType}} for settings the config doesn't cover.
+	func New{{$exportType}}FromConfig(config {{$exportType}}ClientConfig) {{contractType $exportType}} {
+		opts := soap.DefaultOptions()
+		{{- if soap12 .Name}}
+		opts.Version = soap.SOAP12
+		{{- end}}
+		if config.Timeout > 0 {
+			opts.ConnectionTimeout = config.Timeout
+		}
+		if config.Username != "" {
+			opts.BasicAuth = &soap.BasicAuth{Login: config.Username, Password: config.Password}
+		}
+		if config.InsecureSkipVerify {
+			opts.TlsConfig = &tls.Config{InsecureSkipVerify: true}
+		}
+		endpoint := config.Endpoint
+		if endpoint == "" {
+			endpoint = "{{findServiceAddress .Name}}"
+		}
+		return New{{$exportType}}(soap.NewClient(endpoint, &opts))
+	}
+
+	{{if environments}}
+		// New{{$exportType}}ForEnvironment builds the soap.Client for the
+		// endpoint of the environment, with the default options if opts is nil.
+		func New{{$exportType}}ForEnvironment(env {{serviceIdentifier}}Environment, opts *soap.Options) ({{contractType $exportType}}, error) {
+			endpoint, ok := {{serviceIdentifier}}Endpoints[env]
+			if !ok {
+				return nil, fmt.Errorf("no endpoint for environment %q", env)
+			}
+			{{- if soap12 .Name}}
+			options := soap.DefaultOptions()
+			if opts != nil {
+				options = *opts
+			}
+			options.Version = soap.SOAP12
+			opts = &options
+			{{- end}}
+			return New{{$exportType}}(soap.NewClient(endpoint, opts)), nil
+		}
+	{{end}}
+
 	{{range .Operations}}
 		{{$requestType := findType .Input.Message }}
 		{{$soapAction := findSOAPAction .Name $privateType}}
 		{{$responseType := findType .Output.Message }}
+		{{$rootPrefix := rootPrefix .Name }}
+		{{$faultDetails := faultDetails .}}
+		{{$request := "request"}}
+		{{if plainTypes}}{{with messageElementName .Input.Message}}{{$request = printf "soap.NewElement(%q, %q, request)" .Space .Local}}{{end}}{{end}}
 		func (service *{{$privateType}}) {{makePublic .Name | replaceReservedWords}}Context (ctx context.Context, {{if ne $requestType ""}}request *{{$requestType}}{{end}}, responseHeader map[string]interface{}, headers map[string]string) ({{if ne $responseType ""}}*{{$responseType}}, {{end}}error) {
+			{{if and genericCalls (ne $requestType "") (ne $responseType "") (eq $rootPrefix "") (not plainTypes) (not strictPrefixes) (not $faultDetails) -}}
+			response, err := soap.CallOperationTyped[{{$requestType}}, {{$responseType}}](ctx, service.Client, {{serviceIdentifier}}Operation{{makePublic .Name | replaceReservedWords}}, "{{$soapAction}}", request, responseHeader, headers)
+			{{- else -}}
 			{{if ne $responseType ""}}response := new({{$responseType}}){{end}}
-			err := service.Client.CallContext(ctx, "{{if ne $soapAction ""}}{{$soapAction}}{{else}}''{{end}}", {{if ne $requestType ""}}request{{else}}nil{{end}}, {{if ne $responseType ""}}responseHeader, response{{else}}struct{}{}{{end}}, headers)
+			err := service.Client.CallOperationContext{{if $faultDetails}}WithFaultDetails{{end}}(ctx, {{serviceIdentifier}}Operation{{makePublic .Name | replaceReservedWords}}, "{{$soapAction}}", {{if eq $requestType ""}}nil{{else if strictPrefixes}}soap.NewStrictPrefixedContent({{$request}}){{else if ne $rootPrefix ""}}soap.NewPrefixedContent("{{$rootPrefix}}", {{$request}}){{else}}{{$request}}{{end}}, {{if ne $responseType ""}}responseHeader, response{{else}}struct{}{}{{end}}, {{if $faultDetails}}{{serviceIdentifier | makePrivate}}FaultDetails[{{serviceIdentifier}}Operation{{makePublic .Name | replaceReservedWords}}], {{end}}headers)
+			{{- end}}
 			if err != nil {
-				return {{if ne $responseType ""}}nil, {{end}}err
+				return {{if ne $responseType ""}}nil, {{end}}{{if faultErrors}}faultErrors.Map(err){{else}}err{{end}}
 			}
 
 			return {{if ne $responseType ""}}response, {{end}}nil
 		}
 
+		{{$responseHeaders := responseHeaderTypes .Name $exportType}}
+		{{if $responseHeaders}}
+			// New{{$exportType}}{{makePublic .Name}}ResponseHeader returns a responseHeader
+			// for {{makePublic .Name | replaceReservedWords}} which decodes the headers the binding declares.
+			func New{{$exportType}}{{makePublic .Name}}ResponseHeader() map[string]interface{} {
+				return map[string]interface{}{
+					{{range $name, $type := $responseHeaders}}"{{$name}}": new({{$type}}),
+					{{end}}
+				}
+			}
+		{{end}}
+
+		{{$requestHeaders := requestHeaders .Name $exportType}}
+		{{if $requestHeaders}}
+			// With{{$exportType}}{{makePublic .Name}}RequestHeader returns a ctx which sends the
+			// headers the binding declares for {{makePublic .Name | replaceReservedWords}} in the SOAP Header.
+			func With{{$exportType}}{{makePublic .Name}}RequestHeader(ctx context.Context{{range $requestHeaders}}, {{normalize .Name | makePrivate | replaceReservedWords}} *{{.Type}}{{end}}) context.Context {
+				return soap.WithSOAPHeaders(ctx{{range $requestHeaders}}, {{normalize .Name | makePrivate | replaceReservedWords}}{{end}})
+			}
+		{{end}}
+
+		{{$operation := makePublic .Name | replaceReservedWords}}
+		{{with asyncPollOperation .Name $portType}}
+			{{$pollOperation := makePublic .Name | replaceReservedWords}}
+			{{$pollRequestType := findType .Input.Message}}
+			{{$pollResponseType := findType .Output.Message}}
+			// {{$exportType}}{{$operation}}AndWait calls {{$operation}}, then {{$pollOperation}} with the
+			// request pollRequest builds from its response, as poller schedules it,
+			// until done reports a terminal status for a {{$pollOperation}} response.
+			// The last response is returned with the error of an unfinished poll.
+			func {{$exportType}}{{$operation}}AndWait(ctx context.Context, service {{contractType $exportType}}, poller soap.Poller, {{if ne $requestType ""}}request *{{$requestType}}, {{end}}pollRequest func(*{{$responseType}}) *{{$pollRequestType}}, done func(*{{$pollResponseType}}) bool) (*{{$pollResponseType}}, error) {
+				submitted, err := service.{{$operation}}Context(ctx, {{if ne $requestType ""}}request, {{end}}nil, nil)
+				if err != nil {
+					return nil, err
+				}
+				next := pollRequest(submitted)
+				var response *{{$pollResponseType}}
+				err = poller.Poll(ctx, func(ctx context.Context) (bool, error) {
+					polled, err := service.{{$pollOperation}}Context(ctx, next, nil, nil)
+					if err != nil {
+						return false, err
+					}
+					response = polled
+					return done(polled), nil
+				})
+				return response, err
+			}
+		{{end}}
+
 		func (service *{{$privateType}}) {{makePublic .Name | replaceReservedWords}} ({{if ne $requestType ""}}request *{{$requestType}}{{end}}, responseHeader map[string]interface{}, headers map[string]string) ({{if ne $responseType ""}}*{{$responseType}}, {{end}}error) {
 			return service.{{makePublic .Name | replaceReservedWords}}Context(
 				context.Background(),
@@ -74,4 +354,28 @@ import (
 
 	{{end}}
 {{end}}
+
+{{with serviceName}}
+	// {{serviceIdentifier}}ServiceName is the name of the service in the WSDL.
+	const {{serviceIdentifier}}ServiceName = "{{.}}"
+{{end}}
+
+// Names of the operations of the service, which the Client calls are made
+// with carry, see soap.Operation.
+const (
+	{{range operationInfos .}}{{serviceIdentifier}}Operation{{makePublic .Name | replaceReservedWords}} = "{{.Name}}"
+	{{end}}
+)
+
+// {{serviceIdentifier}}Operations describes the operations of the service by
+// name, for tools invoking them without the typed client.
+var {{serviceIdentifier}}Operations = map[string]soap.OperationInfo{
+	{{range operationInfos .}}
+		"{{.Name}}": {
+			Action:   "{{.Action}}",
+			{{if ne .Request ""}}Request: reflect.TypeOf((*{{.Request}})(nil)).Elem(),{{end}}
+			{{if ne .Response ""}}Response: reflect.TypeOf((*{{.Response}})(nil)).Elem(),{{end}}
+		},
+	{{end}}
+}
 `
@@ -0,0 +1,85 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// generatedFile is a file collected for Output or Diff instead of being
+// written.
+type generatedFile struct {
+	dir    string
+	name   string
+	source []byte
+}
+
+// writeOutput writes the collected files to Output as one source file.
+func (g *GoWSDL) writeOutput() (err error) {
+	dirs := map[string]bool{}
+	for _, file := range g.generated {
+		dirs[file.dir] = true
+	}
+	if len(dirs) > 1 {
+		var names []string
+		for dir := range dirs {
+			names = append(names, "\""+dir+"\"")
+		}
+		sort.Strings(names)
+		return fmt.Errorf("the generated code spans the packages %s, a single output needs them in one package",
+			strings.Join(names, ", "))
+	}
+
+	var source []byte
+	if source, err = mergeSources(g.generated); err != nil {
+		return
+	}
+	_, err = g.Output.Write(source)
+	return
+}
+
+// mergeSources joins the files of a package into one source file: the
+// comments and package clause of the first file, the imports of all files and
+// the declarations of each file in turn.
+func mergeSources(files []generatedFile) (ret []byte, err error) {
+	var header string
+	imports := new(bytes.Buffer)
+	decls := new(bytes.Buffer)
+	for i, generated := range files {
+		fset := token.NewFileSet()
+		var file *ast.File
+		if file, err = parser.ParseFile(fset, generated.name, generated.source, parser.ParseComments|parser.ImportsOnly); err != nil {
+			return
+		}
+		bodyStart := fset.Position(file.Name.End()).Offset
+		for _, spec := range file.Imports {
+			if spec.Name != nil {
+				imports.WriteString(spec.Name.Name + " ")
+			}
+			imports.WriteString(spec.Path.Value + "\n")
+		}
+		if len(file.Decls) > 0 {
+			bodyStart = fset.Position(file.Decls[len(file.Decls)-1].End()).Offset
+		}
+		if i == 0 {
+			header = string(generated.source[:fset.Position(file.Name.End()).Offset])
+		}
+		decls.Write(generated.source[bodyStart:])
+		decls.WriteString("\n")
+	}
+
+	merged := new(bytes.Buffer)
+	merged.WriteString(header + "\n\nimport (\n")
+	merged.Write(imports.Bytes())
+	merged.WriteString(")\n")
+	merged.Write(decls.Bytes())
+	return formatGoSource(merged.Bytes())
+}
@@ -0,0 +1,195 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+var policyNamespaces = map[string]bool{
+	"http://schemas.xmlsoap.org/ws/2004/09/policy": true,
+	"http://www.w3.org/ns/ws-policy":               true,
+}
+
+var securityPolicyNamespaces = map[string]bool{
+	"http://schemas.xmlsoap.org/ws/2005/07/securitypolicy":      true,
+	"http://docs.oasis-open.org/ws-sx/ws-securitypolicy/200512": true,
+	"http://docs.oasis-open.org/ws-sx/ws-securitypolicy/200702": true,
+}
+
+// securityTokens are the token assertions of WS-SecurityPolicy.
+var securityTokens = map[string]bool{
+	"UsernameToken":           true,
+	"X509Token":               true,
+	"SamlToken":               true,
+	"KerberosToken":           true,
+	"IssuedToken":             true,
+	"SecureConversationToken": true,
+	"SpnegoContextToken":      true,
+	"SecurityContextToken":    true,
+	"RelToken":                true,
+	"KeyValueToken":           true,
+}
+
+// WSPolicy is an element of a WS-Policy expression: a wsp:Policy, one of its
+// operators or a wsp:PolicyReference, or a policy assertion with its nested
+// policy.
+type WSPolicy struct {
+	XMLName xml.Name
+	// ID is the wsu:Id a PolicyReference refers to as #ID, Name the IRI of
+	// WS-Policy 1.5.
+	ID       string `xml:"Id,attr"`
+	Name     string `xml:"Name,attr"`
+	URI      string `xml:"URI,attr"`
+	Optional bool   `xml:"Optional,attr"`
+	// RequireClientCertificate is the attribute of the HttpsToken of
+	// WS-SecurityPolicy 1.1, later versions nest an assertion.
+	RequireClientCertificate bool        `xml:"RequireClientCertificate,attr"`
+	Children                 []*WSPolicy `xml:",any"`
+}
+
+// securityPolicy is the security the WS-SecurityPolicy assertions of a
+// binding require, generated as soap.SecurityPolicy.
+type securityPolicy struct {
+	TransportBinding         bool
+	HttpsToken               bool
+	RequireClientCertificate bool
+	SymmetricBinding         bool
+	AsymmetricBinding        bool
+	Tokens                   []string
+	HashPassword             bool
+	IncludeTimestamp         bool
+	Signed                   bool
+	Encrypted                bool
+}
+
+// RequiresToken reports whether the policy requires the token assertion.
+func (p *securityPolicy) RequiresToken(token string) bool {
+	for _, required := range p.Tokens {
+		if required == token {
+			return true
+		}
+	}
+	return false
+}
+
+// findPolicy returns the policy of the WSDL or its imports a
+// PolicyReference URI refers to, by its #ID or its Name.
+func (w *WSDL) findPolicy(uri string) *WSPolicy {
+	for _, policy := range w.Policies {
+		if (policy.ID != "" && uri == "#"+policy.ID) || (policy.Name != "" && uri == policy.Name) {
+			return policy
+		}
+	}
+	for _, imported := range w.Imported {
+		if policy := imported.findPolicy(uri); policy != nil {
+			return policy
+		}
+	}
+	return nil
+}
+
+// securityPolicy returns the security the policies attached to the bindings
+// of the port type and their operations require, nil if they don't declare
+// any. Only the first alternative of an ExactlyOne is considered, optional
+// assertions are ignored.
+func (g *GoWSDL) securityPolicy(portType string) *securityPolicy {
+	ret := new(securityPolicy)
+	found := false
+	seen := map[*WSPolicy]bool{}
+	for _, binding := range g.wsdl.Binding {
+		if !strings.EqualFold(stripns(binding.Type), portType) {
+			continue
+		}
+		policies := append(append([]*WSPolicy(nil), binding.Policies...), binding.PolicyReferences...)
+		for _, operation := range binding.Operations {
+			policies = append(policies, operation.Policies...)
+			policies = append(policies, operation.PolicyReferences...)
+			policies = append(policies, operation.Input.Policies...)
+			policies = append(policies, operation.Input.PolicyReferences...)
+			policies = append(policies, operation.Output.Policies...)
+			policies = append(policies, operation.Output.PolicyReferences...)
+		}
+		for _, policy := range policies {
+			found = g.walkSecurityPolicy(policy, ret, seen) || found
+		}
+	}
+	if !found {
+		return nil
+	}
+	return ret
+}
+
+// generatedSecurityPolicy returns the securityPolicy of the port type with
+// SecurityPolicies, nil without.
+func (g *GoWSDL) generatedSecurityPolicy(portType string) *securityPolicy {
+	if !g.SecurityPolicies {
+		return nil
+	}
+	return g.securityPolicy(portType)
+}
+
+// walkSecurityPolicy adds the assertions of the policy expression to ret and
+// reports whether it found any.
+func (g *GoWSDL) walkSecurityPolicy(policy *WSPolicy, ret *securityPolicy, seen map[*WSPolicy]bool) bool {
+	if policy.Optional || seen[policy] {
+		return false
+	}
+	seen[policy] = true
+	defer delete(seen, policy)
+
+	children := policy.Children
+	switch name := policy.XMLName; {
+	case policyNamespaces[name.Space]:
+		switch name.Local {
+		case "PolicyReference":
+			referenced := g.wsdl.findPolicy(policy.URI)
+			return referenced != nil && g.walkSecurityPolicy(referenced, ret, seen)
+		case "ExactlyOne":
+			if len(children) > 1 {
+				children = children[:1]
+			}
+		}
+	case securityPolicyNamespaces[name.Space]:
+		switch local := name.Local; {
+		case local == "TransportBinding":
+			ret.TransportBinding = true
+		case local == "SymmetricBinding":
+			ret.SymmetricBinding = true
+		case local == "AsymmetricBinding":
+			ret.AsymmetricBinding = true
+		case local == "HttpsToken":
+			ret.HttpsToken = true
+			ret.RequireClientCertificate = ret.RequireClientCertificate || policy.RequireClientCertificate
+		case local == "RequireClientCertificate":
+			ret.RequireClientCertificate = true
+		case local == "HashPassword":
+			ret.HashPassword = true
+		case local == "IncludeTimestamp":
+			ret.IncludeTimestamp = true
+		case local == "SignedParts", local == "SignedElements":
+			ret.Signed = true
+		case local == "EncryptedParts", local == "EncryptedElements":
+			ret.Encrypted = true
+		case securityTokens[local]:
+			if !ret.RequiresToken(local) {
+				ret.Tokens = append(ret.Tokens, local)
+			}
+		}
+		for _, child := range children {
+			g.walkSecurityPolicy(child, ret, seen)
+		}
+		return true
+	default:
+		return false
+	}
+
+	found := false
+	for _, child := range children {
+		found = g.walkSecurityPolicy(child, ret, seen) || found
+	}
+	return found
+}
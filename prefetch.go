@@ -0,0 +1,125 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"sync"
+)
+
+// defaultConcurrentDownloads is the number of external schemas fetched at
+// once if MaxConcurrentDownloads is zero.
+const defaultConcurrentDownloads = 8
+
+// prefetchedDocuments holds the documents prefetchXSDExternals fetched, with
+// the locations their references resolve against, or the errors fetching
+// them, by location. A nil value holds nothing.
+type prefetchedDocuments struct {
+	mu        sync.Mutex
+	claimed   map[string]bool
+	documents map[string][]byte
+	bases     map[string]*Location
+	errs      map[string]error
+}
+
+func newPrefetchedDocuments() *prefetchedDocuments {
+	return &prefetchedDocuments{claimed: map[string]bool{}, documents: map[string][]byte{}, bases: map[string]*Location{}, errs: map[string]error{}}
+}
+
+// claim reports whether the location is claimed for the first time, only
+// the first claim fetches it.
+func (p *prefetchedDocuments) claim(location string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.claimed[location] {
+		return false
+	}
+	p.claimed[location] = true
+	return true
+}
+
+func (p *prefetchedDocuments) put(location string, data []byte, base *Location, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		p.errs[location] = err
+		return
+	}
+	p.documents[location] = data
+	p.bases[location] = base
+}
+
+// get returns the document fetched for the location and its base, see
+// fetchFile, or the error fetching it, ok is false if it wasn't fetched.
+func (p *prefetchedDocuments) get(location string) (data []byte, base *Location, ok bool, err error) {
+	if p == nil {
+		return nil, nil, false, nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err, ok = p.errs[location]; ok {
+		return nil, nil, true, err
+	}
+	data, ok = p.documents[location]
+	return data, p.bases[location], ok, nil
+}
+
+// prefetchXSDExternals fetches the schemas the schemas import or include,
+// and the ones these reference in turn, with up to MaxConcurrentDownloads
+// fetches at a time. The schemas are resolved in document order afterwards
+// by resolveXSDExternals, which reads the prefetched documents, so the order
+// of the resolved schemas doesn't depend on the order the fetches complete.
+func (g *GoWSDL) prefetchXSDExternals(schemas []*XSDSchema, loc *Location) {
+	limit := g.MaxConcurrentDownloads
+	if limit == 0 {
+		limit = defaultConcurrentDownloads
+	}
+	if limit < 2 {
+		return
+	}
+	if g.prefetched == nil {
+		g.prefetched = newPrefetchedDocuments()
+	}
+
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, limit)
+	var visit func(schema *XSDSchema, base *Location)
+	fetch := func(base *Location, ref string) {
+		location, err := base.Parse(ref)
+		if err != nil || !g.prefetched.claim(location.String()) {
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			workers <- struct{}{}
+			data, base, err := g.fetchFile(location)
+			<-workers
+			g.prefetched.put(location.String(), data, base, err)
+			if err != nil {
+				return
+			}
+
+			schema := new(XSDSchema)
+			if unmarshalDocument(data, schema) == nil {
+				visit(schema, base)
+			}
+		}()
+	}
+	visit = func(schema *XSDSchema, base *Location) {
+		for _, impts := range schema.Imports {
+			if impts.SchemaLocation != "" && impts.Namespace != xmlNamespace {
+				fetch(base, impts.SchemaLocation)
+			}
+		}
+		for _, incl := range schema.Includes {
+			fetch(base, incl.SchemaLocation)
+		}
+	}
+
+	for _, schema := range schemas {
+		visit(schema, loc)
+	}
+	wg.Wait()
+}
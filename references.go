@@ -0,0 +1,145 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// schemaReferences returns the qualified names of the types, elements and
+// attributes the declarations of the schema refer to. Built in XML Schema
+// types and the attributes of the XML namespace are left out.
+func schemaReferences(schema *XSDSchema) (ret []xml.Name) {
+	collector := &referenceCollector{schema: schema}
+	for _, elm := range schema.Elements {
+		collector.element(elm)
+	}
+	for _, ct := range schema.ComplexTypes {
+		collector.complexType(ct)
+	}
+	for _, st := range schema.SimpleType {
+		collector.simpleType(st)
+	}
+	collector.attributes(schema.Attributes)
+	return collector.refs
+}
+
+type referenceCollector struct {
+	schema *XSDSchema
+	refs   []xml.Name
+}
+
+func (c *referenceCollector) add(qname string) {
+	if qname == "" {
+		return
+	}
+	ref := c.qname(qname)
+	if ref.Space != xmlschema11 && ref.Space != xmlNamespace {
+		c.refs = append(c.refs, ref)
+	}
+}
+
+// qname resolves the prefix of a QName, names without prefix belong to the target namespace.
+func (c *referenceCollector) qname(name string) (ret xml.Name) {
+	if local, ok := xmlAttribute(name); ok {
+		return xml.Name{Space: xmlNamespace, Local: local}
+	}
+	if i := strings.Index(name, ":"); i >= 0 {
+		return xml.Name{Space: c.schema.Xmlns[name[:i]], Local: name[i+1:]}
+	}
+	return xml.Name{Space: c.schema.TargetNamespace, Local: name}
+}
+
+func (c *referenceCollector) elements(elms []*XSDElement) {
+	for _, elm := range elms {
+		c.element(elm)
+	}
+}
+
+func (c *referenceCollector) element(elm *XSDElement) {
+	c.add(elm.Type)
+	c.add(elm.Ref)
+	if elm.ComplexType != nil {
+		c.complexType(elm.ComplexType)
+	}
+	if elm.SimpleType != nil {
+		c.simpleType(elm.SimpleType)
+	}
+}
+
+func (c *referenceCollector) complexType(ct *XSDComplexType) {
+	c.elements(ct.Sequence)
+	c.elements(ct.Choice)
+	c.elements(ct.SequenceChoice)
+	c.elements(ct.RepeatedChoice)
+	c.elements(ct.RepeatedSequence)
+	c.elements(ct.All)
+	c.attributes(ct.Attributes)
+	for _, extension := range []XSDExtension{ct.ComplexContent.Extension, ct.SimpleContent.Extension} {
+		c.add(extension.Base)
+		c.elements(extension.Sequence)
+		c.elements(extension.Choice)
+		c.elements(extension.SequenceChoice)
+		c.elements(extension.RepeatedChoice)
+		c.elements(extension.RepeatedSequence)
+		c.attributes(extension.Attributes)
+	}
+}
+
+func (c *referenceCollector) simpleType(st *XSDSimpleType) {
+	c.add(st.Restriction.Base)
+	c.add(st.List.ItemType)
+	if st.List.SimpleType != nil {
+		c.simpleType(st.List.SimpleType)
+	}
+	for _, member := range strings.Fields(st.Union.MemberTypes) {
+		c.add(member)
+	}
+	for _, member := range st.Union.SimpleType {
+		c.simpleType(member)
+	}
+}
+
+func (c *referenceCollector) attributes(attrs []*XSDAttribute) {
+	for _, attr := range attrs {
+		c.add(attr.Type)
+		c.add(attr.Ref)
+		if attr.SimpleType != nil {
+			c.simpleType(attr.SimpleType)
+		}
+	}
+}
+
+// declares reports whether one of the schemas declares a global type, element
+// or attribute named ref.
+func (w *WSDL) declares(ref xml.Name) bool {
+	for _, schema := range w.Types.Schemas {
+		if schema.TargetNamespace != ref.Space {
+			continue
+		}
+		for _, ct := range schema.ComplexTypes {
+			if ct.Name == ref.Local {
+				return true
+			}
+		}
+		for _, st := range schema.SimpleType {
+			if st.Name == ref.Local {
+				return true
+			}
+		}
+		for _, elm := range schema.Elements {
+			if elm.Name == ref.Local {
+				return true
+			}
+		}
+		for _, attr := range schema.Attributes {
+			if attr.Name == ref.Local {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"github.com/iancoleman/strcase"
 	"log"
+	"path"
+	"strconv"
 	"strings"
 )
 
@@ -16,7 +18,34 @@ type TypeResolver struct {
 	NamespaceToPackage         map[string]string
 	NamespaceToFileName        map[string]string
 
+	// PrefixTypeNames prefixes generated type names with a namespace derived
+	// token, TypeNamePrefixes overrides the token per namespace.
+	PrefixTypeNames  bool
+	TypeNamePrefixes map[string]string
+
+	// NamespacePackages pins the package of a namespace, as package name or
+	// path relative to PackageBase, instead of deriving it from the namespace.
+	NamespacePackages map[string]string
+	// ImportBase is the import path of the directory of PackageBase, the
+	// import paths of the packages derive from PackageBase if empty.
+	ImportBase string
+
+	// XSDBooleans maps xsd:boolean to soap.XSDBoolean instead of bool.
+	XSDBooleans bool
+	// XSDTokens maps xsd:token to soap.Token and xsd:normalizedString to
+	// soap.NormalizedString instead of string.
+	XSDTokens bool
+	// PlainTypes maps the built-in types implemented by the soap package to
+	// string and leaves the soap import out.
+	PlainTypes bool
+	// TransliterateNames spells accented letters of type names in ASCII.
+	TransliterateNames bool
+
 	namespaceToResolver map[string]*NsTypeResolver
+	// schemaToResolver holds a resolver per schema, sharing the registered
+	// types of their target namespace, which can be split across schemas.
+	schemaToResolver map[*XSDSchema]*NsTypeResolver
+	namespaceSchemas map[string][]*XSDSchema
 }
 
 func NewTypeResolver(packageBase string) *TypeResolver {
@@ -27,7 +56,11 @@ func NewTypeResolver(packageBase string) *TypeResolver {
 		NamespaceToPackageFull:     map[string]string{},
 		NamespaceToPackage:         map[string]string{},
 		NamespaceToFileName:        map[string]string{},
+		TypeNamePrefixes:           map[string]string{},
+		NamespacePackages:          map[string]string{},
 		namespaceToResolver:        map[string]*NsTypeResolver{},
+		schemaToResolver:           map[*XSDSchema]*NsTypeResolver{},
+		namespaceSchemas:           map[string][]*XSDSchema{},
 	}
 }
 
@@ -41,6 +74,13 @@ func (o *TypeResolver) AddNamespace(schema *XSDSchema, nativePackage bool) (ret
 
 func (o *TypeResolver) SetNamespaceToPackage(namespace string, nativePackage bool) {
 	if !nativePackage {
+		if alias, ok := o.NamespacePackages[namespace]; ok {
+			o.NamespaceToPackageRelative[namespace] = alias
+			o.NamespaceToPackageFull[namespace] = o.importPath(alias)
+			o.NamespaceToPackage[namespace] = PackageLast(alias)
+			o.NamespaceToFileName[namespace] = PackageLast(alias)
+			return
+		}
 		namespaceRelative := NamespaceToPackageRelative(namespace)
 		o.NamespaceToPackageRelative[namespace] = namespaceRelative
 		var namespaceFull string
@@ -49,9 +89,14 @@ func (o *TypeResolver) SetNamespaceToPackage(namespace string, nativePackage boo
 		} else {
 			namespaceFull = o.PackageBase
 		}
-		o.NamespaceToPackageFull[namespace] = namespaceFull
+		o.NamespaceToPackageFull[namespace] = o.importPath(namespaceRelative)
 		o.NamespaceToPackage[namespace] = PackageLast(namespaceFull)
-		o.NamespaceToFileName[namespace] = NamespaceToFileName(namespace)
+		if namespace != "" {
+			o.NamespaceToFileName[namespace] = NamespaceToFileName(namespace)
+		} else {
+			// types of schemas without a target namespace go to the base package
+			o.NamespaceToFileName[namespace] = o.NamespaceToPackage[namespace]
+		}
 	} else {
 		o.NamespaceToPackageRelative[namespace] = ""
 		o.NamespaceToPackageFull[namespace] = ""
@@ -59,30 +104,126 @@ func (o *TypeResolver) SetNamespaceToPackage(namespace string, nativePackage boo
 	}
 }
 
+// importPath returns the import path of the package in the directory
+// relative to the one of PackageBase.
+func (o *TypeResolver) importPath(relative string) string {
+	base := o.ImportBase
+	if base == "" {
+		base = o.PackageBase
+	}
+	if relative == "" {
+		return base
+	}
+	return fmt.Sprintf("%v/%v", base, relative)
+}
+
+// importSpec returns the import of the package of the namespace, named
+// explicitly if the package name differs from the last element of its import
+// path, as for the base package of PackageBase below ImportBase.
+func (o *TypeResolver) importSpec(namespace string) string {
+	importPath := o.NamespaceToPackageFull[namespace]
+	if name := o.NamespaceToPackage[namespace]; name != path.Base(importPath) {
+		return name + " " + strconv.Quote(importPath)
+	}
+	return strconv.Quote(importPath)
+}
+
+// xsdGoType returns the Go type of the built-in XSD type, empty if unknown.
+func (o *TypeResolver) xsdGoType(typeName string) string {
+	typeName = strings.ToLower(typeName)
+	if o.PlainTypes {
+		if goType := xsd2GoTypes[typeName]; !strings.HasPrefix(goType, "soap.") {
+			return goType
+		}
+		return "string"
+	}
+	if typeName == "boolean" && o.XSDBooleans {
+		return "soap.XSDBoolean"
+	}
+	if o.XSDTokens {
+		switch typeName {
+		case "token":
+			return "soap.Token"
+		case "normalizedstring":
+			return "soap.NormalizedString"
+		}
+	}
+	return xsd2GoTypes[typeName]
+}
+
 func (o *TypeResolver) RegisterTypes(wsdl *WSDL) (ret *NsTypeResolver) {
 	xsdTypeResolver := o.AddNamespace(&XSDSchema{TargetNamespace: "http://www.w3.org/2001/XMLSchema", Xmlns: map[string]string{}}, true)
-	for k, v := range xsd2GoTypes {
-		xsdTypeResolver.RegisterType(k, v)
+	for k := range xsd2GoTypes {
+		xsdTypeResolver.RegisterType(k, o.xsdGoType(k))
 	}
 	// Register types first
 	for _, schema := range wsdl.Types.Schemas {
-		resolver := o.AddNamespace(schema, false)
-		o.namespaceToResolver[schema.TargetNamespace] = resolver
-		newTraverser(schema, wsdl.Types.Schemas, resolver).Traverse()
+		newTraverser(schema, wsdl.Types.Schemas, o.addSchema(schema)).Traverse()
 	}
 
 	// Register element types after, because of cycle dependencies
 	for _, schema := range wsdl.Types.Schemas {
-		newTraverser(schema, wsdl.Types.Schemas, o.namespaceToResolver[schema.TargetNamespace]).Traverse()
+		newTraverser(schema, wsdl.Types.Schemas, o.GetResolverForSchema(schema)).Traverse()
+	}
+	for _, imported := range wsdl.Imported {
+		resolver := o.resolverForWSDL(imported)
+		for _, message := range imported.Messages {
+			resolver.OnMessage(message)
+		}
+	}
+
+	ret = o.resolverForWSDL(wsdl)
+	for _, message := range wsdl.Messages {
+		ret.OnMessage(message)
 	}
+	return
+}
+
+func (o *TypeResolver) resolverForWSDL(wsdl *WSDL) (ret *NsTypeResolver) {
 	ret = o.namespaceToResolver[wsdl.TargetNamespace]
 	if ret == nil {
 		ret = o.AddNamespace(&XSDSchema{TargetNamespace: wsdl.TargetNamespace, Xmlns: wsdl.Xmlns}, false)
 		o.namespaceToResolver[wsdl.TargetNamespace] = ret
 	}
+	return
+}
 
-	for _, message := range wsdl.Messages {
-		ret.OnMessage(message)
+// addSchema adds the resolver of the schema. The first schema of a target
+// namespace adds the namespace, further ones share its registered types but
+// resolve prefixes with their own declarations.
+func (o *TypeResolver) addSchema(schema *XSDSchema) (ret *NsTypeResolver) {
+	namespace := schema.TargetNamespace
+	if base := o.namespaceToResolver[namespace]; base != nil {
+		view := *base
+		view.Schema = schema
+		view.GoImports = ""
+		ret = &view
+	} else {
+		ret = o.AddNamespace(schema, false)
+		o.namespaceToResolver[namespace] = ret
+	}
+	o.schemaToResolver[schema] = ret
+	o.namespaceSchemas[namespace] = append(o.namespaceSchemas[namespace], schema)
+	return
+}
+
+// GetResolverForSchema returns the resolver for the types of the schema.
+func (o *TypeResolver) GetResolverForSchema(schema *XSDSchema) *NsTypeResolver {
+	if ret := o.schemaToResolver[schema]; ret != nil {
+		return ret
+	}
+	return o.namespaceToResolver[schema.TargetNamespace]
+}
+
+// TypeNamePrefix returns the token the generated type names of the namespace start with.
+func (o *TypeResolver) TypeNamePrefix(namespace string) (ret string) {
+	if !o.PrefixTypeNames {
+		return
+	}
+	if alias, ok := o.TypeNamePrefixes[namespace]; ok {
+		ret = alias
+	} else {
+		ret = normalize(strcase.ToCamel(o.NamespaceToPackage[namespace]))
 	}
 	return
 }
@@ -99,6 +240,10 @@ type NsTypeResolver struct {
 
 	GoPackage string
 	GoImports string
+
+	// inlineElements are the local elements with inline enumerations visited
+	// since the last global type, their types are named after it.
+	inlineElements []*XSDElement
 }
 
 func NewNsTypeResolver(schema *XSDSchema, resolver *TypeResolver, goPackage string) (ret *NsTypeResolver) {
@@ -125,19 +270,34 @@ func (o *NsTypeResolver) GetGoImports() string {
 	if o.GoImports == "" {
 		buffer := bytes.Buffer{}
 		buffer.WriteString("\"encoding/xml\"\n")
-		buffer.WriteString("\"github.com/hooklift/gowsdl/soap\"\n")
+		if !o.Resolver.PlainTypes {
+			buffer.WriteString("\"github.com/hooklift/gowsdl/soap\"\n")
+		}
 
+		// the types of a namespace are generated to one file for all its schemas
+		schemas := o.Resolver.namespaceSchemas[o.Schema.TargetNamespace]
+		if len(schemas) == 0 {
+			schemas = []*XSDSchema{o.Schema}
+		}
 		var imp string
-		for _, namespace := range o.Schema.Xmlns {
-			myPackage := o.Resolver.NamespaceToPackageFull[o.Schema.TargetNamespace]
-			targetPackage := o.Resolver.NamespaceToPackageFull[namespace]
-			if myPackage != targetPackage {
-				imp = targetPackage
-				if imp != "" {
-					buffer.WriteString("\"" + imp + "\"\n")
+		for _, schema := range schemas {
+			for _, namespace := range schema.Xmlns {
+				myPackage := o.Resolver.NamespaceToPackageFull[o.Schema.TargetNamespace]
+				targetPackage := o.Resolver.NamespaceToPackageFull[namespace]
+				if myPackage != targetPackage {
+					imp = targetPackage
+					if imp != "" {
+						buffer.WriteString(o.Resolver.importSpec(namespace) + "\n")
+					}
 				}
 			}
 		}
+		if nsResolver := o.Resolver.namespaceToResolver[""]; nsResolver != nil && nsResolver != o {
+			// unqualified names may resolve to the schemas without a target namespace
+			if imp = o.Resolver.NamespaceToPackageFull[""]; imp != "" && imp != o.Resolver.NamespaceToPackageFull[o.Schema.TargetNamespace] {
+				buffer.WriteString(o.Resolver.importSpec("") + "\n")
+			}
+		}
 		o.GoImports = buffer.String()
 	}
 	return o.GoImports
@@ -151,6 +311,16 @@ func (o *NsTypeResolver) FindTypeNillable(xsdType string, nillable bool) (ret st
 	return
 }
 
+// FindElementType resolves the Go type of a field for the element. Nillable
+// elements are pointers, as are optional elements of non basic types.
+func (o *NsTypeResolver) FindElementType(elm *XSDElement) (ret string) {
+	ret = o.findTypeNameFull(elm.Type, true)
+	if elm.Nillable || (elm.MinOccurs == "0" && !isBasicType(ret)) {
+		ret = "*" + ret
+	}
+	return
+}
+
 func (o *NsTypeResolver) toNamespaceAndType(xsdType string) (namespace string, typeName string) {
 	namespaceLabelAndTypeName := strings.Split(xsdType, ":")
 
@@ -169,13 +339,14 @@ func (o *NsTypeResolver) toNamespaceAndType(xsdType string) (namespace string, t
 
 func (o *NsTypeResolver) OnSimpleType(item *XSDSimpleType) {
 	if item.Name != "" {
-		o.RegisterType(item.Name, NormalizeTypeName(item.Name))
+		o.RegisterType(item.Name, o.normalizeTypeName(item.Name))
 	}
 }
 
 func (o *NsTypeResolver) OnComplexType(item *XSDComplexType) {
 	if item.Name != "" {
-		o.RegisterType(item.Name, NormalizeTypeName(item.Name))
+		o.RegisterType(item.Name, o.normalizeTypeName(item.Name))
+		o.registerInlineTypes(item.Name)
 	}
 }
 
@@ -183,17 +354,63 @@ func (o *NsTypeResolver) OnElement(item *XSDElement) {
 	if item.ComplexType != nil {
 		//log.Printf("register element based complex type %v", item.Name)
 		if item.ComplexType.Name != "" {
-			o.RegisterType(item.Name, NormalizeTypeName(item.ComplexType.Name))
+			o.RegisterType(item.Name, o.normalizeTypeName(item.ComplexType.Name))
 		} else {
-			o.RegisterType(item.Name, NormalizeTypeName(item.Name))
+			o.RegisterType(item.Name, o.normalizeTypeName(item.Name))
+		}
+		if o.isGlobalElement(item) {
+			o.registerInlineTypes(item.Name)
 		}
 	} else if item.SimpleType != nil {
-		log.Printf("register element based simple type %v", item)
+		if o.isGlobalElement(item) {
+			o.RegisterType(item.Name, o.normalizeTypeName(item.Name))
+		} else if len(item.SimpleType.Restriction.Enumeration) > 0 {
+			o.inlineElements = append(o.inlineElements, item)
+		}
+	} else if item.Type != "" && o.NameToGoType[item.Name] == "" && o.isGlobalElement(item) {
+		// a global element of a named type is generated as a type of its own,
+		// which names the root of the messages referencing the element
+		o.RegisterType(item.Name, o.normalizeTypeName(item.Name))
 	} else {
 		//no virtual types to register
 	}
 }
 
+// registerInlineTypes registers the enumerations of the local elements of the
+// global type typeName, named after the type and the element.
+func (o *NsTypeResolver) registerInlineTypes(typeName string) {
+	prefix := o.NameToGoType[typeName]
+	for _, elm := range o.inlineElements {
+		o.RegisterType(inlineTypeKey(typeName, elm.Name), prefix+o.Resolver.goTypeName(normalize(elm.Name)))
+	}
+	o.inlineElements = nil
+}
+
+// inlineTypeKey is the name the type of the inline enumeration of a local
+// element is registered with, the slash keeps it apart from the XML names.
+func inlineTypeKey(typeName string, elementName string) string {
+	return typeName + "/" + elementName
+}
+
+func (o *NsTypeResolver) isGlobalElement(item *XSDElement) bool {
+	schemas := o.Resolver.namespaceSchemas[o.Schema.TargetNamespace]
+	if len(schemas) == 0 {
+		schemas = []*XSDSchema{o.Schema}
+	}
+	for _, schema := range schemas {
+		for _, elm := range schema.Elements {
+			if elm == item {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// OnAttribute does nothing, attributes have no types of their own to register.
+func (o *NsTypeResolver) OnAttribute(item *XSDAttribute) {
+}
+
 func (o *NsTypeResolver) OnMessage(msg *WSDLMessage) {
 	// Assumes document/literal wrapped WS-I
 	if len(msg.Parts) == 0 {
@@ -204,15 +421,16 @@ func (o *NsTypeResolver) OnMessage(msg *WSDLMessage) {
 		return
 	}
 
-	part := msg.Parts[0]
-	var typeNameFull string
+	part := msg.BodyPart()
+	ref := part.Element
 	if part.Type != "" {
-		typeNameFull = o.findTypeNameFull(part.Type, false)
-	} else {
-		typeNameFull = o.findTypeNameFull(part.Element, false)
+		ref = part.Type
 	}
+	typeNameFull := o.findTypeNameFull(ref, false)
 
-	if typeNameFull != "" {
+	if namespace, _ := o.toNamespaceAndType(ref); typeNameFull != "" && o.isMyNamespace(namespace) {
+		o.RegisterType(msg.Name, typeNameFull)
+	} else if typeNameFull != "" {
 		o.RegisterTypeExternal(msg.Name, typeNameFull)
 	} else {
 		log.Printf("can't register type for the WSDL message port element: %v", part)
@@ -222,7 +440,12 @@ func (o *NsTypeResolver) OnMessage(msg *WSDLMessage) {
 func (o *NsTypeResolver) findTypeNameFull(nsName string, buildNotAvailable bool) (ret string) {
 	namespace, typeName := o.toNamespaceAndType(nsName)
 	if o.isMyNamespace(namespace) {
-		ret = o.getTypeName(typeName, buildNotAvailable)
+		if ret = o.getTypeName(typeName, false); ret == "" {
+			ret = o.findNoNamespaceTypeName(typeName)
+		}
+		if ret == "" {
+			ret = o.getTypeName(typeName, buildNotAvailable)
+		}
 	} else {
 		nsResolver := o.Resolver.NamespaceToResolver[namespace]
 		if nsResolver != nil {
@@ -238,6 +461,21 @@ func (o *NsTypeResolver) findTypeNameFull(nsName string, buildNotAvailable bool)
 	return
 }
 
+// findNoNamespaceTypeName resolves unqualified names, which this namespace
+// doesn't declare, against the schemas without a target namespace.
+func (o *NsTypeResolver) findNoNamespaceTypeName(typeName string) (ret string) {
+	nsResolver := o.Resolver.namespaceToResolver[""]
+	if nsResolver == nil || nsResolver == o {
+		return
+	}
+	if o.GetGoPackage() == nsResolver.GetGoPackage() {
+		ret = nsResolver.getTypeName(typeName, false)
+	} else {
+		ret = nsResolver.getTypeNameFull(typeName, false)
+	}
+	return
+}
+
 func (o *NsTypeResolver) getTypeName(typeName string, buildNotAvailable bool) (ret string) {
 	ret = o.NameToGoType[typeName]
 	if ret == "" && buildNotAvailable {
@@ -272,10 +510,14 @@ func (o *NsTypeResolver) RegisterTypeExternal(name string, typeName string) {
 }
 
 func (o *NsTypeResolver) BuildGoType(namespace string, typeName string) (ret string) {
-	ret = xsd2GoTypes[strings.ToLower(typeName)]
+	ret = o.Resolver.xsdGoType(typeName)
 
 	if ret == "" {
-		ret = NormalizeTypeName(typeName)
+		if o.isMyNamespace(namespace) {
+			ret = o.normalizeTypeName(typeName)
+		} else {
+			ret = o.Resolver.TypeNamePrefix(namespace) + o.Resolver.goTypeName(typeName)
+		}
 		if o.isMyNamespace(namespace) {
 			goPackage := o.Resolver.NamespaceToPackage[namespace]
 			if goPackage != "" {
@@ -285,9 +527,6 @@ func (o *NsTypeResolver) BuildGoType(namespace string, typeName string) (ret str
 			}
 		}
 	}
-	if strings.Contains(ret, "URI") {
-		fmt.Printf("", ret)
-	}
 	return
 }
 
@@ -295,11 +534,21 @@ func (o *NsTypeResolver) isMyNamespace(namespace string) bool {
 	return namespace == "" || namespace == o.Schema.TargetNamespace
 }
 
+// normalizeTypeName builds the Go type name of a type declared in this namespace.
+func (o *NsTypeResolver) normalizeTypeName(typeName string) string {
+	return o.Resolver.TypeNamePrefix(o.Schema.TargetNamespace) + o.Resolver.goTypeName(typeName)
+}
+
+// goTypeName builds the Go type name of an XML type name, without prefix.
+func (o *TypeResolver) goTypeName(typeName string) string {
+	if o.TransliterateNames {
+		typeName = transliterate(typeName)
+	}
+	return NormalizeTypeName(typeName)
+}
+
 func NormalizeTypeName(typeName string) (ret string) {
 	ret = strcase.ToCamel(typeName)
-	if strings.Contains(ret, "URI") {
-		fmt.Printf("", ret)
-	}
 	ret = replaceReservedWords(makePublic(ret))
 	return ret
 }
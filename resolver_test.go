@@ -0,0 +1,29 @@
+package gowsdl
+
+import (
+	"testing"
+)
+
+func TestTypeResolver_PrefixTypeNames(t *testing.T) {
+	tests := []struct {
+		namespace string
+		prefixes  map[string]string
+		expected  string
+	}{
+		{"http://example.com/stockquote", nil, "StockquoteTradePrice"},
+		{"http://example.com/stockquote", map[string]string{"http://example.com/stockquote": "Sq"}, "SqTradePrice"},
+	}
+	for _, test := range tests {
+		resolver := NewTypeResolver("gen")
+		resolver.PrefixTypeNames = true
+		for namespace, prefix := range test.prefixes {
+			resolver.TypeNamePrefixes[namespace] = prefix
+		}
+		nsResolver := resolver.AddNamespace(&XSDSchema{TargetNamespace: test.namespace, Xmlns: map[string]string{}}, false)
+		nsResolver.OnComplexType(&XSDComplexType{Name: "tradePrice"})
+
+		if got := nsResolver.NameToGoType["tradePrice"]; got != test.expected {
+			t.Errorf("got %s wanted %s", got, test.expected)
+		}
+	}
+}
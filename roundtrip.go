@@ -0,0 +1,67 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"text/template"
+)
+
+// genRoundTripTests writes a test next to the types of the namespace which
+// round trips each struct type having a New constructor through XML.
+func (g *GoWSDL) genRoundTripTests(namespace string, types []byte) error {
+	file, err := parser.ParseFile(token.NewFileSet(), "", types, 0)
+	if err != nil {
+		return err
+	}
+	roundTripTypes := constructedStructs(file)
+	if len(roundTripTypes) == 0 {
+		return nil
+	}
+
+	data := new(bytes.Buffer)
+	tmpl := template.Must(template.New("RoundTrip").Parse(roundTripTmpl))
+	if err = tmpl.Execute(data, map[string]interface{}{"Package": file.Name.Name, "Types": roundTripTypes}); err != nil {
+		return err
+	}
+	return g.writeFileSuffix("types_", namespace, g.formatSource(data), "", "_roundtrip_test.go")
+}
+
+// constructedStructs returns the sorted names of the struct types of the
+// file which have a constructor New<Type>() *<Type>.
+func constructedStructs(file *ast.File) (ret []string) {
+	structs := map[string]bool{}
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.TYPE {
+			for _, spec := range gen.Specs {
+				if typeSpec := spec.(*ast.TypeSpec); typeSpec.TypeParams == nil {
+					if _, ok := typeSpec.Type.(*ast.StructType); ok {
+						structs[typeSpec.Name.Name] = true
+					}
+				}
+			}
+		}
+	}
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || len(fn.Type.Params.List) > 0 || fn.Type.Results == nil || len(fn.Type.Results.List) != 1 {
+			continue
+		}
+		star, ok := fn.Type.Results.List[0].Type.(*ast.StarExpr)
+		if !ok {
+			continue
+		}
+		if ident, ok := star.X.(*ast.Ident); ok && structs[ident.Name] && fn.Name.Name == "New"+ident.Name {
+			ret = append(ret, ident.Name)
+		}
+	}
+	sort.Strings(ret)
+	return
+}
@@ -0,0 +1,21 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+var roundTripTmpl = `
+// Code generated by gowsdl DO NOT EDIT.
+package {{.Package}}
+
+import (
+	"testing"
+
+	"github.com/hooklift/gowsdl/soap/soaptest"
+)
+{{range .Types}}
+func TestRoundTrip{{.}}(t *testing.T) {
+	soaptest.RoundTrip(t, func() interface{} { return New{{.}}() })
+}
+{{end}}
+`
@@ -10,6 +10,7 @@ import (
 	"errors"
 	"reflect"
 	"strings"
+	"sync"
 	"net/http"
 	{{GoImports}}
 )
@@ -21,17 +22,17 @@ var serverTmpl = `
 var WSDLUndefinedError = errors.New("Server was unable to process request. --> Object reference not set to an instance of an object.")
 
 type SOAPEnvelopeRequest struct {
-	XMLName xml.Name ` + "`" + `xml:"http://schemas.xmlsoap.org/Soap/envelope/ Envelope"` + "`" + `
+	XMLName xml.Name ` + "`" + `xml:"http://schemas.xmlsoap.org/soap/envelope/ Envelope"` + "`" + `
 	Body SOAPBodyRequest
 }
 
 type SOAPBodyRequest struct {
-	XMLName xml.Name ` + "`" + `xml:"http://schemas.xmlsoap.org/Soap/envelope/ Body"` + "`" + `
+	XMLName xml.Name ` + "`" + `xml:"http://schemas.xmlsoap.org/soap/envelope/ Body"` + "`" + `
 	{{range .}}
 		{{range .Operations}}
 				{{$requestType := findType .Input.Message }} ` + `
 				{{$requestTypeName := findTypeName .Input.Message }} ` + `
-  				{{$requestTypeName}} *{{$requestType}} ` + "`" + `xml:,omitempty` + "`" + `
+  				{{$requestTypeName}} *{{$requestType}} ` + "`" + `xml:"{{messageElement .Input.Message}},omitempty"` + "`" + `
 		{{end}}
 	{{end}}
 }
@@ -47,7 +48,7 @@ type SOAPEnvelopeResponse struct { ` + `
 
 func NewSOAPEnvelopResponse() *SOAPEnvelopeResponse {
 	return &SOAPEnvelopeResponse{
-		PrefixSoap: "http://schemas.xmlsoap.org/Soap/envelope/",
+		PrefixSoap: "http://schemas.xmlsoap.org/soap/envelope/",
 		PrefixXsd:  "http://www.w3.org/2001/XMLSchema",
 		PrefixXsi:  "http://www.w3.org/2001/XMLSchema-instance",
 	}
@@ -63,6 +64,58 @@ type Fault struct { ` + `
 	Detail string    ` + "`" + `xml:"detail,omitempty"` + "`" + `
 }
 
+func (f *Fault) Error() string {
+	return f.String
+}
+
+// operationNames maps the request elements of the body to their operations.
+var operationNames = map[string]string{
+{{range .}}
+	{{range .Operations}}
+		"{{findTypeName .Input.Message}}": "{{.Name}}",
+	{{end}}
+{{end}}
+}
+
+{{$server := printf "%sServer" serviceIdentifier}}
+// {{$server}} is a http.Handler answering the requests like Endpoint, its
+// operations can be set to answer with faults, to mock the service in tests.
+type {{$server}} struct {
+	mu     sync.RWMutex
+	faults map[string]*Fault
+}
+
+// New{{$server}} returns a server calling the handlers of all operations.
+func New{{$server}}() *{{$server}} {
+	return &{{$server}}{faults: map[string]*Fault{}}
+}
+
+// SetOperationFault makes the server answer the operation with the fault
+// instead of calling its handler, nil restores the handler.
+func (s *{{$server}}) SetOperationFault(operation string, fault *Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if fault == nil {
+		delete(s.faults, operation)
+		return
+	}
+	s.faults[operation] = fault
+}
+
+func (s *{{$server}}) operationFault(name string) *Fault {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.faults[operationNames[name]]
+}
+
+func (s *{{$server}}) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	request := SOAPEnvelopeRequest{}
+	request.call(w, r, s)
+}
+
 
 type SOAPBodyResponse struct { ` + `
 	XMLName xml.Name   ` + "`" + `xml:"Soap:Body"` + "`" + `
@@ -71,7 +124,7 @@ type SOAPBodyResponse struct { ` + `
 	{{range .Operations}}
 		{{$responseType := findType .Output.Message }}
 		{{$requestTypeName := findTypeName .Input.Message }} ` + `
-			{{$requestTypeName}} *{{$responseType}} ` + "`" + `xml:",omitempty"` + "`" + `
+			{{$requestTypeName}} *{{$responseType}} ` + "`" + `xml:"{{if plainTypes}}{{with messageElementName .Output.Message}}{{if .Space}}{{.Space}} {{end}}{{.Local}}{{end}}{{end}},omitempty"` + "`" + `
 	{{end}}
 {{end}}
 
@@ -90,7 +143,7 @@ func (service *SOAPBodyRequest) {{$requestTypeName}}Func(request *{{$requestType
 {{end}}
 
 
-func (service *SOAPEnvelopeRequest) call(w http.ResponseWriter, r *http.Request) {
+func (service *SOAPEnvelopeRequest) call(w http.ResponseWriter, r *http.Request, server *{{serviceIdentifier}}Server) {
 	w.Header().Add("Content-Type", "text/xml; charset=utf-8")
 	val := reflect.ValueOf(&service.Body).Elem()
 	n := val.NumField()
@@ -106,11 +159,17 @@ func (service *SOAPEnvelopeRequest) call(w http.ResponseWriter, r *http.Request)
 	resp := NewSOAPEnvelopResponse()
 	defer func() {
 		if r := recover(); r != nil {
-			resp.Body.Fault = &Fault{}
-			resp.Body.Fault.Space = "http://schemas.xmlsoap.org/Soap/envelope/"
-			resp.Body.Fault.Code = "Soap:Server"
-			resp.Body.Fault.Detail = fmt.Sprintf("%v", r)
-			resp.Body.Fault.String = fmt.Sprintf("%v", r)
+			if fault, ok := r.(*Fault); ok {
+				copied := *fault
+				resp.Body.Fault = &copied
+			} else {
+				resp.Body.Fault = &Fault{}
+				resp.Body.Fault.Code = "Soap:Server"
+				resp.Body.Fault.Detail = fmt.Sprintf("%v", r)
+				resp.Body.Fault.String = fmt.Sprintf("%v", r)
+			}
+			resp.Body.Fault.Space = "http://schemas.xmlsoap.org/soap/envelope/"
+			w.WriteHeader(http.StatusInternalServerError)
 		}
 		xml.NewEncoder(w).Encode(resp)
 	}()
@@ -143,6 +202,10 @@ func (service *SOAPEnvelopeRequest) call(w http.ResponseWriter, r *http.Request)
 	if !find {
 		panic(WSDLUndefinedError)
 	} else {
+		if fault := server.operationFault(name); fault != nil {
+			panic(fault)
+		}
+
 		m := val.Addr().MethodByName(name + "Func")
 		if !m.IsValid() {
 			panic(WSDLUndefinedError)
@@ -160,7 +223,7 @@ func (service *SOAPEnvelopeRequest) call(w http.ResponseWriter, r *http.Request)
 
 func Endpoint(w http.ResponseWriter, r *http.Request) {
 	request := SOAPEnvelopeRequest{}
-	request.call(w, r)
+	request.call(w, r, nil)
 }
 
 `
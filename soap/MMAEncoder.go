@@ -24,11 +24,19 @@ type mmaDecoder struct {
 	reader *multipart.Reader
 }
 
-func newMmaEncoder(w io.Writer, attachments []MIMEMultipartAttachment) *mmaEncoder {
+// newMmaEncoder returns an encoder writing the parts separated by boundary,
+// a random one if empty.
+func newMmaEncoder(w io.Writer, attachments []MIMEMultipartAttachment, boundary string) (*mmaEncoder, error) {
+	writer := multipart.NewWriter(w)
+	if boundary != "" {
+		if err := writer.SetBoundary(boundary); err != nil {
+			return nil, fmt.Errorf("invalid MMA boundary %q: %w", boundary, err)
+		}
+	}
 	return &mmaEncoder{
-		writer:      multipart.NewWriter(w),
+		writer:      writer,
 		attachments: attachments,
-	}
+	}, nil
 }
 
 func newMmaDecoder(r io.Reader, boundary string) *mmaDecoder {
@@ -56,10 +64,18 @@ func (e *mmaEncoder) Encode(v interface{}) error {
 
 	// 2. write attachments parts
 	for _, attachment := range e.attachments {
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = fmt.Sprintf("application/octet-stream; name=%s", attachment.Name)
+		}
+		contentID := attachment.ContentID
+		if contentID == "" {
+			contentID = attachment.Name
+		}
 		attHeader := make(textproto.MIMEHeader)
-		attHeader.Set("Content-Type", fmt.Sprintf("application/octet-stream; name=%s", attachment.Name))
+		attHeader.Set("Content-Type", contentType)
 		attHeader.Set("Content-Transfer-Encoding", "binary")
-		attHeader.Set("Content-ID", fmt.Sprintf("<%s>", attachment.Name))
+		attHeader.Set("Content-ID", fmt.Sprintf("<%s>", strings.Trim(contentID, "<>")))
 		attHeader.Set("Content-Disposition",
 			fmt.Sprintf("attachment; name=\"%s\"; filename=\"%s\"", attachment.Name, attachment.Name))
 		var attachmentPartWriter io.Writer
@@ -120,7 +136,7 @@ func (d *mmaDecoder) Decode(v interface{}) error {
 		contentType := p.Header.Get("Content-Type")
 		if contentType == "text/xml;charset=UTF-8" {
 			// decode SOAP part
-			err := xml.NewDecoder(p).Decode(v)
+			err := newNamespaceDecoder(p).Decode(v)
 			if err != nil {
 				return err
 			}
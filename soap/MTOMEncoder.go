@@ -1,6 +1,7 @@
 package soap
 
 import (
+	"bytes"
 	"encoding/xml"
 	"errors"
 	"fmt"
@@ -10,10 +11,13 @@ import (
 	"mime"
 	"mime/multipart"
 	"net/textproto"
+	"net/url"
 	"reflect"
 	"strings"
 )
 
+const xopNamespace = "http://www.w3.org/2004/08/xop/include"
+
 type mtomEncoder struct {
 	writer *multipart.Writer
 }
@@ -221,6 +225,7 @@ func (d *mtomDecoder) Decode(v interface{}) error {
 	fields := make([]reflect.Value, 0)
 	getBinaryFields(v, &fields)
 
+	var envelope []byte
 	packages := make(map[string]*Binary, 0)
 	for {
 		p, err := d.reader.NextPart()
@@ -232,8 +237,9 @@ func (d *mtomDecoder) Decode(v interface{}) error {
 		}
 		contentType := p.Header.Get("Content-Type")
 		if contentType == "application/xop+xml" {
-			err := xml.NewDecoder(p).Decode(v)
-			if err != nil {
+			// the envelope references the parts which follow it, only it is
+			// kept until they are read from the stream
+			if envelope, err = ioutil.ReadAll(p); err != nil {
 				return err
 			}
 		} else {
@@ -254,11 +260,50 @@ func (d *mtomDecoder) Decode(v interface{}) error {
 		}
 	}
 
+	if envelope != nil {
+		dec := newNamespaceDecoder(bytes.NewReader(envelope))
+		dec.tracker.attachments = make(map[string][]byte, len(packages))
+		for contentID, pkg := range packages {
+			dec.tracker.attachments[contentID] = *pkg.content
+		}
+		if err := dec.Decode(v); err != nil {
+			return err
+		}
+	}
+
 	// Set binary fields with correct content
 	for _, f := range fields {
 		b := f.Interface().(*Binary)
-		b.content = packages[b.packageID].content
-		b.contentType = packages[b.packageID].contentType
+		if b == nil {
+			continue
+		}
+		if pkg, ok := packages[b.packageID]; ok {
+			b.content = pkg.content
+			b.contentType = pkg.contentType
+		}
 	}
 	return nil
 }
+
+// attachment returns the content of the part an xop:Include or the href of
+// el references by cid: URL, and whether el is an xop:Include.
+func (t *namespaceTracker) attachment(el xml.StartElement) (content []byte, include bool, ok bool) {
+	if len(t.attachments) == 0 {
+		return
+	}
+	for _, attr := range el.Attr {
+		if attr.Name.Space != "" || attr.Name.Local != "href" || !strings.HasPrefix(attr.Value, "cid:") {
+			continue
+		}
+		contentID := strings.TrimPrefix(attr.Value, "cid:")
+		if unescaped, err := url.PathUnescape(contentID); err == nil {
+			contentID = unescaped
+		}
+		if content, ok = t.attachments[contentID]; ok {
+			namespace, _ := t.lookup(el.Name.Space)
+			include = el.Name.Local == "Include" && namespace == xopNamespace
+		}
+		return
+	}
+	return
+}
@@ -0,0 +1,163 @@
+package soap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// xsiNamespace is the namespace of the xsi:type attribute.
+const xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+// anyTypePrefix is the prefix bound to the element namespace when AnyType content is decoded again.
+const anyTypePrefix = "_anytype"
+
+// AnyType holds the content of an xsd:anyType element as raw XML.
+//
+// Decoded values also record the element name, its xsi:type and the namespace
+// declarations in scope, so the content can be decoded once more into a
+// concrete Go type, with As for a known type or with Value for the type
+// registered in NamespaceTypes.
+type AnyType struct {
+	InnerXML string `xml:",innerxml"`
+
+	// Name is the name of the decoded element.
+	Name xml.Name `xml:"-"`
+	// Type is the xsi:type of the decoded element, with its prefix resolved.
+	Type xml.Name `xml:"-"`
+
+	// declarations are the namespace declarations in scope of the element, as attribute text.
+	declarations string
+}
+
+// UnmarshalXML keeps the content as is and records the element name, type and namespaces.
+func (a *AnyType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	scope := map[string]string{}
+	tracker, tracked := namespaceTrackers.Load(d)
+	if tracked {
+		tracker.(*namespaceTracker).capture()
+		for _, declared := range tracker.(*namespaceTracker).scopes {
+			for prefix, namespace := range declared {
+				scope[prefix] = namespace
+			}
+		}
+	}
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "xmlns" {
+			scope[attr.Name.Local] = attr.Value
+		} else if attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+			scope[""] = attr.Value
+		}
+	}
+
+	var content struct {
+		InnerXML string `xml:",innerxml"`
+	}
+	if err := d.DecodeElement(&content, &start); err != nil {
+		return err
+	}
+
+	a.InnerXML = content.InnerXML
+	if tracked {
+		a.InnerXML = string(tracker.(*namespaceTracker).inner)
+	}
+	a.Name = start.Name
+	a.Type = xml.Name{}
+	for _, attr := range start.Attr {
+		if attr.Name.Space == xsiNamespace && attr.Name.Local == "type" {
+			prefix, local := splitQName(attr.Value)
+			a.Type = xml.Name{Space: scope[prefix], Local: local}
+		}
+	}
+	a.declarations = declarations(scope)
+	return nil
+}
+
+// As decodes the content into target, as if the element had been declared with the type of target.
+func (a AnyType) As(target interface{}) error {
+	return xml.Unmarshal(a.document(), target)
+}
+
+// Value decodes the content into a new value of the type registered in
+// NamespaceTypes for the xsi:type of the element, or else for its name.
+func (a AnyType) Value() (interface{}, error) {
+	for _, name := range []xml.Name{a.Type, a.Name} {
+		if name.Local == "" {
+			continue
+		}
+		if value, ok := NamespaceTypes.New(name); ok {
+			return value, a.As(value)
+		}
+	}
+	return nil, fmt.Errorf("no type registered for %s (xsi:type %s)", QName(a.Name), QName(a.Type))
+}
+
+// document returns the content wrapped in its element, with the namespaces of its scope declared.
+func (a AnyType) document() []byte {
+	local := a.Name.Local
+	if local == "" {
+		local = "anyType"
+	}
+
+	var doc bytes.Buffer
+	doc.WriteString("<" + anyTypePrefix + ":" + local + " xmlns:" + anyTypePrefix + `="`)
+	xml.EscapeText(&doc, []byte(a.Name.Space))
+	doc.WriteString(`"` + a.declarations + ">")
+	doc.WriteString(a.InnerXML)
+	doc.WriteString("</" + anyTypePrefix + ":" + local + ">")
+	return doc.Bytes()
+}
+
+func declarations(scope map[string]string) string {
+	prefixes := make([]string, 0, len(scope))
+	for prefix := range scope {
+		if prefix != anyTypePrefix {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	sort.Strings(prefixes)
+
+	var text bytes.Buffer
+	for _, prefix := range prefixes {
+		if prefix == "" {
+			text.WriteString(` xmlns="`)
+		} else {
+			text.WriteString(" xmlns:" + prefix + `="`)
+		}
+		xml.EscapeText(&text, []byte(scope[prefix]))
+		text.WriteString(`"`)
+	}
+	return text.String()
+}
+
+// TypeRegistry maps the XML names of types and elements to constructors of their Go types.
+type TypeRegistry struct {
+	mu        sync.RWMutex
+	factories map[xml.Name]func() interface{}
+}
+
+// Register sets the constructor for values of the named type or element.
+func (r *TypeRegistry) Register(name xml.Name, factory func() interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.factories == nil {
+		r.factories = map[xml.Name]func() interface{}{}
+	}
+	r.factories[name] = factory
+}
+
+// New returns a new value for the named type or element, false if none is registered.
+func (r *TypeRegistry) New(name xml.Name) (interface{}, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	factory, ok := r.factories[name]
+	if !ok {
+		return nil, false
+	}
+	return factory(), true
+}
+
+// NamespaceTypes is the registry AnyType.Value looks up the concrete types in.
+var NamespaceTypes = &TypeRegistry{}
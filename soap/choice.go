@@ -0,0 +1,76 @@
+package soap
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+)
+
+// MarshalChoice encodes the item of a repeating choice which v points to,
+// each set field as an element named by its xml tag, without an element of
+// the item itself. The elements of a wildcard field are encoded as they are.
+func MarshalChoice(e *xml.Encoder, v interface{}) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if value.Field(i).IsZero() {
+			continue
+		}
+		var err error
+		if name := choiceElementName(field); name != "" {
+			err = e.EncodeElement(value.Field(i).Interface(), xml.StartElement{Name: xml.Name{Local: name}})
+		} else if isWildcard(field) {
+			err = e.Encode(value.Field(i).Interface())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalChoice decodes the element start to the field of the repeating
+// choice item v points to which its xml tag names, or else to its wildcard
+// field. Elements of no field are skipped.
+func UnmarshalChoice(d *xml.Decoder, start xml.StartElement, v interface{}) error {
+	value := reflect.ValueOf(v).Elem()
+	if i := elementField(value.Type(), start.Name.Local); i >= 0 {
+		return d.DecodeElement(value.Field(i).Addr().Interface(), &start)
+	}
+	return d.Skip()
+}
+
+// elementField returns the index of the field of the item type t which
+// decodes the element local, the wildcard field for unknown elements, -1 if
+// none does.
+func elementField(t reflect.Type, local string) int {
+	wildcard := -1
+	for i := 0; i < t.NumField(); i++ {
+		if choiceElementName(t.Field(i)) == local {
+			return i
+		}
+		if wildcard < 0 && isWildcard(t.Field(i)) {
+			wildcard = i
+		}
+	}
+	return wildcard
+}
+
+// choiceElementName returns the local name of the element of field, empty
+// for fields without element.
+func choiceElementName(field reflect.StructField) string {
+	tag := field.Tag.Get("xml")
+	name := strings.Split(tag, ",")[0]
+	if name == "-" || field.PkgPath != "" || strings.Contains(tag, ",attr") {
+		return ""
+	}
+	if i := strings.LastIndex(name, " "); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// isWildcard reports whether field takes the elements of an xsd:any.
+func isWildcard(field reflect.StructField) bool {
+	return field.PkgPath == "" && strings.HasPrefix(field.Tag.Get("xml"), ",any")
+}
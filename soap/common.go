@@ -1,11 +1,35 @@
 package soap
 
-type AnyType struct {
-	InnerXML string `"xml:",innerxml"`
-}
+import (
+	"encoding/xml"
+	"reflect"
+)
 
 type AnyURI string
 
 type NCName string
 
-type QName string
+// CDATAString is a string written wrapped in a CDATA section, for services
+// which reject entity escaped content.
+type CDATAString string
+
+func (s CDATAString) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(struct {
+		Value string `xml:",cdata"`
+	}{string(s)}, start)
+}
+
+func (s *CDATAString) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return d.DecodeElement((*string)(s), &start)
+}
+
+// OperationInfo describes an operation of a generated client, generated
+// clients list theirs by operation name in their <Service>Operations map.
+type OperationInfo struct {
+	// Action is the SOAPAction of the operation.
+	Action string
+	// Request and Response are the types of the request and response
+	// elements, nil if the operation has none.
+	Request  reflect.Type
+	Response reflect.Type
+}
@@ -0,0 +1,35 @@
+package soap
+
+import (
+	"context"
+	"encoding/xml"
+)
+
+// CorrelationHeader describes the SOAP header the Client adds to the calls
+// made with a context carrying a correlation ID, see WithCorrelationID.
+type CorrelationHeader struct {
+	// Name is the header element, like {Space: "urn:example:tracing", Local: "CorrelationId"}.
+	Name xml.Name
+}
+
+// element returns the header element with the id as its content.
+func (h *CorrelationHeader) element(id string) interface{} {
+	return struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	}{h.Name, id}
+}
+
+type correlationIDKey struct{}
+
+// WithCorrelationID returns a copy of ctx carrying the correlation ID, which
+// calls made with it send in the Options.CorrelationHeader.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationID returns the correlation ID of ctx, if it carries one.
+func CorrelationID(ctx context.Context) (id string, ok bool) {
+	id, ok = ctx.Value(correlationIDKey{}).(string)
+	return
+}
@@ -0,0 +1,21 @@
+package soap
+
+import "encoding/xml"
+
+// Element marshals Content as an element named Name. Generated clients wrap
+// requests in it when the generated types have no XMLName field, which would
+// name the element otherwise.
+type Element struct {
+	Name    xml.Name
+	Content interface{}
+}
+
+// NewElement wraps content in an Element named local in the namespace space.
+func NewElement(space, local string, content interface{}) *Element {
+	return &Element{Name: xml.Name{Space: space, Local: local}, Content: content}
+}
+
+// MarshalXML writes the Content with the Name.
+func (el Element) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return e.EncodeElement(el.Content, xml.StartElement{Name: el.Name})
+}
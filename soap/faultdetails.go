@@ -0,0 +1,104 @@
+package soap
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+)
+
+// FaultDetails is a FaultError which decodes the first element of the fault
+// detail that is one of Names into a new value of the type registered for it
+// in NamespaceTypes. The generated services pass the detail elements of the
+// faults an operation declares, so callers don't choose a fault detail.
+type FaultDetails struct {
+	Names []xml.Name
+
+	// Name is the name of the decoded detail element.
+	Name xml.Name
+	// Value is the decoded detail, nil if the detail has none of Names.
+	Value interface{}
+}
+
+// UnmarshalXML decodes the first child of the detail with a registered type
+// among Names, the other children are skipped.
+func (f *FaultDetails) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if f.Value == nil && f.declares(t.Name) {
+				if value, ok := NamespaceTypes.New(t.Name); ok {
+					if err := d.DecodeElement(value, &t); err != nil {
+						return err
+					}
+					f.Name, f.Value = t.Name, value
+					continue
+				}
+			}
+			if err := d.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func (f *FaultDetails) declares(name xml.Name) bool {
+	for _, declared := range f.Names {
+		if declared == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorString returns the error string of a decoded value which is a
+// FaultError itself.
+func (f *FaultDetails) ErrorString() string {
+	if detail, ok := f.Value.(FaultError); ok {
+		return detail.ErrorString()
+	}
+	return ""
+}
+
+// HasData reports whether a decoded value which is a FaultError has data,
+// the faultstring is the error message otherwise.
+func (f *FaultDetails) HasData() bool {
+	detail, ok := f.Value.(FaultError)
+	return ok && detail.HasData()
+}
+
+// FaultDetailError is the error of a Fault whose detail decoded to Detail,
+// a pointer to the Go type of the detail element. Err is the Fault, or the
+// MappedFaultError of its code.
+type FaultDetailError struct {
+	Name   xml.Name
+	Detail interface{}
+	Err    error
+}
+
+func (e *FaultDetailError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FaultDetailError) Unwrap() error {
+	return e.Err
+}
+
+// CallOperationContextWithFaultDetails is CallOperationContext which decodes
+// the detail of a SOAP fault with FaultDetails of details, the fault is then
+// returned as FaultDetailError if its detail decoded.
+func (s *Client) CallOperationContextWithFaultDetails(ctx context.Context, operation, soapAction string, request interface{},
+	responseHeader map[string]interface{}, responseContent interface{}, details []xml.Name, headers map[string]string) error {
+	faultDetail := &FaultDetails{Names: details}
+	err := s.call(WithOperation(ctx, operation), soapAction, request, responseHeader, responseContent, faultDetail, nil, headers)
+	var fault *Fault
+	if faultDetail.Value != nil && errors.As(err, &fault) {
+		return &FaultDetailError{Name: faultDetail.Name, Detail: faultDetail.Value, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+package soap
+
+import (
+	"errors"
+	"sort"
+)
+
+// FaultErrors maps fault codes to the errors callers test for with
+// errors.Is. Codes match the faultcode exactly or by their local name, so
+// "NotAuthorized" matches "tns:NotAuthorized". A faultcode without exact
+// match takes the error of its local name, else of the first code of the
+// same local name in sorted order.
+type FaultErrors map[string]error
+
+// Map returns a MappedFaultError if err is a Fault with a mapped code, err
+// otherwise. A FaultDetailError keeps its detail, with the mapped error as
+// its Err.
+func (m FaultErrors) Map(err error) error {
+	if detailed, ok := err.(*FaultDetailError); ok {
+		if mapped := m.Map(detailed.Err); mapped != detailed.Err {
+			return &FaultDetailError{Name: detailed.Name, Detail: detailed.Detail, Err: mapped}
+		}
+		return err
+	}
+	var fault *Fault
+	if len(m) == 0 || !errors.As(err, &fault) {
+		return err
+	}
+	if mapped, ok := m[fault.Code]; ok {
+		return &MappedFaultError{Err: mapped, Fault: fault}
+	}
+	_, local := splitQName(fault.Code)
+	if mapped, ok := m[local]; ok {
+		return &MappedFaultError{Err: mapped, Fault: fault}
+	}
+	codes := make([]string, 0, len(m))
+	for code := range m {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if _, codeLocal := splitQName(code); codeLocal == local {
+			return &MappedFaultError{Err: m[code], Fault: fault}
+		}
+	}
+	return err
+}
+
+// MappedFaultError is a Fault matching the error of its code in
+// FaultErrors, the Fault itself is its cause.
+type MappedFaultError struct {
+	Err   error
+	Fault *Fault
+}
+
+func (e *MappedFaultError) Error() string {
+	return e.Fault.Error()
+}
+
+// Is reports whether target is the error the fault code is mapped to.
+func (e *MappedFaultError) Is(target error) bool {
+	return target == e.Err
+}
+
+func (e *MappedFaultError) Unwrap() error {
+	return e.Fault
+}
@@ -2,7 +2,10 @@ package soap
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
+	"reflect"
+	"strings"
 )
 
 type XmlContent struct {
@@ -37,6 +40,49 @@ func (o *XmlContent) AddItem(item interface{}) (err error) {
 	return
 }
 
+// SetItem replaces the items of the element name with item, or removes them
+// if item is nil.
+func (o *XmlContent) SetItem(name xml.Name, item interface{}) (err error) {
+	items := o.Items
+	o.Content = ""
+	o.Items = nil
+	for _, existing := range items {
+		if itemName(existing) == name {
+			continue
+		}
+		if err = o.AddItem(existing); err != nil {
+			return
+		}
+	}
+	if item == nil {
+		return
+	}
+	return o.AddItem(item)
+}
+
+// itemName returns the name of the root element of an item, marshaled or
+// given as XML string.
+func itemName(item interface{}) xml.Name {
+	data, ok := item.(string)
+	if !ok {
+		marshaled, err := xml.Marshal(item)
+		if err != nil {
+			return xml.Name{}
+		}
+		data = string(marshaled)
+	}
+	d := xml.NewDecoder(strings.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return xml.Name{}
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name
+		}
+	}
+}
+
 func (o *XmlContent) SetItems(items []interface{}) (err error) {
 	o.Content = ""
 	o.Items = items
@@ -48,11 +94,31 @@ func (o *XmlContent) SetItems(items []interface{}) (err error) {
 	return
 }
 
+type soapHeadersKey struct{}
+
+// WithSOAPHeaders returns a copy of ctx carrying header elements, which calls
+// made with it send after the Client Headers, in addition to those ctx
+// already carries.
+func WithSOAPHeaders(ctx context.Context, headers ...interface{}) context.Context {
+	return context.WithValue(ctx, soapHeadersKey{}, append(SOAPHeaders(ctx), headers...))
+}
+
+// SOAPHeaders returns a copy of the header elements ctx carries.
+func SOAPHeaders(ctx context.Context) []interface{} {
+	headers, _ := ctx.Value(soapHeadersKey{}).([]interface{})
+	return append([]interface{}(nil), headers...)
+}
+
 type Header struct {
 	XMLName xml.Name    `xml:"soap:Header"`
 	Headers *XmlContent `xml:",innerxml"`
 }
 
+// empty reports whether the header is nil or has no items.
+func (o *Header) empty() bool {
+	return o == nil || o.Headers == nil || len(o.Headers.Items) == 0
+}
+
 //func (o *Header) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 //	return e.EncodeElement(o.Headers.Content, start)
 //}
@@ -82,3 +148,83 @@ func (o *ResponseHeaders) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (
 	(*o)[e.XMLName.Local] = e.Content
 	return
 }
+
+// UnmarshalXML decodes each header element into Headers by its local name.
+//
+// A value put into Headers before the call selects the Go type of the header:
+// a pointer is decoded into and a pointer to a slice gets every occurrence
+// appended. Other headers decode to their character data as string. Repeated
+// headers accumulate into []string, or into []interface{} for typed headers.
+func (o *HeaderResponse) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	o.XMLName = start.Name
+	if o.Headers == nil {
+		o.Headers = ResponseHeaders{}
+	}
+
+	decoded := map[string]bool{}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			name := el.Name.Local
+			var value interface{}
+			if value, err = o.Headers.decode(d, el, decoded[name]); err != nil {
+				return err
+			}
+			decoded[name] = true
+			o.Headers[name] = value
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// decode reads the header element and returns the new value for its entry.
+func (o ResponseHeaders) decode(d *xml.Decoder, start xml.StartElement, repeated bool) (interface{}, error) {
+	current := o[start.Name.Local]
+	if values, ok := current.([]interface{}); ok && repeated {
+		value, err := decodeLike(d, start, values[0])
+		return append(values, value), err
+	}
+
+	target := reflect.ValueOf(current)
+	if target.Kind() == reflect.Ptr && !target.IsNil() {
+		if target.Elem().Kind() == reflect.Slice {
+			item := reflect.New(target.Elem().Type().Elem())
+			if err := d.DecodeElement(item.Interface(), &start); err != nil {
+				return nil, err
+			}
+			target.Elem().Set(reflect.Append(target.Elem(), item.Elem()))
+			return current, nil
+		}
+		if !repeated {
+			return current, d.DecodeElement(current, &start)
+		}
+		value, err := decodeLike(d, start, current)
+		return []interface{}{current, value}, err
+	}
+
+	part := HeaderPart{}
+	if err := d.DecodeElement(&part, &start); err != nil {
+		return nil, err
+	}
+	if repeated {
+		switch previous := current.(type) {
+		case string:
+			return []string{previous, part.Content}, nil
+		case []string:
+			return append(previous, part.Content), nil
+		}
+	}
+	return part.Content, nil
+}
+
+// decodeLike decodes the element into a new value of the pointer type of like.
+func decodeLike(d *xml.Decoder, start xml.StartElement, like interface{}) (interface{}, error) {
+	value := reflect.New(reflect.TypeOf(like).Elem()).Interface()
+	return value, d.DecodeElement(value, &start)
+}
@@ -0,0 +1,38 @@
+package soap
+
+import (
+	"encoding/hex"
+	"encoding/xml"
+	"strings"
+)
+
+// HexBinary is binary data written in the hex encoding of xsd:hexBinary,
+// a plain []byte field would be written base64 encoded instead.
+type HexBinary []byte
+
+// MarshalXML writes the data as upper case hex digits, the canonical form of xsd:hexBinary.
+func (b HexBinary) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(strings.ToUpper(hex.EncodeToString(b)), start)
+}
+
+// UnmarshalXML reads hex digits of either case.
+func (b *HexBinary) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var value string
+	if err := d.DecodeElement(&value, &start); err != nil {
+		return err
+	}
+	return b.decode(value)
+}
+
+func (b HexBinary) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: name, Value: strings.ToUpper(hex.EncodeToString(b))}, nil
+}
+
+func (b *HexBinary) UnmarshalXMLAttr(attr xml.Attr) error {
+	return b.decode(attr.Value)
+}
+
+func (b *HexBinary) decode(value string) (err error) {
+	*b, err = hex.DecodeString(strings.TrimSpace(value))
+	return
+}
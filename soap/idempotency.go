@@ -0,0 +1,33 @@
+package soap
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+)
+
+type idempotencyKeyKey struct{}
+
+// WithIdempotencyKey returns a copy of ctx carrying the idempotency key,
+// which calls made with it send in the Options.IdempotencyKeyHeader instead
+// of a generated one. Pass the same key to retry a call by hand.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+// IdempotencyKey returns the idempotency key of ctx, if it carries one.
+func IdempotencyKey(ctx context.Context) (key string, ok bool) {
+	key, ok = ctx.Value(idempotencyKeyKey{}).(string)
+	return
+}
+
+// newIdempotencyKey returns a random version 4 UUID.
+func newIdempotencyKey() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,339 @@
+package soap
+
+import (
+	"encoding"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+var xmlNameType = reflect.TypeOf(xml.Name{})
+
+// StructToMap converts a struct of the generated types, or a pointer to one,
+// to a map keyed by the local names of the xml tags of its fields, for
+// tools handling requests and responses generically. Fields without name in
+// their tag, like ",chardata", are keyed by the field name. Nested structs
+// become maps and slices []interface{}, structs with a MarshalText or
+// MarshalXMLAttr method, like dates, become strings. Nil pointers and slices
+// and the XMLName are left out, the fields of embedded bases are merged.
+func StructToMap(v interface{}) (map[string]interface{}, error) {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, fmt.Errorf("nil %v", value.Type())
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%v is not a struct", value.Type())
+	}
+	if !value.CanAddr() {
+		addressable := reflect.New(value.Type()).Elem()
+		addressable.Set(value)
+		value = addressable
+	}
+	ret := map[string]interface{}{}
+	if err := structToMap(value, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// MapToStruct sets the fields of the struct v points to from a map as
+// StructToMap returns it. Numbers convert between the numeric kinds, as
+// decoded from JSON for example, if they fit. Keys matching no field are an
+// error.
+func MapToStruct(m map[string]interface{}, v interface{}) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%T is not a pointer to a struct", v)
+	}
+	if m == nil {
+		return nil
+	}
+	return fromMapValue(m, value.Elem())
+}
+
+// mapKey returns the key of the field, empty if it has none or is an
+// embedded base whose fields are merged.
+func mapKey(field reflect.StructField) (key string, embedded bool) {
+	tag := field.Tag.Get("xml")
+	if tag == "-" || field.PkgPath != "" || field.Type == xmlNameType {
+		return "", false
+	}
+	name := strings.Split(tag, ",")[0]
+	if i := strings.LastIndex(name, " "); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" {
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && fieldType.Kind() == reflect.Struct {
+			return "", true
+		}
+		name = field.Name
+	}
+	return name, false
+}
+
+func structToMap(value reflect.Value, ret map[string]interface{}) error {
+	for i := 0; i < value.NumField(); i++ {
+		key, embedded := mapKey(value.Type().Field(i))
+		field := value.Field(i)
+		if embedded {
+			if field.Kind() == reflect.Ptr {
+				if field.IsNil() {
+					continue
+				}
+				field = field.Elem()
+			}
+			if err := structToMap(field, ret); err != nil {
+				return err
+			}
+			continue
+		}
+		if key == "" {
+			continue
+		}
+		converted, err := toMapValue(field)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if converted != nil {
+			ret[key] = converted
+		}
+	}
+	return nil
+}
+
+// toMapValue converts a field value, nil for nil pointers and slices.
+func toMapValue(value reflect.Value) (interface{}, error) {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return nil, nil
+		}
+		return toMapValue(value.Elem())
+	case reflect.Struct:
+		if text, ok, err := marshalText(value); ok {
+			return text, err
+		}
+		ret := map[string]interface{}{}
+		if err := structToMap(value, ret); err != nil {
+			return nil, err
+		}
+		return ret, nil
+	case reflect.Slice:
+		if value.IsNil() {
+			return nil, nil
+		}
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return append([]byte(nil), value.Bytes()...), nil
+		}
+		fallthrough
+	case reflect.Array:
+		ret := make([]interface{}, value.Len())
+		for i := range ret {
+			item, err := toMapValue(value.Index(i))
+			if err != nil {
+				return nil, fmt.Errorf("%d: %w", i, err)
+			}
+			ret[i] = item
+		}
+		return ret, nil
+	}
+	return value.Interface(), nil
+}
+
+// marshalText returns the text of a struct with a MarshalText or
+// MarshalXMLAttr method, false if it has neither.
+func marshalText(value reflect.Value) (string, bool, error) {
+	target := value.Interface()
+	if value.CanAddr() {
+		target = value.Addr().Interface()
+	}
+	switch marshaler := target.(type) {
+	case encoding.TextMarshaler:
+		text, err := marshaler.MarshalText()
+		return string(text), true, err
+	case xml.MarshalerAttr:
+		attr, err := marshaler.MarshalXMLAttr(xml.Name{})
+		return attr.Value, true, err
+	}
+	return "", false, nil
+}
+
+// unmarshalText sets a struct with an UnmarshalText or UnmarshalXMLAttr
+// method from text, false if it has neither.
+func unmarshalText(text string, value reflect.Value) (bool, error) {
+	switch unmarshaler := value.Addr().Interface().(type) {
+	case encoding.TextUnmarshaler:
+		return true, unmarshaler.UnmarshalText([]byte(text))
+	case xml.UnmarshalerAttr:
+		return true, unmarshaler.UnmarshalXMLAttr(xml.Attr{Value: text})
+	}
+	return false, nil
+}
+
+// mapToStruct sets the fields of value from m, recording the keys used.
+func mapToStruct(m map[string]interface{}, value reflect.Value, used map[string]bool) error {
+	for i := 0; i < value.NumField(); i++ {
+		key, embedded := mapKey(value.Type().Field(i))
+		field := value.Field(i)
+		if embedded {
+			base := field
+			if field.Kind() == reflect.Ptr {
+				base = reflect.New(field.Type().Elem()).Elem()
+			}
+			baseUsed := map[string]bool{}
+			if err := mapToStruct(m, base, baseUsed); err != nil {
+				return err
+			}
+			if field.Kind() == reflect.Ptr && len(baseUsed) > 0 {
+				field.Set(base.Addr())
+			}
+			for key := range baseUsed {
+				used[key] = true
+			}
+			continue
+		}
+		item, ok := m[key]
+		if key == "" || !ok {
+			continue
+		}
+		used[key] = true
+		if err := fromMapValue(item, field); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// fromMapValue sets value from an item of a map.
+func fromMapValue(item interface{}, value reflect.Value) error {
+	if item == nil {
+		value.Set(reflect.Zero(value.Type()))
+		return nil
+	}
+	if value.Kind() == reflect.Ptr {
+		target := reflect.New(value.Type().Elem())
+		if err := fromMapValue(item, target.Elem()); err != nil {
+			return err
+		}
+		value.Set(target)
+		return nil
+	}
+
+	source := reflect.ValueOf(item)
+	switch value.Kind() {
+	case reflect.Struct:
+		if text, ok := item.(string); ok {
+			if ok, err := unmarshalText(text, value); ok {
+				return err
+			}
+		}
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("got %T for %v", item, value.Type())
+		}
+		used := map[string]bool{}
+		if err := mapToStruct(m, value, used); err != nil {
+			return err
+		}
+		for key := range m {
+			if !used[key] {
+				return fmt.Errorf("%s: no field in %v", key, value.Type())
+			}
+		}
+		return nil
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			switch data := item.(type) {
+			case []byte:
+				value.SetBytes(append([]byte(nil), data...))
+				return nil
+			case string:
+				value.SetBytes([]byte(data))
+				return nil
+			}
+		}
+		if source.Kind() != reflect.Slice && source.Kind() != reflect.Array {
+			return fmt.Errorf("got %T for %v", item, value.Type())
+		}
+		items := reflect.MakeSlice(value.Type(), source.Len(), source.Len())
+		for i := 0; i < source.Len(); i++ {
+			if err := fromMapValue(source.Index(i).Interface(), items.Index(i)); err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+		}
+		value.Set(items)
+		return nil
+	}
+	return setScalar(source, value)
+}
+
+// setScalar sets value to source, converting between the numeric kinds
+// and between types of the same kind.
+func setScalar(source, value reflect.Value) error {
+	if source.Type().AssignableTo(value.Type()) {
+		value.Set(source)
+		return nil
+	}
+	switch {
+	case isInt(value.Kind()) && isFloat(source.Kind()):
+		f := source.Float()
+		if f != math.Trunc(f) || value.OverflowInt(int64(f)) {
+			return fmt.Errorf("%v doesn't fit %v", f, value.Type())
+		}
+		value.SetInt(int64(f))
+	case isInt(value.Kind()) && isInt(source.Kind()):
+		if value.OverflowInt(source.Int()) {
+			return fmt.Errorf("%v doesn't fit %v", source.Int(), value.Type())
+		}
+		value.SetInt(source.Int())
+	case isInt(value.Kind()) && isUint(source.Kind()):
+		if source.Uint() > math.MaxInt64 || value.OverflowInt(int64(source.Uint())) {
+			return fmt.Errorf("%v doesn't fit %v", source.Uint(), value.Type())
+		}
+		value.SetInt(int64(source.Uint()))
+	case isUint(value.Kind()) && isFloat(source.Kind()):
+		f := source.Float()
+		if f != math.Trunc(f) || f < 0 || value.OverflowUint(uint64(f)) {
+			return fmt.Errorf("%v doesn't fit %v", f, value.Type())
+		}
+		value.SetUint(uint64(f))
+	case isUint(value.Kind()) && isInt(source.Kind()):
+		if source.Int() < 0 || value.OverflowUint(uint64(source.Int())) {
+			return fmt.Errorf("%v doesn't fit %v", source.Int(), value.Type())
+		}
+		value.SetUint(uint64(source.Int()))
+	case isUint(value.Kind()) && isUint(source.Kind()):
+		if value.OverflowUint(source.Uint()) {
+			return fmt.Errorf("%v doesn't fit %v", source.Uint(), value.Type())
+		}
+		value.SetUint(source.Uint())
+	case isFloat(value.Kind()) && (isInt(source.Kind()) || isUint(source.Kind()) || isFloat(source.Kind())):
+		value.Set(source.Convert(value.Type()))
+	case source.Kind() == value.Kind() && source.Type().ConvertibleTo(value.Type()):
+		value.Set(source.Convert(value.Type()))
+	default:
+		return fmt.Errorf("got %v for %v", source.Type(), value.Type())
+	}
+	return nil
+}
+
+func isInt(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Int64
+}
+
+func isUint(kind reflect.Kind) bool {
+	return kind >= reflect.Uint && kind <= reflect.Uintptr
+}
+
+func isFloat(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
@@ -0,0 +1,66 @@
+package soap
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// Nillable is the value of a nillable element which has a default.
+//
+// A nil Value is written as an empty element with xsi:nil="true", while a
+// field of type *Nillable left nil omits the element, so the default of the
+// schema applies on the server. The generated constructors set such fields to
+// the default with NewNillable.
+type Nillable[T any] struct {
+	Value *T
+}
+
+// NewNillable returns a Nillable holding the value decoded from the lexical
+// form, usually the default of the element. A lexical form which can't be
+// decoded to T leaves the Value nil, the generator rejects such defaults of
+// basic types.
+func NewNillable[T any](lexical string) *Nillable[T] {
+	value := new(T)
+	if err := xml.Unmarshal([]byte("<v>"+escapeText(lexical)+"</v>"), value); err != nil {
+		return &Nillable[T]{}
+	}
+	return &Nillable[T]{Value: value}
+}
+
+// MarshalXML writes the value, or xsi:nil="true" for a nil Value.
+func (n Nillable[T]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if n.Value == nil {
+		start.Attr = append(start.Attr,
+			xml.Attr{Name: xml.Name{Local: "xmlns:xsi"}, Value: xsiNamespace},
+			xml.Attr{Name: xml.Name{Local: "xsi:nil"}, Value: "true"})
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		return e.EncodeToken(start.End())
+	}
+	return e.EncodeElement(n.Value, start)
+}
+
+// UnmarshalXML reads the value, leaving it nil for an element with xsi:nil="true".
+func (n *Nillable[T]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Space == xsiNamespace && attr.Name.Local == "nil" {
+			if value := strings.TrimSpace(attr.Value); value == "true" || value == "1" {
+				n.Value = nil
+				return d.Skip()
+			}
+		}
+	}
+	value := new(T)
+	if err := d.DecodeElement(value, &start); err != nil {
+		return err
+	}
+	n.Value = value
+	return nil
+}
+
+func escapeText(text string) string {
+	var escaped strings.Builder
+	_ = xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
@@ -0,0 +1,18 @@
+package soap
+
+import "context"
+
+type operationKey struct{}
+
+// WithOperation returns a copy of ctx naming the operation of the call, for
+// Transports labelling their requests, see Operation.
+func WithOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// Operation returns the name of the operation ctx is called for, generated
+// clients name theirs with CallOperationContext.
+func Operation(ctx context.Context) (operation string, ok bool) {
+	operation, ok = ctx.Value(operationKey{}).(string)
+	return
+}
@@ -0,0 +1,47 @@
+package soap
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrCertificateNotPinned is returned by the TLS handshake of a pinned
+// Options when the server presents another certificate.
+var ErrCertificateNotPinned = errors.New("server certificate doesn't match the pinned fingerprint")
+
+// PinCertificate makes the client accept only the server certificate with
+// the SHA-256 fingerprint sha256hex, in hex with or without colons, instead
+// of verifying the chain and host name. This suits self-signed endpoints
+// better than InsecureSkipVerify, which accepts any certificate. A pin which
+// isn't a SHA-256 fingerprint rejects every certificate. A later call
+// replaces the pin. TlsConfig is copied before it is changed.
+func (o *Options) PinCertificate(sha256hex string) {
+	pin, err := hex.DecodeString(strings.ReplaceAll(strings.TrimSpace(sha256hex), ":", ""))
+	if err != nil || len(pin) != sha256.Size {
+		pin = nil
+	}
+
+	config := o.TlsConfig.Clone()
+	if config == nil {
+		config = &tls.Config{}
+	}
+	// the chain of a pinned certificate isn't verified, the pin is, also on
+	// resumed sessions, which skip VerifyPeerCertificate
+	config.InsecureSkipVerify = true
+	config.VerifyConnection = func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return ErrCertificateNotPinned
+		}
+		fingerprint := sha256.Sum256(state.PeerCertificates[0].Raw)
+		if pin == nil || subtle.ConstantTimeCompare(fingerprint[:], pin) != 1 {
+			return fmt.Errorf("%w: got %s", ErrCertificateNotPinned, hex.EncodeToString(fingerprint[:]))
+		}
+		return nil
+	}
+	o.TlsConfig = config
+}
@@ -0,0 +1,36 @@
+package soap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// OptionsFromPKCS12 reads a PKCS#12 bundle, a .p12 or .pfx file, and returns
+// a tls.Config for Options.TlsConfig which presents its certificate and key as
+// client certificate. The CA certificates of the bundle complete the chain of
+// the client certificate and are trusted next to the system roots.
+func OptionsFromPKCS12(path, password string) (*tls.Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, leaf, caCerts, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PKCS#12 file %s: %w", path, err)
+	}
+
+	certificate := tls.Certificate{Certificate: [][]byte{leaf.Raw}, PrivateKey: key, Leaf: leaf}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	for _, caCert := range caCerts {
+		certificate.Certificate = append(certificate.Certificate, caCert.Raw)
+		roots.AddCert(caCert)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{certificate}, RootCAs: roots}, nil
+}
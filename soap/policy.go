@@ -0,0 +1,49 @@
+package soap
+
+// SecurityPolicy lists the security a service requires by the
+// WS-SecurityPolicy assertions attached to its binding, which generated
+// services declare as <PortType>SecurityPolicy. It is informational, the
+// Client doesn't enforce it.
+type SecurityPolicy struct {
+	// TransportBinding requires the transport to secure the messages, HTTPS
+	// if HttpsToken, with a client certificate if RequireClientCertificate.
+	TransportBinding         bool
+	HttpsToken               bool
+	RequireClientCertificate bool
+
+	// SymmetricBinding and AsymmetricBinding require the messages to be
+	// signed or encrypted with WS-Security, by a shared or a public key.
+	SymmetricBinding  bool
+	AsymmetricBinding bool
+
+	// Tokens lists the security tokens the messages carry by the local names
+	// of their assertions, like UsernameToken, X509Token or SamlToken.
+	// HashPassword requires a UsernameToken with the password digest.
+	Tokens       []string
+	HashPassword bool
+
+	// IncludeTimestamp requires a Timestamp in the Security header, Signed
+	// and Encrypted require signing and encrypting parts of the messages.
+	IncludeTimestamp bool
+	Signed           bool
+	Encrypted        bool
+}
+
+// RequiresToken reports whether the policy requires the token, the local
+// name of its assertion.
+func (p SecurityPolicy) RequiresToken(token string) bool {
+	for _, required := range p.Tokens {
+		if required == token {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSecurityHeader sends header in the SOAP Header of every request,
+// replacing a previous Security header. The mustUnderstand attribute is
+// written for the Version of the Client.
+func (s *Client) SetSecurityHeader(header *WSSSecurityHeader) error {
+	header.Version = s.opts.Version
+	return s.SetHeader(itemName(header), header)
+}
@@ -0,0 +1,60 @@
+package soap
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Poller repeats a poll of an asynchronous operation with a growing interval
+// until it reports a terminal status, the generated AndWait functions use it
+// to wait for the result of a submitted request.
+type Poller struct {
+	// Interval is the wait before the first poll, one second if zero.
+	Interval time.Duration
+	// Multiplier grows the interval after each poll, it stays constant if
+	// Multiplier is below 1.
+	Multiplier float64
+	// MaxInterval limits the interval grown by Multiplier, unlimited if zero.
+	MaxInterval time.Duration
+	// MaxPolls limits the number of polls, ErrPollLimit is returned when it
+	// is reached, unlimited if zero. The ctx deadline limits the total wait.
+	MaxPolls int
+}
+
+// ErrPollLimit is returned by Poll when MaxPolls polls reported no terminal status.
+var ErrPollLimit = errors.New("poll limit reached without a terminal status")
+
+// Poll waits the interval and calls poll until it returns done or an error,
+// which is returned. It returns the ctx error when ctx ends while waiting.
+func (p Poller) Poll(ctx context.Context, poll func(ctx context.Context) (done bool, err error)) error {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for polls := 1; ; polls++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		done, err := poll(ctx)
+		if err != nil || done {
+			return err
+		}
+		if p.MaxPolls > 0 && polls >= p.MaxPolls {
+			return ErrPollLimit
+		}
+
+		if p.Multiplier > 1 {
+			interval = time.Duration(float64(interval) * p.Multiplier)
+		}
+		if p.MaxInterval > 0 && interval > p.MaxInterval {
+			interval = p.MaxInterval
+		}
+		timer.Reset(interval)
+	}
+}
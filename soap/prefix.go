@@ -0,0 +1,213 @@
+package soap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"sort"
+)
+
+// PrefixedContent marshals Content with the namespace of its root element
+// bound to Prefix, as in <tns:GetInfo xmlns:tns="...">, instead of declaring
+// it as the default namespace. Children in the same namespace carry the prefix
+// too, for servers which don't accept default namespace declarations.
+type PrefixedContent struct {
+	Prefix  string
+	Content interface{}
+}
+
+// NewPrefixedContent wraps content in a PrefixedContent, content is returned as is if prefix is empty.
+func NewPrefixedContent(prefix string, content interface{}) interface{} {
+	if prefix == "" {
+		return content
+	}
+	return &PrefixedContent{Prefix: prefix, Content: content}
+}
+
+// MarshalXML writes the Content with the root namespace prefixed.
+func (p PrefixedContent) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return encodePrefixed(e, p.Content, func(root string) map[string]string {
+		return map[string]string{root: p.Prefix}
+	})
+}
+
+// StrictPrefixedContent marshals Content with every namespace of Prefixes
+// bound to its prefix, for servers which match elements by prefix instead of
+// by namespace. The prefixes are declared once on the root element and its
+// elements and attributes of these namespaces are qualified with them,
+// namespaces without a prefix are declared as default namespace as usual.
+// The Client sends the content with the prefixes registered by
+// Client.RegisterNamespacePrefix if Prefixes is nil.
+type StrictPrefixedContent struct {
+	Content interface{}
+	// Prefixes maps namespaces to their prefixes.
+	Prefixes map[string]string
+}
+
+// NewStrictPrefixedContent wraps content in a StrictPrefixedContent with the
+// prefixes of the Client sending it.
+func NewStrictPrefixedContent(content interface{}) *StrictPrefixedContent {
+	return &StrictPrefixedContent{Content: content}
+}
+
+// MarshalXML writes the Content with the Prefixes.
+func (p StrictPrefixedContent) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return encodePrefixed(e, p.Content, func(string) map[string]string {
+		return p.Prefixes
+	})
+}
+
+// encodePrefixed marshals content and writes its tokens to e with the
+// namespaces of the prefixes map, which is built from the namespace of the
+// root element, qualified by prefix instead of declared as default namespace.
+// The prefixes of the namespaces content uses are declared on the root.
+func encodePrefixed(e *xml.Encoder, content interface{}, prefixes func(root string) map[string]string) error {
+	data, err := xml.Marshal(content)
+	if err != nil {
+		return err
+	}
+	tokens, err := decodeTokens(data)
+	if err != nil {
+		return err
+	}
+
+	var root string
+	used := map[string]bool{}
+	for _, tok := range tokens {
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if len(used) == 0 {
+			root = el.Name.Space
+		}
+		used[el.Name.Space] = true
+		for _, attr := range el.Attr {
+			if attr.Name.Space != "xmlns" && attr.Name.Space != "" {
+				used[attr.Name.Space] = true
+			}
+		}
+	}
+
+	var declarations []xml.Attr
+	prefixByNamespace := map[string]string{}
+	for namespace, prefix := range prefixes(root) {
+		if namespace != "" && prefix != "" && used[namespace] {
+			prefixByNamespace[namespace] = prefix
+			declarations = append(declarations, xml.Attr{Name: xml.Name{Local: "xmlns:" + prefix}, Value: namespace})
+		}
+	}
+	sort.Slice(declarations, func(i, j int) bool { return declarations[i].Name.Local < declarations[j].Name.Local })
+
+	qualify := func(name xml.Name) xml.Name {
+		if prefix, ok := prefixByNamespace[name.Space]; ok {
+			return xml.Name{Local: prefix + ":" + name.Local}
+		}
+		return name
+	}
+
+	first := true
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch el := tok.(type) {
+		case xml.StartElement:
+			var attrs []xml.Attr
+			if first {
+				attrs = append(attrs, declarations...)
+				first = false
+			}
+			for _, attr := range el.Attr {
+				switch {
+				case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+					// The default namespace is expressed by the element names.
+					continue
+				case attr.Name.Space == "xmlns":
+					if _, ok := prefixByNamespace[attr.Value]; ok {
+						// Declared on the root already.
+						continue
+					}
+					attr.Name = xml.Name{Local: "xmlns:" + attr.Name.Local}
+				default:
+					attr.Name = qualify(attr.Name)
+				}
+				attrs = append(attrs, attr)
+			}
+			el.Name = qualify(el.Name)
+			el.Attr = attrs
+			if inner, end, ok := rawText(tokens, i+1); ok {
+				// the encoder escapes character data, CDATA sections are
+				// written as they are
+				if err = e.EncodeElement(struct {
+					Inner string `xml:",innerxml"`
+				}{inner}, el); err != nil {
+					return err
+				}
+				i = end
+				continue
+			}
+			tok = el
+		case xml.EndElement:
+			el.Name = qualify(el.Name)
+			tok = el
+		case cdataText:
+			tok = el.text
+		}
+		if err = e.EncodeToken(tok); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cdataText is character data of a document with CDATA sections, raw as
+// written.
+type cdataText struct {
+	text xml.CharData
+	raw  []byte
+}
+
+// rawText returns the content of the element whose content tokens start at
+// tokens[i], as written, and the index of its end, if the element contains
+// only character data with CDATA sections.
+func rawText(tokens []xml.Token, i int) (inner string, end int, ok bool) {
+	var content bytes.Buffer
+	for end = i; end < len(tokens); end++ {
+		switch tok := tokens[end].(type) {
+		case xml.CharData:
+			if err := xml.EscapeText(&content, tok); err != nil {
+				return "", 0, false
+			}
+		case cdataText:
+			content.Write(tok.raw)
+			ok = true
+		case xml.EndElement:
+			return content.String(), end, ok
+		default:
+			return "", 0, false
+		}
+	}
+	return "", 0, false
+}
+
+// decodeTokens returns the tokens of the XML document data, character data
+// with CDATA sections as cdataText.
+func decodeTokens(data []byte) (ret []xml.Token, err error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		offset := d.InputOffset()
+		var tok xml.Token
+		if tok, err = d.Token(); err == io.EOF {
+			return ret, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		tok = xml.CopyToken(tok)
+		if text, ok := tok.(xml.CharData); ok {
+			if raw := data[offset:d.InputOffset()]; bytes.Contains(raw, []byte("<![CDATA[")) {
+				tok = cdataText{text: text, raw: raw}
+			}
+		}
+		ret = append(ret, tok)
+	}
+}
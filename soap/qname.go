@@ -0,0 +1,207 @@
+package soap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"sync"
+)
+
+// qnamePrefix is the prefix declared on an element carrying a namespace qualified QName value.
+const qnamePrefix = "qn"
+
+// QName is a namespace qualified XML name as defined by xsd:QName.
+//
+// On the wire a QName is written as "prefix:local", where the prefix is only
+// meaningful together with the namespace declarations in scope. Values decoded
+// through the Client resolve the prefix against all declarations of the
+// document, including those made on ancestor elements.
+type QName xml.Name
+
+// String returns the QName in the "{space}local" notation.
+func (q QName) String() string {
+	if q.Space == "" {
+		return q.Local
+	}
+	return "{" + q.Space + "}" + q.Local
+}
+
+// MarshalXML writes the QName as "prefix:local" and declares the prefix on the element itself.
+func (q QName) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	value := q.Local
+	if q.Space != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns:" + qnamePrefix}, Value: q.Space})
+		value = qnamePrefix + ":" + q.Local
+	}
+	return e.EncodeElement(value, start)
+}
+
+// UnmarshalXML reads a "prefix:local" value and resolves the prefix to its namespace.
+func (q *QName) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var value string
+	if err := d.DecodeElement(&value, &start); err != nil {
+		return err
+	}
+
+	prefix, local := splitQName(value)
+	q.Local = local
+	q.Space = ""
+	for _, attr := range start.Attr {
+		if (prefix == "" && attr.Name.Space == "" && attr.Name.Local == "xmlns") ||
+			(prefix != "" && attr.Name.Space == "xmlns" && attr.Name.Local == prefix) {
+			q.Space = attr.Value
+			return nil
+		}
+	}
+	if tracker, ok := namespaceTrackers.Load(d); ok {
+		q.Space, _ = tracker.(*namespaceTracker).lookup(prefix)
+	}
+	return nil
+}
+
+// MarshalXMLAttr writes the local part only, attributes can't declare the namespace of their value.
+func (q QName) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: name, Value: q.Local}, nil
+}
+
+// UnmarshalXMLAttr reads the local part of the attribute value, the prefix can't be resolved for attributes.
+func (q *QName) UnmarshalXMLAttr(attr xml.Attr) error {
+	_, q.Local = splitQName(attr.Value)
+	q.Space = ""
+	return nil
+}
+
+func splitQName(value string) (prefix string, local string) {
+	value = strings.TrimSpace(value)
+	if i := strings.Index(value, ":"); i >= 0 {
+		return value[:i], value[i+1:]
+	}
+	return "", value
+}
+
+// namespaceTrackers maps the decoders created by newNamespaceDecoder to their tracker while decoding.
+var namespaceTrackers sync.Map
+
+// namespaceTracker feeds raw tokens to an xml.Decoder while recording the
+// namespace declarations in scope, which the decoder keeps to itself. It also
+// keeps the input of the elements captured for their inner XML, as the
+// decoder can't serve innerxml fields from tokens, the rest of the input is
+// dropped once read.
+type namespaceTracker struct {
+	raw   *xml.Decoder
+	input *bytes.Buffer
+	// base is the input offset of the first byte kept in input.
+	base   int64
+	scopes []map[string]string
+	// contents are the input offsets where the content of each open element starts.
+	contents []int64
+	// captures are the depths of the open elements whose inner XML is kept.
+	captures []int
+	// inner is the content of the element closed last.
+	inner []byte
+	// attachments are the MIME parts by Content-ID, which cid: references
+	// of the document are replaced with.
+	attachments map[string][]byte
+	// pending are tokens to return before reading on.
+	pending []xml.Token
+}
+
+func (t *namespaceTracker) Token() (xml.Token, error) {
+	if len(t.pending) > 0 {
+		tok := t.pending[0]
+		t.pending = t.pending[1:]
+		return tok, nil
+	}
+	offset := t.raw.InputOffset()
+	t.discard(offset)
+	tok, err := t.raw.RawToken()
+	if err != nil {
+		return tok, err
+	}
+
+	switch el := tok.(type) {
+	case xml.StartElement:
+		scope := map[string]string{}
+		for _, attr := range el.Attr {
+			if attr.Name.Space == "xmlns" {
+				scope[attr.Name.Local] = attr.Value
+			} else if attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+				scope[""] = attr.Value
+			}
+		}
+		t.scopes = append(t.scopes, scope)
+		t.contents = append(t.contents, t.raw.InputOffset())
+		if content, include, ok := t.attachment(el); ok {
+			// an xop:Include stands for the content of its parent, which
+			// skips the element itself
+			if include {
+				t.pending = append(t.pending, xml.CopyToken(el))
+				return xml.CharData(content), nil
+			}
+			t.pending = append(t.pending, xml.CharData(content))
+		}
+	case xml.EndElement:
+		if len(t.scopes) > 0 {
+			t.scopes = t.scopes[:len(t.scopes)-1]
+		}
+		if n := len(t.contents); n > 0 {
+			t.inner = nil
+			if t.contents[n-1] >= t.base {
+				t.inner = t.input.Bytes()[t.contents[n-1]-t.base : offset-t.base]
+			}
+			t.contents = t.contents[:n-1]
+		}
+		for n := len(t.captures); n > 0 && t.captures[n-1] > len(t.contents); n-- {
+			t.captures = t.captures[:n-1]
+		}
+	}
+	return xml.CopyToken(tok), nil
+}
+
+// capture keeps the input of the element opened last until it is closed,
+// for its inner XML.
+func (t *namespaceTracker) capture() {
+	t.captures = append(t.captures, len(t.contents))
+}
+
+// discard drops the input before offset which no captured element needs.
+func (t *namespaceTracker) discard(offset int64) {
+	if len(t.captures) > 0 {
+		offset = t.contents[t.captures[0]-1]
+	}
+	if offset > t.base {
+		t.input.Next(int(offset - t.base))
+		t.base = offset
+	}
+}
+
+func (t *namespaceTracker) lookup(prefix string) (string, bool) {
+	for i := len(t.scopes) - 1; i >= 0; i-- {
+		if namespace, ok := t.scopes[i][prefix]; ok {
+			return namespace, true
+		}
+	}
+	return "", false
+}
+
+// namespaceDecoder is a SOAPDecoder which lets QName values resolve their prefixes.
+type namespaceDecoder struct {
+	decoder *xml.Decoder
+	tracker *namespaceTracker
+}
+
+func newNamespaceDecoder(r io.Reader) *namespaceDecoder {
+	input := new(bytes.Buffer)
+	tracker := &namespaceTracker{raw: xml.NewDecoder(io.TeeReader(r, input)), input: input}
+	return &namespaceDecoder{
+		decoder: xml.NewTokenDecoder(tracker),
+		tracker: tracker,
+	}
+}
+
+func (d *namespaceDecoder) Decode(v interface{}) error {
+	namespaceTrackers.Store(d.decoder, d.tracker)
+	defer namespaceTrackers.Delete(d.decoder)
+	return d.decoder.Decode(v)
+}
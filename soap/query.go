@@ -0,0 +1,42 @@
+package soap
+
+import (
+	"context"
+	"net/url"
+)
+
+type queryParamsKey struct{}
+
+// WithQueryParams returns a copy of ctx carrying query parameters, which
+// calls made with it add to the request URL over Options.QueryParams.
+func WithQueryParams(ctx context.Context, params url.Values) context.Context {
+	return context.WithValue(ctx, queryParamsKey{}, params)
+}
+
+// QueryParams returns the query parameters of ctx, if it carries any.
+func QueryParams(ctx context.Context) (params url.Values, ok bool) {
+	params, ok = ctx.Value(queryParamsKey{}).(url.Values)
+	return
+}
+
+// BuildURL adds the params to the query of endpoint. Each set of params
+// replaces the values of its keys in the endpoint and the previous sets.
+func BuildURL(endpoint string, params ...url.Values) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	merged := false
+	for _, values := range params {
+		for key, value := range values {
+			query[key] = append([]string(nil), value...)
+			merged = true
+		}
+	}
+	if merged {
+		u.RawQuery = query.Encode()
+	}
+	return u.String(), nil
+}
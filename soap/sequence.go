@@ -0,0 +1,54 @@
+package soap
+
+import (
+	"encoding/xml"
+	"reflect"
+)
+
+// MarshalSequence encodes the items of a repeating sequence which v is or
+// points to, each like MarshalChoice, without elements of the items
+// themselves.
+func MarshalSequence(e *xml.Encoder, v interface{}) error {
+	items := reflect.Indirect(reflect.ValueOf(v))
+	for i := 0; i < items.Len(); i++ {
+		if err := MarshalChoice(e, items.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalSequence decodes the element start to the items of the repeating
+// sequence v points to. The element continues the last item, unless its field
+// precedes the last set field of the item, or is that field and takes a
+// single element, which begins the next occurrence. Elements of no field are
+// skipped.
+func UnmarshalSequence(d *xml.Decoder, start xml.StartElement, v interface{}) error {
+	items := reflect.ValueOf(v).Elem()
+	itemType := items.Type().Elem()
+	field := elementField(itemType, start.Name.Local)
+	if field < 0 {
+		return d.Skip()
+	}
+	if n := items.Len(); n == 0 || beginsOccurrence(items.Index(n-1), field) {
+		items.Set(reflect.Append(items, reflect.Zero(itemType)))
+	}
+	item := items.Index(items.Len() - 1)
+	return d.DecodeElement(item.Field(field).Addr().Interface(), &start)
+}
+
+// beginsOccurrence reports whether an element of the field at index field
+// begins a new occurrence after item.
+func beginsOccurrence(item reflect.Value, field int) bool {
+	last := -1
+	for i := 0; i < item.NumField(); i++ {
+		if !item.Field(i).IsZero() {
+			last = i
+		}
+	}
+	if field != last {
+		return field < last
+	}
+	t := item.Field(field).Type()
+	return t.Kind() != reflect.Slice || t.Elem().Kind() == reflect.Uint8
+}
@@ -1,16 +1,25 @@
 package soap
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/tls"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net"
 	"net/http"
 	"net/http/cookiejar"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
 )
 
 type SOAPEncoder interface {
@@ -22,8 +31,9 @@ type SOAPDecoder interface {
 	Decode(v interface{}) error
 }
 
+// EnvelopeResponse is a SOAP 1.1 or SOAP 1.2 response envelope.
 type EnvelopeResponse struct {
-	XMLName     xml.Name `xml:"http://schemas.xmlsoap.org/soap/envelope/ Envelope"`
+	XMLName     xml.Name `xml:"Envelope"`
 	Header      *HeaderResponse
 	Body        BodyResponse
 	Attachments []MIMEMultipartAttachment `xml:"attachments,omitempty"`
@@ -33,10 +43,47 @@ type Envelope struct {
 	XMLName xml.Name `xml:"soap:Envelope"`
 	XmlNS   string   `xml:"xmlns:soap,attr"`
 
+	// ExtraNamespaces are declared as xmlns:prefix attributes on the Envelope
+	// element, for servers that expect all namespaces declared on the root.
+	ExtraNamespaces map[string]string `xml:"-"`
+
 	Header *Header
 	Body   Body
 }
 
+// MarshalXML writes the Envelope with the soap namespace and the ExtraNamespaces declared on the root element.
+// A Header without items is left out, as some servers reject an empty one.
+func (o Envelope) MarshalXML(e *xml.Encoder, _ xml.StartElement) (err error) {
+	start := xml.StartElement{
+		Name: xml.Name{Local: "soap:Envelope"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns:soap"}, Value: o.XmlNS}},
+	}
+
+	prefixes := make([]string, 0, len(o.ExtraNamespaces))
+	for prefix := range o.ExtraNamespaces {
+		if prefix != "soap" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns:" + prefix}, Value: o.ExtraNamespaces[prefix]})
+	}
+
+	if err = e.EncodeToken(start); err != nil {
+		return
+	}
+	if !o.Header.empty() {
+		if err = e.Encode(o.Header); err != nil {
+			return
+		}
+	}
+	if err = e.Encode(o.Body); err != nil {
+		return
+	}
+	return e.EncodeToken(start.End())
+}
+
 type Body struct {
 	XMLName xml.Name `xml:"soap:Body"`
 
@@ -59,11 +106,22 @@ type BodyResponse struct {
 	// fault is initialized to non-nil with user-provided detail type.
 	faultOccurred bool
 	Fault         *Fault `xml:",omitempty"`
+
+	// lenientNamespace decodes the Content element whatever its namespace.
+	lenientNamespace bool
+	// partialResults decodes the Content element next to a Fault.
+	partialResults bool
 }
 
 type MIMEMultipartAttachment struct {
 	Name string
 	Data []byte
+	// ContentID is sent in angle brackets as Content-ID of the part, which
+	// the XML references the attachment by, the Name if empty.
+	ContentID string
+	// ContentType is sent as Content-Type of the part, if empty
+	// application/octet-stream with the Name.
+	ContentType string
 }
 
 // UnmarshalXML unmarshals SOAPBody xml
@@ -90,19 +148,29 @@ Loop:
 
 		switch se := token.(type) {
 		case xml.StartElement:
-			if consumed {
+			fault := (se.Name.Space == XmlNsSoapEnv || se.Name.Space == XmlNsSoap12Env) && se.Name.Local == "Fault"
+			if b.faultOccurred && (fault || !b.partialResults) || consumed && (!fault || !b.partialResults) {
 				return xml.UnmarshalError("Found multiple elements inside SOAP body; not wrapped-document/literal WS-I compliant")
-			} else if se.Name.Space == "http://schemas.xmlsoap.org/soap/envelope/" && se.Name.Local == "Fault" {
-				b.Content = nil
+			} else if fault {
+				if !b.partialResults {
+					b.Content = nil
+				}
 
 				b.faultOccurred = true
-				err = d.DecodeElement(b.Fault, &se)
+				if se.Name.Space == XmlNsSoap12Env {
+					err = b.Fault.decodeSOAP12(d, se)
+				} else {
+					err = d.DecodeElement(b.Fault, &se)
+				}
 				if err != nil {
 					return err
 				}
-
-				consumed = true
 			} else {
+				if b.lenientNamespace {
+					if name, ok := xmlNameTag(b.Content); ok && name.Local == se.Name.Local {
+						se.Name.Space = name.Space
+					}
+				}
 				if err = d.DecodeElement(b.Content, &se); err != nil {
 					return err
 				}
@@ -117,6 +185,31 @@ Loop:
 	return nil
 }
 
+// xmlNameTag returns the element name in the XMLName field tag of the struct v points to.
+func xmlNameTag(v interface{}) (name xml.Name, ok bool) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return
+	}
+	var field reflect.StructField
+	if field, ok = t.FieldByName("XMLName"); !ok || field.Type != reflect.TypeOf(xml.Name{}) {
+		return name, false
+	}
+	tag := strings.Split(field.Tag.Get("xml"), ",")[0]
+	if tag == "" {
+		return name, false
+	}
+	if i := strings.LastIndex(tag, " "); i >= 0 {
+		name.Space, name.Local = tag[:i], tag[i+1:]
+	} else {
+		name.Local = tag
+	}
+	return name, true
+}
+
 func (b *Body) ErrorFromFault() error {
 	if b.faultOccurred {
 		return b.Fault
@@ -156,6 +249,34 @@ type Fault struct {
 	Detail FaultError `xml:"detail,omitempty"`
 }
 
+// soap12Fault is the Fault of a SOAP 1.2 envelope.
+type soap12Fault struct {
+	Code struct {
+		Value string `xml:"Value"`
+	} `xml:"Code"`
+	Reason struct {
+		Text []string `xml:"Text"`
+	} `xml:"Reason"`
+	Role   string     `xml:"Role"`
+	Detail FaultError `xml:"Detail"`
+}
+
+// decodeSOAP12 decodes a SOAP 1.2 Fault to f, the code is read from the
+// Code Value, the string from the first Reason Text and the actor from the
+// Role.
+func (f *Fault) decodeSOAP12(d *xml.Decoder, start xml.StartElement) error {
+	fault := soap12Fault{Detail: f.Detail}
+	if err := d.DecodeElement(&fault, &start); err != nil {
+		return err
+	}
+	f.Code = strings.TrimSpace(fault.Code.Value)
+	if len(fault.Reason.Text) > 0 {
+		f.String = fault.Reason.Text[0]
+	}
+	f.Actor = fault.Role
+	return nil
+}
+
 func (f *Fault) Error() string {
 	if f.Detail != nil && f.Detail.HasData() {
 		return f.Detail.ErrorString()
@@ -169,12 +290,32 @@ type HTTPError struct {
 	StatusCode int
 	//ResponseBody contains the body returned in the HTTP response
 	ResponseBody []byte
+	//ContentType is the Content-Type of the HTTP response
+	ContentType string
 }
 
 func (e *HTTPError) Error() string {
 	return fmt.Sprintf("HTTP Status %d: %s", e.StatusCode, string(e.ResponseBody))
 }
 
+// mayCarryFault reports whether the response may be a SOAP envelope with a
+// fault: SOAP 1.1 servers send faults with status 500, SOAP 1.2 servers with
+// the status of the fault code and an XML content type.
+func (e *HTTPError) mayCarryFault() bool {
+	if e.StatusCode == http.StatusInternalServerError {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(e.ContentType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case "text/xml", "application/xml", "application/soap+xml", "multipart/related":
+		return true
+	}
+	return false
+}
+
 const (
 	// Predefined WSS namespaces to be used in
 	WssNsWSSE       string = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd"
@@ -182,17 +323,92 @@ const (
 	WssNsType       string = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordText"
 	mtomContentType string = `multipart/related; start-info="application/soap+xml"; type="application/xop+xml"; boundary="%s"`
 	XmlNsSoapEnv    string = "http://schemas.xmlsoap.org/soap/envelope/"
+	XmlNsSoap12Env  string = "http://www.w3.org/2003/05/soap-envelope"
 )
 
+// SOAPVersion is the version of the SOAP envelopes a Client sends or a header
+// is written for.
+type SOAPVersion int
+
+const (
+	SOAP11 SOAPVersion = iota
+	SOAP12
+)
+
+// envelopePrefix returns the prefix and namespace of the envelope of the version.
+func (v SOAPVersion) envelopePrefix() (prefix, namespace string) {
+	if v == SOAP12 {
+		return "env", XmlNsSoap12Env
+	}
+	return "soap", XmlNsSoapEnv
+}
+
 type WSSSecurityHeader struct {
 	XMLName   xml.Name `xml:"http://schemas.xmlsoap.org/soap/envelope/ wsse:Security"`
 	XmlNSWsse string   `xml:"xmlns:wsse,attr"`
 
-	MustUnderstand string `xml:"mustUnderstand,attr,omitempty"`
+	// MustUnderstand is written as the mustUnderstand attribute of the
+	// envelope namespace of Version, as 1 or 0 for SOAP 1.1 and as true or
+	// false for SOAP 1.2.
+	MustUnderstand string `xml:"-"`
+	// Actor is written as the actor attribute for SOAP 1.1 and as the role
+	// attribute for SOAP 1.2.
+	Actor string `xml:"-"`
+	// Version is the SOAP version of the envelope, SOAP 1.1 unless set.
+	Version SOAPVersion `xml:"-"`
 
 	Token *WSSUsernameToken `xml:",omitempty"`
 }
 
+// MarshalXML writes the header with the mustUnderstand and actor or role
+// attributes of its SOAP version.
+func (h WSSSecurityHeader) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type header WSSSecurityHeader
+	prefix, namespace := h.Version.envelopePrefix()
+	start.Name = h.XMLName
+	if start.Name.Local == "" {
+		start.Name = xml.Name{Space: namespace, Local: "wsse:Security"}
+	}
+	var attrs []xml.Attr
+	if h.MustUnderstand != "" {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: prefix + ":mustUnderstand"}, Value: h.mustUnderstand()})
+	}
+	if h.Actor != "" {
+		actor := "actor"
+		if h.Version == SOAP12 {
+			actor = "role"
+		}
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: prefix + ":" + actor}, Value: h.Actor})
+	}
+	if len(attrs) > 0 {
+		// the prefix is declared on the header, which can be marshaled on its own
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns:" + prefix}, Value: namespace})
+		start.Attr = append(start.Attr, attrs...)
+	}
+	return e.EncodeElement(header(h), start)
+}
+
+// mustUnderstand returns MustUnderstand in the lexical form of the version.
+func (h WSSSecurityHeader) mustUnderstand() string {
+	value := strings.TrimSpace(h.MustUnderstand)
+	if h.Version == SOAP12 {
+		switch value {
+		case "1":
+			return "true"
+		case "0":
+			return "false"
+		}
+		return value
+	}
+	switch value {
+	case "true":
+		return "1"
+	case "false":
+		return "0"
+	}
+	return value
+}
+
 type WSSUsernameToken struct {
 	XMLName   xml.Name `xml:"wsse:UsernameToken"`
 	XmlNSWsu  string   `xml:"xmlns:wsu,attr"`
@@ -239,12 +455,68 @@ type Options struct {
 	Timeout             time.Duration
 	ConnectionTimeout   time.Duration
 	TlsHandShakeTimeout time.Duration
-	Client              HTTPClient
-	HttpHeaders         map[string]string
-	Mtom                bool
-	Mma                 bool
-	UserAgent           string
-	Debug               bool
+	// ExpectContinueTimeout makes requests send "Expect: 100-continue" and
+	// wait up to this long for the server to accept before writing the body,
+	// zero sends the body right away.
+	ExpectContinueTimeout time.Duration
+	// Client sends the requests of the default Transport. A custom Client is
+	// used as is, the TLS, dial and timeout settings above only configure
+	// the client built by BuildHttpClient when Client is nil. Set
+	// EnforceTimeout to bound the requests of a custom Client as well.
+	Client HTTPClient
+	// EnforceTimeout bounds each request by ConnectionTimeout through its
+	// context, whichever Client sends it.
+	EnforceTimeout bool
+	HttpHeaders    map[string]string
+	Mtom           bool
+	Mma            bool
+	// MmaBoundary separates the parts of MMA requests, a random one is
+	// generated if empty.
+	MmaBoundary string
+	UserAgent   string
+	// Accept is sent as Accept header unless empty. The default accepts the
+	// media type of the envelopes of Version first, text/xml for SOAP 1.1
+	// and only application/soap+xml for SOAP 1.2.
+	Accept string
+	Debug  bool
+	// ExtraNamespaces maps prefixes to namespace URIs declared on the Envelope.
+	ExtraNamespaces map[string]string
+	// NamespacePrefixes maps namespace URIs to the prefixes requests wrapped
+	// by NewStrictPrefixedContent bind them to, see RegisterNamespacePrefix.
+	NamespacePrefixes map[string]string
+	// Validate checks requests before sending and responses after decoding
+	// with their Validator implementation, if any.
+	Validate bool
+	// Transport sends the requests, HTTP POST to the Client url if nil.
+	Transport Transport
+	// QueryParams are added to the query of the request URL, parameters
+	// of the call context take precedence, see WithQueryParams.
+	QueryParams url.Values
+	// LenientResponseNamespace decodes the response element by its local
+	// name, for servers answering in another namespace than the response
+	// type declares.
+	LenientResponseNamespace bool
+	// PartialResults decodes the response element of a body which carries
+	// it next to a Fault, for servers returning the partial results of batch
+	// operations along with the fault. The call still returns the fault.
+	PartialResults bool
+	// AllowEmptyResponse accepts a response without body, which some servers
+	// send for operations declaring an output, leaving the response content
+	// as it is instead of failing to decode the envelope.
+	AllowEmptyResponse bool
+	// IdempotencyKeyHeader names the HTTP header, like Idempotency-Key,
+	// carrying a key the server can detect repeated calls by. The key is
+	// generated per call unless the context carries one, see
+	// WithIdempotencyKey.
+	IdempotencyKeyHeader string
+	// CorrelationHeader is added to the Client Headers of calls whose
+	// context carries a correlation ID, see WithCorrelationID.
+	CorrelationHeader *CorrelationHeader
+	// Version is the SOAP version of the request envelopes, SOAP11 if zero.
+	// SOAP 1.2 requests are sent as application/soap+xml with the action as
+	// action parameter of the Content-Type instead of a SOAPAction header,
+	// they can't carry MMA attachments.
+	Version SOAPVersion
 }
 
 var defaultOptions = Options{
@@ -252,8 +524,13 @@ var defaultOptions = Options{
 	ConnectionTimeout:   90 * time.Second,
 	TlsHandShakeTimeout: 15 * time.Second,
 	UserAgent:           "gowsdl/0.1",
+	Accept:              defaultAccept,
 }
 
+// defaultAccept is the Accept of the default options, requestHeaders sends
+// the media types of the SOAP version instead.
+const defaultAccept = "text/xml, application/soap+xml"
+
 func DefaultOptions() Options {
 	return defaultOptions
 }
@@ -265,7 +542,8 @@ func (o *Options) BuildHttpClient() (ret *http.Client, err error) {
 			d := net.Dialer{Timeout: o.Timeout}
 			return d.DialContext(ctx, network, addr)
 		},
-		TLSHandshakeTimeout: o.TlsHandShakeTimeout,
+		TLSHandshakeTimeout:   o.TlsHandShakeTimeout,
+		ExpectContinueTimeout: o.ExpectContinueTimeout,
 	}
 	var jar *cookiejar.Jar
 	if jar, err = cookiejar.New(nil); err != nil {
@@ -275,6 +553,8 @@ func (o *Options) BuildHttpClient() (ret *http.Client, err error) {
 	return
 }
 
+// getOrBuildHttpClient returns the custom Client if set, it takes precedence
+// over the settings BuildHttpClient applies.
 func (o *Options) getOrBuildHttpClient() (ret HTTPClient, err error) {
 	if o.Client == nil {
 		o.Client, err = o.BuildHttpClient()
@@ -289,6 +569,9 @@ type Client struct {
 	url         string
 	opts        *Options
 	attachments []MIMEMultipartAttachment
+
+	headersMu      sync.RWMutex
+	defaultHeaders map[string]string
 }
 
 // HTTPClient is a Client which can make HTTP requests
@@ -309,6 +592,65 @@ func NewClient(url string, opts *Options) *Client {
 	}
 }
 
+// AddNamespace declares an additional namespace prefix on the Envelope of every request.
+func (s *Client) AddNamespace(prefix string, namespace string) {
+	if s.opts.ExtraNamespaces == nil {
+		s.opts.ExtraNamespaces = map[string]string{}
+	}
+	s.opts.ExtraNamespaces[prefix] = namespace
+}
+
+// RegisterNamespacePrefix binds the namespace to prefix in the requests
+// wrapped by NewStrictPrefixedContent. Clients generated with strict prefixes
+// register the prefixes of their namespaces when they are created. It fails
+// if the namespace is bound to another prefix or the prefix to another
+// namespace already, the prefixes of the requests would depend on the order
+// of the registrations otherwise.
+func (s *Client) RegisterNamespacePrefix(namespace string, prefix string) error {
+	for registered, registeredPrefix := range s.opts.NamespacePrefixes {
+		if registered == namespace && registeredPrefix != prefix {
+			return fmt.Errorf("namespace %s is bound to prefix %s already, not to %s", namespace, registeredPrefix, prefix)
+		}
+		if registered != namespace && registeredPrefix == prefix {
+			return fmt.Errorf("prefix %s is bound to namespace %s already, not to %s", prefix, registered, namespace)
+		}
+	}
+	if s.opts.NamespacePrefixes == nil {
+		s.opts.NamespacePrefixes = map[string]string{}
+	}
+	s.opts.NamespacePrefixes[namespace] = prefix
+	return nil
+}
+
+// SetHeader sends header in the SOAP Header of every request, replacing the
+// Headers of the element name, a nil header removes them. Set the headers
+// before making requests, Headers isn't guarded against concurrent calls.
+func (s *Client) SetHeader(name xml.Name, header interface{}) error {
+	if s.Headers == nil {
+		s.Headers = &XmlContent{}
+	}
+	return s.Headers.SetItem(name, header)
+}
+
+// SetDefaultHeader sets an HTTP header sent with every request, over
+// Options.HttpHeaders. Headers passed to a call still take precedence. It is
+// safe to call while requests are in flight, e.g. to rotate an API key.
+func (s *Client) SetDefaultHeader(key, value string) {
+	s.headersMu.Lock()
+	defer s.headersMu.Unlock()
+	if s.defaultHeaders == nil {
+		s.defaultHeaders = map[string]string{}
+	}
+	s.defaultHeaders[http.CanonicalHeaderKey(key)] = value
+}
+
+// RemoveDefaultHeader removes a header set with SetDefaultHeader.
+func (s *Client) RemoveDefaultHeader(key string) {
+	s.headersMu.Lock()
+	defer s.headersMu.Unlock()
+	delete(s.defaultHeaders, http.CanonicalHeaderKey(key))
+}
+
 // AddMIMEMultipartAttachment adds an attachment to the Client that will be sent only if the
 // WithMIMEMultipartAttachments option is used
 func (s *Client) AddMIMEMultipartAttachment(attachment MIMEMultipartAttachment) {
@@ -321,8 +663,15 @@ func (s *Client) CallContext(ctx context.Context, soapAction string, request int
 	return s.call(ctx, soapAction, request, responseHeader, responseContent, nil, nil, headers)
 }
 
+// CallOperationContext is CallContext for the named operation, the context
+// passed to the Transport carries the name, see Operation.
+func (s *Client) CallOperationContext(ctx context.Context, operation, soapAction string, request interface{},
+	responseHeader map[string]interface{}, responseContent interface{}, headers map[string]string) error {
+	return s.call(WithOperation(ctx, operation), soapAction, request, responseHeader, responseContent, nil, nil, headers)
+}
+
 // Call performs HTTP POST request.
-// Note that if the server returns a status code >= 400, a HTTPError will be returned
+// Note that if the server returns a status code >= 400 without a SOAP fault, a HTTPError will be returned
 func (s *Client) Call(soapAction string, request interface{}, responseHeader map[string]interface{}, responseContent interface{},
 	headers map[string]string) error {
 	return s.call(context.Background(), soapAction, request, responseHeader, responseContent, nil, nil, headers)
@@ -356,14 +705,43 @@ func (s *Client) CallWithFaultDetail(soapAction string, request interface{},
 func (s *Client) call(ctx context.Context, soapAction string, request interface{}, responseHeader map[string]interface{},
 	responseContent interface{}, faultDetail FaultError, retAttachments *[]MIMEMultipartAttachment, headers map[string]string) (err error) {
 
+	if s.opts.Validate {
+		if err = validate(request, false); err != nil {
+			return
+		}
+	}
+
+	if strict, ok := request.(*StrictPrefixedContent); ok && strict.Prefixes == nil {
+		request = &StrictPrefixedContent{Content: strict.Content, Prefixes: s.opts.NamespacePrefixes}
+	}
+
 	// SOAP envelope capable of namespace prefixes
 	envelope := Envelope{
-		XmlNS: XmlNsSoapEnv,
+		ExtraNamespaces: s.opts.ExtraNamespaces,
 	}
+	_, envelope.XmlNS = s.opts.Version.envelopePrefix()
 
-	if s.Headers != nil {
+	soapHeaders := s.Headers
+	callHeaders := SOAPHeaders(ctx)
+	if id, ok := CorrelationID(ctx); ok && s.opts.CorrelationHeader != nil {
+		callHeaders = append(callHeaders, s.opts.CorrelationHeader.element(id))
+	}
+	if len(callHeaders) > 0 {
+		// the Client headers are shared by concurrent calls, add to a copy
+		soapHeaders = &XmlContent{}
+		if s.Headers != nil {
+			soapHeaders.Content = s.Headers.Content
+			soapHeaders.Items = append(soapHeaders.Items, s.Headers.Items...)
+		}
+		for _, header := range callHeaders {
+			if err = soapHeaders.AddItem(header); err != nil {
+				return
+			}
+		}
+	}
+	if soapHeaders != nil {
 		envelope.Header = &Header{
-			Headers: s.Headers,
+			Headers: soapHeaders,
 		}
 	}
 
@@ -373,10 +751,14 @@ func (s *Client) call(ctx context.Context, soapAction string, request interface{
 	var encoder SOAPEncoder
 	if s.opts.Mtom && s.opts.Mma {
 		return fmt.Errorf("cannot use MTOM (XOP) and MMA (MIME Multipart Attachments) option at the same time")
+	} else if s.opts.Mma && s.opts.Version == SOAP12 {
+		return fmt.Errorf("cannot use MMA (MIME Multipart Attachments) with SOAP 1.2")
 	} else if s.opts.Mtom {
 		encoder = newMtomEncoder(buffer)
 	} else if s.opts.Mma {
-		encoder = newMmaEncoder(buffer, s.attachments)
+		if encoder, err = newMmaEncoder(buffer, s.attachments, s.opts.MmaBoundary); err != nil {
+			return
+		}
 	} else {
 		encoder = xml.NewEncoder(buffer)
 	}
@@ -389,72 +771,110 @@ func (s *Client) call(ctx context.Context, soapAction string, request interface{
 		return
 	}
 
-	var req *http.Request
-	if req, err = http.NewRequest("POST", s.url, buffer); err != nil {
+	contentType := s.envelopeContentType()
+	if s.opts.Mtom {
+		contentType = fmt.Sprintf(mtomContentType, encoder.(*mtomEncoder).Boundary())
+	} else if s.opts.Mma {
+		contentType = fmt.Sprintf(mmaContentType, encoder.(*mmaEncoder).Boundary())
+	}
+	reqHeaders, err := s.requestHeaders(ctx, contentType, soapAction, headers)
+	if err != nil {
 		return
 	}
-	if s.opts.BasicAuth != nil {
-		req.SetBasicAuth(s.opts.BasicAuth.Login, s.opts.BasicAuth.Password)
+	return s.roundTrip(ctx, soapAction, buffer.Bytes(), reqHeaders, responseHeader, responseContent, faultDetail, retAttachments)
+}
+
+// CallRawBody sends body, a complete serialized SOAP envelope, as is instead
+// of marshaling a request and decodes the response into responseContent like
+// the other calls, e.g. to replay a captured or edited request. The HTTP
+// headers, authentication, TLS settings, Transport and idempotency key of the
+// Client still apply, while the Client Headers, the SOAP headers of ctx,
+// Options.Validate for the request and the MTOM or MMA encoding don't, as
+// they are part of the envelope.
+func (s *Client) CallRawBody(ctx context.Context, soapAction string, body []byte, responseContent interface{}) error {
+	reqHeaders, err := s.requestHeaders(ctx, s.envelopeContentType(), soapAction, nil)
+	if err != nil {
+		return err
 	}
+	return s.roundTrip(ctx, soapAction, body, reqHeaders, nil, responseContent, nil, nil)
+}
 
-	req = req.WithContext(ctx)
+// envelopeContentType returns the Content-Type of a plain envelope of the SOAP version.
+func (s *Client) envelopeContentType() string {
+	if s.opts.Version == SOAP12 {
+		return "application/soap+xml; charset=\"utf-8\""
+	}
+	return "text/xml; charset=\"utf-8\""
+}
 
-	if s.opts.Mtom {
-		req.Header.Add("Content-Type", fmt.Sprintf(mtomContentType, encoder.(*mtomEncoder).Boundary()))
-	} else if s.opts.Mma {
-		req.Header.Add("Content-Type", fmt.Sprintf(mmaContentType, encoder.(*mmaEncoder).Boundary()))
-	} else {
-		req.Header.Add("Content-Type", "text/xml; charset=\"utf-8\"")
+// requestHeaders returns the HTTP headers of a request, headers passed to the call take precedence.
+func (s *Client) requestHeaders(ctx context.Context, contentType, soapAction string, headers map[string]string) (reqHeaders map[string]string, err error) {
+	reqHeaders = map[string]string{"Content-Type": contentType}
+	if s.opts.Version == SOAP12 && soapAction != "" {
+		reqHeaders["Content-Type"] += fmt.Sprintf("; action=%q", soapAction)
+	}
+	reqHeaders["User-Agent"] = s.opts.UserAgent
+	if s.opts.Accept == defaultAccept && s.opts.Version == SOAP12 {
+		reqHeaders["Accept"] = "application/soap+xml"
+	} else if s.opts.Accept != "" {
+		reqHeaders["Accept"] = s.opts.Accept
+	}
+	for k, v := range s.opts.HttpHeaders {
+		reqHeaders[http.CanonicalHeaderKey(k)] = v
 	}
-	req.Header.Add("SOAPAction", soapAction)
-	req.Header.Set("User-Agent", s.opts.UserAgent)
-	if s.opts.HttpHeaders != nil {
-		for k, v := range s.opts.HttpHeaders {
-			req.Header.Set(k, v)
+	s.headersMu.RLock()
+	for k, v := range s.defaultHeaders {
+		reqHeaders[k] = v
+	}
+	s.headersMu.RUnlock()
+	if s.opts.IdempotencyKeyHeader != "" {
+		// the key is made once per call, the Transport sends it with each attempt
+		key, ok := IdempotencyKey(ctx)
+		if !ok {
+			if key, err = newIdempotencyKey(); err != nil {
+				return
+			}
 		}
+		reqHeaders[http.CanonicalHeaderKey(s.opts.IdempotencyKeyHeader)] = key
 	}
-
 	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
-
-	req.Close = true
-
-	var client HTTPClient
-	if client, err = s.opts.getOrBuildHttpClient(); err != nil {
-		return
+		reqHeaders[http.CanonicalHeaderKey(k)] = v
 	}
+	return
+}
 
-	if s.opts.Debug {
-		fmt.Printf("\n=== Start: Debug Request ===\n")
-		fmt.Printf("\nrequest: url=%v, header=%v, body=%v\n", req.URL, req.Header, buffer.String())
-		fmt.Printf("\n=== End: Debug Request===\n")
+// roundTrip sends the request body and decodes the response into responseHeader and responseContent.
+func (s *Client) roundTrip(ctx context.Context, soapAction string, reqBody []byte, reqHeaders map[string]string, responseHeader map[string]interface{},
+	responseContent interface{}, faultDetail FaultError, retAttachments *[]MIMEMultipartAttachment) (err error) {
+	transport := s.opts.Transport
+	if transport == nil {
+		transport = &httpTransport{url: s.url, opts: s.opts}
 	}
 
-	var res *http.Response
-	if res, err = client.Do(req); err != nil {
-		return
+	var body io.ReadCloser
+	var resHeaders map[string]string
+	if body, resHeaders, err = transport.RoundTrip(ctx, soapAction, reqBody, reqHeaders); err != nil {
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) || !httpErr.mayCarryFault() {
+			return
+		}
+		// decode the fault of the body, the HTTPError is returned if it has none
+		body = io.NopCloser(bytes.NewReader(httpErr.ResponseBody))
+		resHeaders = map[string]string{"Content-Type": httpErr.ContentType}
+		defer func() {
+			var fault *Fault
+			if !errors.As(err, &fault) {
+				err = httpErr
+			}
+		}()
 	}
-	defer res.Body.Close()
-
-	bodyReader := res.Body
-	if s.opts.Debug {
-		fmt.Printf("\n=== Start: Debug Response ===\n")
-		buf := new(bytes.Buffer)
-		_, err = buf.ReadFrom(bodyReader)
-		bodyReader = io.NopCloser(bytes.NewReader(buf.Bytes()))
-
-		fmt.Printf("\nresponse: body=%v, header=%v\n", buf.String(), res.Header)
-
-		//spew.Dump("SOAP Response: ", res)
-		//fmt.Printf("Response.Body: %v", buf.String())
-		//bodyReader = io.NopCloser(bytes.NewReader(buf.Bytes()))
-
-		//mapDecoder := xml2map.NewDecoder(strings.NewReader(buf.String()))
-		//responseMap, mapErr := mapDecoder.Decode()
-		//fmt.Printf("response: %v, err: %v", responseMap, mapErr)
-
-		fmt.Printf("\n=== End: Debug Response===\n")
+	defer body.Close()
+	bodyReader := bufio.NewReader(body)
+	if s.opts.AllowEmptyResponse {
+		var empty bool
+		if empty, err = emptyBody(bodyReader); err != nil || empty {
+			return
+		}
 	}
 
 	// xml Decoder (used with and without MTOM) cannot handle namespace prefixes (yet),
@@ -469,10 +889,12 @@ func (s *Client) call(ctx context.Context, soapAction string, request interface{
 		Fault: &Fault{
 			Detail: faultDetail,
 		},
+		lenientNamespace: s.opts.LenientResponseNamespace,
+		partialResults:   s.opts.PartialResults,
 	}
 
 	var mtomBoundary string
-	contentType := res.Header.Get("Content-Type")
+	contentType := resHeaders["Content-Type"]
 	if mtomBoundary, err = getMtomHeader(contentType); err != nil {
 		return
 	}
@@ -490,15 +912,37 @@ func (s *Client) call(ctx context.Context, soapAction string, request interface{
 	} else if mmaBoundary != "" {
 		dec = newMmaDecoder(bodyReader, mmaBoundary)
 	} else {
-		dec = xml.NewDecoder(bodyReader)
+		dec = newNamespaceDecoder(bodyReader)
 	}
 
 	if err = dec.Decode(respEnvelope); err != nil {
 		return err
 	}
 
-	if respEnvelope.Attachments != nil {
+	if respEnvelope.Attachments != nil && retAttachments != nil {
 		*retAttachments = respEnvelope.Attachments
 	}
-	return respEnvelope.Body.ErrorFromFault()
+	if err = respEnvelope.Body.ErrorFromFault(); err != nil {
+		return
+	}
+	if s.opts.Validate {
+		return validate(responseContent, true)
+	}
+	return nil
+}
+
+// emptyBody reports whether the rest of r is white space, leaving the
+// first other byte unread.
+func emptyBody(r *bufio.Reader) (bool, error) {
+	for {
+		c, err := r.ReadByte()
+		if err == io.EOF {
+			return true, nil
+		} else if err != nil {
+			return false, err
+		}
+		if !unicode.IsSpace(rune(c)) {
+			return false, r.UnreadByte()
+		}
+	}
 }
@@ -3,16 +3,37 @@ package soap
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"software.sslmate.com/src/go-pkcs12"
 )
 
 type Ping struct {
@@ -48,6 +69,12 @@ type AttachmentRequest struct {
 	ContentID string `xml:"contentID,omitempty"`
 }
 
+func withOptions(configure func(o *Options)) *Options {
+	opts := DefaultOptions()
+	configure(&opts)
+	return &opts
+}
+
 func TestClient_Call(t *testing.T) {
 	var pingRequest = new(Ping)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -66,7 +93,7 @@ func TestClient_Call(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	client := NewClient(ts.URL)
+	client := NewClient(ts.URL, nil)
 	req := &Ping{Request: &PingRequest{Message: "Hi"}}
 	reply := &PingResponse{}
 	if err := client.Call("GetData", req, nil, reply, nil); err != nil {
@@ -119,7 +146,7 @@ func TestClient_Send_Correct_Headers(t *testing.T) {
 	defer ts.Close()
 
 	for _, test := range tests {
-		client := NewClient(ts.URL, WithHTTPHeaders(test.reqHeaders))
+		client := NewClient(ts.URL, withOptions(func(o *Options) { o.HttpHeaders = test.reqHeaders }))
 		req := struct{}{}
 		reply := struct{}{}
 		client.Call(test.action, req, nil, reply, nil)
@@ -133,6 +160,37 @@ func TestClient_Send_Correct_Headers(t *testing.T) {
 	}
 }
 
+func TestClient_SOAPAction(t *testing.T) {
+	var gotHeaders http.Header
+	var gotBody []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header
+		gotBody, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		version     SOAPVersion
+		action      string
+		contentType string
+		soapAction  []string
+		envelope    string
+	}{
+		{SOAP11, "GetData", `text/xml; charset="utf-8"`, []string{"GetData"}, XmlNsSoapEnv},
+		{SOAP11, "", `text/xml; charset="utf-8"`, nil, XmlNsSoapEnv},
+		{SOAP12, "GetData", `application/soap+xml; charset="utf-8"; action="GetData"`, nil, XmlNsSoap12Env},
+		{SOAP12, "", `application/soap+xml; charset="utf-8"`, nil, XmlNsSoap12Env},
+	}
+	for _, test := range tests {
+		client := NewClient(ts.URL, withOptions(func(o *Options) { o.Version = test.version }))
+		client.Call(test.action, &Ping{Request: &PingRequest{Message: "ping"}}, nil, &PingResponse{}, nil)
+
+		assert.Equal(t, test.contentType, gotHeaders.Get("Content-Type"))
+		assert.Equal(t, test.soapAction, gotHeaders.Values("SOAPAction"))
+		assert.Contains(t, string(gotBody), `xmlns:soap="`+test.envelope+`"`)
+	}
+}
+
 func TestClient_Attachments_WithAttachmentResponse(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		for k, v := range r.Header {
@@ -155,7 +213,7 @@ func TestClient_Attachments_WithAttachmentResponse(t *testing.T) {
 		Name: "Second_Attachment",
 		Data: []byte(`tl;tr`),
 	}
-	client := NewClient(ts.URL, WithMIMEMultipartAttachments())
+	client := NewClient(ts.URL, withOptions(func(o *Options) { o.Mma = true }))
 	client.AddMIMEMultipartAttachment(firstAtt)
 	client.AddMIMEMultipartAttachment(secondAtt)
 	req := &AttachmentRequest{
@@ -178,6 +236,60 @@ func TestClient_Attachments_WithAttachmentResponse(t *testing.T) {
 	assert.Equal(t, retAttachments[1], secondAtt)
 }
 
+func TestClient_Attachments_MIMEHeaders(t *testing.T) {
+	var contentType string
+	var partHeaders []textproto.MIMEHeader
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		_, params, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		reader := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+			partHeaders = append(partHeaders, part.Header)
+		}
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL, withOptions(func(o *Options) {
+		o.Mma = true
+		o.MmaBoundary = "gowsdl-boundary"
+	}))
+	client.AddMIMEMultipartAttachment(MIMEMultipartAttachment{
+		Name:        "invoice.pdf",
+		Data:        []byte(`%PDF`),
+		ContentID:   "invoice@example.com",
+		ContentType: "application/pdf",
+	})
+	client.AddMIMEMultipartAttachment(MIMEMultipartAttachment{
+		Name: "notes",
+		Data: []byte(`foobar`),
+	})
+	client.Call("''", &AttachmentRequest{Name: "Upload", ContentID: "invoice@example.com"}, nil, new(AttachmentRequest), nil)
+
+	assert.Equal(t, `multipart/related; start="<soaprequest@gowsdl.lib>"; type="text/xml"; boundary="gowsdl-boundary"`, contentType)
+	if assert.Len(t, partHeaders, 3) {
+		assert.Equal(t, "<soaprequest@gowsdl.lib>", partHeaders[0].Get("Content-ID"))
+		assert.Equal(t, "application/pdf", partHeaders[1].Get("Content-Type"))
+		assert.Equal(t, "<invoice@example.com>", partHeaders[1].Get("Content-ID"))
+		assert.Equal(t, "application/octet-stream; name=notes", partHeaders[2].Get("Content-Type"))
+		assert.Equal(t, "<notes>", partHeaders[2].Get("Content-ID"))
+	}
+
+	client = NewClient(ts.URL, withOptions(func(o *Options) {
+		o.Mma = true
+		o.MmaBoundary = "invalid boundary "
+	}))
+	err := client.Call("''", &AttachmentRequest{Name: "Upload"}, nil, new(AttachmentRequest), nil)
+	assert.Error(t, err)
+}
+
 func TestClient_MTOM(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		for k, v := range r.Header {
@@ -188,7 +300,7 @@ func TestClient_MTOM(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	client := NewClient(ts.URL, WithMTOM())
+	client := NewClient(ts.URL, withOptions(func(o *Options) { o.Mtom = true }))
 	req := &PingRequest{Attachment: NewBinary([]byte("Attached data")).SetContentType("text/plain")}
 	reply := &PingRequest{}
 	if err := client.Call("GetData", req, nil, reply, nil); err != nil {
@@ -204,6 +316,41 @@ func TestClient_MTOM(t *testing.T) {
 	}
 }
 
+func TestClient_MTOM_ContentIDReferences(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"application/xop+xml"}})
+		part.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` +
+			`<AttachmentReply xmlns="http://example.com/service.xsd" xmlns:xop="http://www.w3.org/2004/08/xop/include">` +
+			`<Included><xop:Include href="cid:included%40example.com"/></Included>` +
+			`<Referenced href="cid:referenced@example.com"/>` +
+			`<Unresolved><xop:Include href="cid:missing@example.com"/></Unresolved>` +
+			`</AttachmentReply></soap:Body></soap:Envelope>`))
+		for id, content := range map[string]string{"included@example.com": "included data", "referenced@example.com": "referenced data"} {
+			part, _ = writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain"}, "Content-Id": {"<" + id + ">"}})
+			part.Write([]byte(content))
+		}
+		writer.Close()
+		w.Header().Set("Content-Type", fmt.Sprintf(mtomContentType, writer.Boundary()))
+		w.Write(body.Bytes())
+	}))
+	defer ts.Close()
+
+	reply := &struct {
+		XMLName    xml.Name `xml:"http://example.com/service.xsd AttachmentReply"`
+		Included   []byte   `xml:"Included"`
+		Referenced []byte   `xml:"Referenced"`
+		Unresolved []byte   `xml:"Unresolved"`
+	}{}
+	if err := NewClient(ts.URL, nil).Call("GetData", &Ping{}, nil, reply, nil); err != nil {
+		t.Fatalf("couln't call service: %v", err)
+	}
+	assert.Equal(t, "included data", string(reply.Included))
+	assert.Equal(t, "referenced data", string(reply.Referenced))
+	assert.Empty(t, reply.Unresolved)
+}
+
 type SimpleNode struct {
 	Detail string      `xml:"Detail,omitempty"`
 	Num    float64     `xml:"Num,omitempty"`
@@ -346,7 +493,7 @@ func Test_Client_FaultDefault(t *testing.T) {
 
 			faultErrString := tt.wantErrString
 
-			client := NewClient(ts.URL)
+			client := NewClient(ts.URL, nil)
 			req := &Ping{Request: &PingRequest{Message: "Hi"}}
 			var reply PingResponse
 			fault := Wrapper{
@@ -823,7 +970,7 @@ func TestHTTPError(t *testing.T) {
 				w.Write([]byte(test.responseBody))
 			}))
 			defer ts.Close()
-			client := NewClient(ts.URL)
+			client := NewClient(ts.URL, nil)
 			gotErr := client.Call("GetData", &Ping{}, nil, &PingResponse{}, nil)
 			if test.wantErr {
 				if gotErr == nil {
@@ -852,3 +999,1894 @@ func TestHTTPError(t *testing.T) {
 	}
 
 }
+
+func TestHTTPError_Fault(t *testing.T) {
+	fault11 := `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><soap:Fault>` +
+		`<faultcode>soap:Server</faultcode><faultstring>database down</faultstring></soap:Fault></soap:Body></soap:Envelope>`
+	fault12 := `<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope"><env:Body><env:Fault>` +
+		`<env:Code><env:Value>env:Sender</env:Value></env:Code><env:Reason><env:Text xml:lang="en">bad account</env:Text></env:Reason>` +
+		`</env:Fault></env:Body></env:Envelope>`
+	response := `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` +
+		`<PingResponse xmlns="http://example.com/service.xsd"><PingResult><Message>Pong</Message></PingResult></PingResponse></soap:Body></soap:Envelope>`
+
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		wantFault   string
+	}{
+		{name: "SOAP 1.1 fault with 500", status: http.StatusInternalServerError, contentType: "text/xml; charset=utf-8", body: fault11, wantFault: "database down"},
+		{name: "SOAP 1.2 fault with 400", status: http.StatusBadRequest, contentType: "application/soap+xml", body: fault12, wantFault: "bad account"},
+		{name: "fault with 403 as text", status: http.StatusForbidden, contentType: "text/plain", body: fault11},
+		{name: "500 with an envelope without fault", status: http.StatusInternalServerError, contentType: "text/xml", body: response},
+		{name: "502 with html", status: http.StatusBadGateway, contentType: "text/html", body: "<html>bad gateway</html>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header()["Content-Type"] = []string{test.contentType}
+				w.WriteHeader(test.status)
+				w.Write([]byte(test.body))
+			}))
+			defer ts.Close()
+
+			err := NewClient(ts.URL, nil).Call("GetData", &Ping{}, nil, &PingResponse{}, nil)
+			if test.wantFault != "" {
+				var fault *Fault
+				if assert.True(t, errors.As(err, &fault), "got %v", err) {
+					assert.Equal(t, test.wantFault, fault.String)
+				}
+				return
+			}
+			var httpErr *HTTPError
+			if assert.True(t, errors.As(err, &httpErr), "got %v", err) {
+				assert.Equal(t, test.status, httpErr.StatusCode)
+				assert.Equal(t, test.body, string(httpErr.ResponseBody))
+			}
+		})
+	}
+}
+
+func TestClient_ExtraNamespaces(t *testing.T) {
+	var gotBody []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = ioutil.ReadAll(r.Body)
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+		<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+			<soap:Body>
+				<PingResponse xmlns="http://example.com/service.xsd"></PingResponse>
+			</soap:Body>
+		</soap:Envelope>`))
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL, withOptions(func(o *Options) {
+		o.ExtraNamespaces = map[string]string{"svc": "http://example.com/service.xsd"}
+	}))
+	client.AddNamespace("ext", "http://example.com/ext.xsd")
+	if err := client.Call("GetData", &Ping{}, nil, &PingResponse{}, nil); err != nil {
+		t.Fatalf("couln't call service: %v", err)
+	}
+
+	wantEnvelope := `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" ` +
+		`xmlns:ext="http://example.com/ext.xsd" xmlns:svc="http://example.com/service.xsd"><soap:Body>`
+	assert.Contains(t, string(gotBody), wantEnvelope)
+}
+
+type FaultCodeResponse struct {
+	XMLName xml.Name `xml:"http://example.com/service.xsd FaultCodeResponse"`
+
+	Code QName `xml:"Code"`
+}
+
+func TestQName_ResolvesAncestorPrefix(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+		<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:ex="http://example.com/codes">
+			<soap:Body>
+				<FaultCodeResponse xmlns="http://example.com/service.xsd">
+					<Code>ex:Invalid</Code>
+				</FaultCodeResponse>
+			</soap:Body>
+		</soap:Envelope>`))
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL, nil)
+	reply := &FaultCodeResponse{}
+	if err := client.Call("GetData", &Ping{}, nil, reply, nil); err != nil {
+		t.Fatalf("couln't call service: %v", err)
+	}
+	assert.Equal(t, QName{Space: "http://example.com/codes", Local: "Invalid"}, reply.Code)
+}
+
+func TestQName_RoundTrip(t *testing.T) {
+	in := FaultCodeResponse{Code: QName{Space: "http://example.com/codes", Local: "Invalid"}}
+	data, err := xml.Marshal(in)
+	if err != nil {
+		t.Fatalf("error encoding: %v", err)
+	}
+
+	out := FaultCodeResponse{}
+	if err = xml.Unmarshal(data, &out); err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+	assert.Equal(t, in.Code, out.Code)
+	assert.Equal(t, "{http://example.com/codes}Invalid", out.Code.String())
+}
+
+type Snippet struct {
+	XMLName xml.Name `xml:"Snippet"`
+
+	Html CDATAString `xml:"Html"`
+}
+
+func TestCDATAString_RoundTrip(t *testing.T) {
+	in := Snippet{Html: "<b>bold & brave</b>"}
+	data, err := xml.Marshal(in)
+	if err != nil {
+		t.Fatalf("error encoding: %v", err)
+	}
+	assert.Equal(t, `<Snippet><Html><![CDATA[<b>bold & brave</b>]]></Html></Snippet>`, string(data))
+
+	out := Snippet{}
+	if err = xml.Unmarshal(data, &out); err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+	assert.Equal(t, in.Html, out.Html)
+}
+
+type GetInfo struct {
+	XMLName xml.Name `xml:"http://example.com/info.xsd GetInfo"`
+
+	Id    string `xml:"Id"`
+	Other string `xml:"http://example.com/other.xsd Other"`
+}
+
+type GetInfoNote struct {
+	XMLName xml.Name `xml:"http://example.com/info.xsd GetInfo"`
+
+	Id   string      `xml:"Id"`
+	Note CDATAString `xml:"Note"`
+}
+
+func TestPrefixedContent(t *testing.T) {
+	request := &GetInfo{Id: "1", Other: "2"}
+
+	tests := []struct {
+		name    string
+		content interface{}
+		want    string
+	}{
+		{
+			name:    "default namespace",
+			content: NewPrefixedContent("", request),
+			want: `<soap:Body><GetInfo xmlns="http://example.com/info.xsd"><Id>1</Id>` +
+				`<Other xmlns="http://example.com/other.xsd">2</Other></GetInfo></soap:Body>`,
+		},
+		{
+			name:    "prefixed",
+			content: NewPrefixedContent("tns", request),
+			want: `<soap:Body><tns:GetInfo xmlns:tns="http://example.com/info.xsd"><tns:Id>1</tns:Id>` +
+				`<Other xmlns="http://example.com/other.xsd">2</Other></tns:GetInfo></soap:Body>`,
+		},
+		{
+			name:    "prefixed with CDATA",
+			content: NewPrefixedContent("tns", &GetInfoNote{Id: "1", Note: "a < b & c"}),
+			want: `<soap:Body><tns:GetInfo xmlns:tns="http://example.com/info.xsd"><tns:Id>1</tns:Id>` +
+				`<tns:Note><![CDATA[a < b & c]]></tns:Note></tns:GetInfo></soap:Body>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envelope := Envelope{XmlNS: XmlNsSoapEnv, Body: Body{Content: tt.content}}
+			got, err := xml.Marshal(envelope)
+			if err != nil {
+				t.Fatalf("couldn't marshal envelope: %v", err)
+			}
+			assert.Contains(t, string(got), tt.want)
+		})
+	}
+}
+
+type StrictOrder struct {
+	XMLName xml.Name `xml:"http://example.com/strict/orders.xsd PlaceOrder"`
+
+	Id   string     `xml:"Id"`
+	Item StrictItem `xml:"http://example.com/strict/items.xsd Item"`
+	Note string     `xml:"http://example.com/strict/notes.xsd Note"`
+}
+
+type StrictItem struct {
+	Sku  string `xml:"http://example.com/strict/items.xsd sku,attr"`
+	Name string `xml:"Name"`
+}
+
+func TestStrictPrefixedContent(t *testing.T) {
+	// The server matches elements by prefix, as some stacks do.
+	var body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+		want := `<ord:PlaceOrder xmlns:itm="http://example.com/strict/items.xsd" xmlns:ord="http://example.com/strict/orders.xsd">` +
+			`<ord:Id>1</ord:Id><itm:Item itm:sku="A-1"><itm:Name>Widget</itm:Name></itm:Item>` +
+			`<Note xmlns="http://example.com/strict/notes.xsd">fragile</Note></ord:PlaceOrder>`
+		if !strings.Contains(body, want) {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>
+				<soap:Fault><faultcode>soap:Client</faultcode><faultstring>unexpected prefixes</faultstring></soap:Fault>
+			</soap:Body></soap:Envelope>`))
+			return
+		}
+		w.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>
+			<PingResponse xmlns="http://example.com/service.xsd"><PingResult><Message>placed</Message></PingResult></PingResponse>
+		</soap:Body></soap:Envelope>`))
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL, nil)
+	assert.NoError(t, client.RegisterNamespacePrefix("http://example.com/strict/orders.xsd", "ord"))
+	assert.NoError(t, client.RegisterNamespacePrefix("http://example.com/strict/items.xsd", "itm"))
+	assert.NoError(t, client.RegisterNamespacePrefix("http://example.com/strict/items.xsd", "itm"))
+	// conflicting registrations are rejected instead of replacing the prefixes
+	assert.Error(t, client.RegisterNamespacePrefix("http://example.com/strict/items.xsd", "ord"))
+	assert.Error(t, client.RegisterNamespacePrefix("http://example.com/strict/notes.xsd", "itm"))
+	request := &StrictOrder{Id: "1", Item: StrictItem{Sku: "A-1", Name: "Widget"}, Note: "fragile"}
+	reply := &PingResponse{}
+	if err := client.Call("PlaceOrder", NewStrictPrefixedContent(request), nil, reply, nil); err != nil {
+		t.Fatalf("couldn't call service: %v\nrequest: %s", err, body)
+	}
+	assert.Equal(t, "placed", reply.PingResult.Message)
+
+	// Without strict prefixes the server rejects the request.
+	err := client.Call("PlaceOrder", request, nil, &PingResponse{}, nil)
+	assert.Error(t, err)
+
+	// the prefixes are registered with the client, other clients don't use them
+	err = NewClient(ts.URL, nil).Call("PlaceOrder", NewStrictPrefixedContent(request), nil, &PingResponse{}, nil)
+	assert.Error(t, err)
+
+	note := &struct {
+		XMLName xml.Name    `xml:"http://example.com/strict/orders.xsd Note"`
+		Text    CDATAString `xml:"Text"`
+	}{Text: "<fragile>"}
+	data, err := xml.Marshal(&StrictPrefixedContent{Content: note, Prefixes: map[string]string{"http://example.com/strict/orders.xsd": "ord"}})
+	assert.NoError(t, err)
+	assert.Equal(t, `<ord:Note xmlns:ord="http://example.com/strict/orders.xsd"><ord:Text><![CDATA[<fragile>]]></ord:Text></ord:Note>`, string(data))
+}
+
+type PlainInfo struct {
+	Id string `xml:"Id"`
+}
+
+func TestElement(t *testing.T) {
+	request := &PlainInfo{Id: "1"}
+
+	tests := []struct {
+		name    string
+		content interface{}
+		want    string
+	}{
+		{
+			name:    "default namespace",
+			content: NewElement("http://example.com/info.xsd", "GetInfo", request),
+			want:    `<soap:Body><GetInfo xmlns="http://example.com/info.xsd"><Id>1</Id></GetInfo></soap:Body>`,
+		},
+		{
+			name:    "prefixed",
+			content: NewPrefixedContent("tns", NewElement("http://example.com/info.xsd", "GetInfo", request)),
+			want:    `<soap:Body><tns:GetInfo xmlns:tns="http://example.com/info.xsd"><tns:Id>1</tns:Id></tns:GetInfo></soap:Body>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envelope := Envelope{XmlNS: XmlNsSoapEnv, Body: Body{Content: tt.content}}
+			got, err := xml.Marshal(envelope)
+			if err != nil {
+				t.Fatalf("couldn't marshal envelope: %v", err)
+			}
+			assert.Contains(t, string(got), tt.want)
+		})
+	}
+}
+
+type ValidatedPing struct {
+	XMLName xml.Name `xml:"http://example.com/service.xsd Ping"`
+
+	Message string `xml:"Message"`
+}
+
+func (p *ValidatedPing) Validate() error {
+	if p.Message == "" {
+		return errors.New("Message is required")
+	}
+	return nil
+}
+
+type ValidatedPingResponse struct {
+	XMLName xml.Name `xml:"http://example.com/service.xsd PingResponse"`
+
+	Result string `xml:"Result"`
+}
+
+func (p *ValidatedPingResponse) Validate() error {
+	if p.Result == "" {
+		return errors.New("Result is required")
+	}
+	return nil
+}
+
+func TestClient_Validate(t *testing.T) {
+	var calls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+		<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+			<soap:Body>
+				<PingResponse xmlns="http://example.com/service.xsd"><Result></Result></PingResponse>
+			</soap:Body>
+		</soap:Envelope>`))
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name         string
+		validate     bool
+		message      string
+		element      bool
+		wantResponse bool
+		wantErr      bool
+		wantCalls    int
+	}{
+		{name: "disabled", message: "", wantCalls: 1},
+		{name: "invalid request", validate: true, message: "", wantErr: true},
+		{name: "invalid element request", validate: true, message: "", element: true, wantErr: true},
+		{name: "invalid response", validate: true, message: "hi", wantErr: true, wantResponse: true, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+			client := NewClient(ts.URL, withOptions(func(o *Options) {
+				o.Validate = tt.validate
+			}))
+			var request interface{} = &ValidatedPing{Message: tt.message}
+			if tt.element {
+				request = NewElement("http://example.com/service.xsd", "Ping", request)
+			}
+			err := client.Call("Ping", request, nil, &ValidatedPingResponse{}, nil)
+
+			var validationErr *ValidationError
+			if tt.wantErr != errors.As(err, &validationErr) {
+				t.Fatalf("got error %v, wanted validation error %v", err, tt.wantErr)
+			}
+			if tt.wantErr && validationErr.Response != tt.wantResponse {
+				t.Errorf("got response validation %v, wanted %v", validationErr.Response, tt.wantResponse)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("got %d calls, wanted %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+type recordingTransport struct {
+	action    string
+	operation string
+	body      []byte
+	headers   map[string]string
+}
+
+func (t *recordingTransport) RoundTrip(ctx context.Context, action string, body []byte, headers map[string]string) (io.ReadCloser, map[string]string, error) {
+	t.action, t.body, t.headers = action, body, headers
+	t.operation, _ = Operation(ctx)
+	return io.NopCloser(strings.NewReader(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+			<soap:Body>
+				<PingResponse xmlns="http://example.com/service.xsd"><PingResult><Message>pong</Message></PingResult></PingResponse>
+			</soap:Body>
+		</soap:Envelope>`)), map[string]string{"Content-Type": "text/xml"}, nil
+}
+
+func TestClient_Transport(t *testing.T) {
+	transport := &recordingTransport{}
+	client := NewClient("jms://queue", withOptions(func(o *Options) {
+		o.Transport = transport
+		o.HttpHeaders = map[string]string{"x-client": "test"}
+	}))
+
+	reply := &PingResponse{}
+	if err := client.Call("GetData", &Ping{Request: &PingRequest{Message: "ping"}}, nil, reply, nil); err != nil {
+		t.Fatalf("couldn't call service: %v", err)
+	}
+
+	assert.Equal(t, "GetData", transport.action)
+	assert.Contains(t, string(transport.body), "<Message>ping</Message>")
+	assert.Equal(t, "text/xml; charset=\"utf-8\"", transport.headers["Content-Type"])
+	assert.Equal(t, "test", transport.headers["X-Client"])
+	assert.Equal(t, "pong", reply.PingResult.Message)
+}
+
+// streamTransport answers with body delivered one byte at a time, like a
+// slow connection, and records whether the Client closed it.
+type streamTransport struct {
+	contentType string
+	body        string
+	closed      bool
+}
+
+func (t *streamTransport) RoundTrip(ctx context.Context, action string, body []byte, headers map[string]string) (io.ReadCloser, map[string]string, error) {
+	return &closeRecorder{Reader: iotest.OneByteReader(strings.NewReader(t.body)), closed: &t.closed},
+		map[string]string{"Content-Type": t.contentType}, nil
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed *bool
+}
+
+func (r *closeRecorder) Close() error {
+	*r.closed = true
+	return nil
+}
+
+func TestClient_TransportStream(t *testing.T) {
+	transport := &streamTransport{contentType: "text/xml", body: `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` +
+		`<PingResponse xmlns="http://example.com/service.xsd"><PingResult><Message>pong</Message></PingResult></PingResponse></soap:Body></soap:Envelope>`}
+	client := NewClient("jms://queue", withOptions(func(o *Options) { o.Transport = transport }))
+
+	reply := &PingResponse{}
+	if err := client.Call("GetData", &Ping{}, nil, reply, nil); err != nil {
+		t.Fatalf("couldn't call service: %v", err)
+	}
+	assert.Equal(t, "pong", reply.PingResult.Message)
+	assert.True(t, transport.closed)
+
+	transport = &streamTransport{contentType: "text/xml", body: " \n "}
+	client = NewClient("jms://queue", withOptions(func(o *Options) {
+		o.Transport = transport
+		o.AllowEmptyResponse = true
+	}))
+	assert.NoError(t, client.Call("GetData", &Ping{}, nil, &PingResponse{}, nil))
+	assert.True(t, transport.closed)
+}
+
+func TestClient_TransportStreamMTOM(t *testing.T) {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"application/xop+xml"}})
+	part.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` +
+		`<AttachmentReply xmlns="http://example.com/service.xsd" xmlns:xop="http://www.w3.org/2004/08/xop/include">` +
+		`<Included><xop:Include href="cid:included@example.com"/></Included>` +
+		`</AttachmentReply></soap:Body></soap:Envelope>`))
+	part, _ = writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain"}, "Content-Id": {"<included@example.com>"}})
+	part.Write([]byte("included data"))
+	writer.Close()
+
+	transport := &streamTransport{contentType: fmt.Sprintf(mtomContentType, writer.Boundary()), body: body.String()}
+	reply := &struct {
+		XMLName  xml.Name `xml:"http://example.com/service.xsd AttachmentReply"`
+		Included []byte   `xml:"Included"`
+	}{}
+	client := NewClient("jms://queue", withOptions(func(o *Options) { o.Transport = transport }))
+	if err := client.Call("GetData", &Ping{}, nil, reply, nil); err != nil {
+		t.Fatalf("couldn't call service: %v", err)
+	}
+	assert.Equal(t, "included data", string(reply.Included))
+	assert.True(t, transport.closed)
+}
+
+func TestClient_EnforceTimeoutReadsBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		io.WriteString(w, `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>`)
+		w.(http.Flusher).Flush()
+		time.Sleep(20 * time.Millisecond)
+		io.WriteString(w, `<PingResponse xmlns="http://example.com/service.xsd"><PingResult><Message>pong</Message></PingResult></PingResponse></soap:Body></soap:Envelope>`)
+	}))
+	defer ts.Close()
+
+	// the timeout still runs while the Client reads the body the transport returned
+	client := NewClient(ts.URL, withOptions(func(o *Options) {
+		o.ConnectionTimeout = time.Second
+		o.EnforceTimeout = true
+	}))
+	reply := &PingResponse{}
+	if err := client.Call("GetData", &Ping{}, nil, reply, nil); err != nil {
+		t.Fatalf("couldn't call service: %v", err)
+	}
+	assert.Equal(t, "pong", reply.PingResult.Message)
+}
+
+type SessionHeader struct {
+	XMLName xml.Name `xml:"http://example.com/session.xsd Session"`
+
+	Id string `xml:"Id"`
+}
+
+func TestClient_SetHeader(t *testing.T) {
+	transport := &recordingTransport{}
+	client := NewClient("jms://queue", withOptions(func(o *Options) { o.Transport = transport }))
+	session := xml.Name{Space: "http://example.com/session.xsd", Local: "Session"}
+	tenant := xml.Name{Space: "http://example.com/tenant.xsd", Local: "Tenant"}
+
+	assert.NoError(t, client.SetHeader(session, &SessionHeader{Id: "1"}))
+	assert.NoError(t, client.SetHeader(tenant, NewElement(tenant.Space, tenant.Local, &PlainInfo{Id: "acme"})))
+	assert.NoError(t, client.SetHeader(session, &SessionHeader{Id: "2"}))
+	if err := client.Call("GetData", &Ping{}, nil, &PingResponse{}, nil); err != nil {
+		t.Fatalf("couldn't call service: %v", err)
+	}
+	body := string(transport.body)
+	assert.Contains(t, body, `<soap:Header><Tenant xmlns="http://example.com/tenant.xsd"><Id>acme</Id></Tenant>`+
+		`<Session xmlns="http://example.com/session.xsd"><Id>2</Id></Session></soap:Header>`)
+	assert.Equal(t, 1, strings.Count(body, "<Session "))
+
+	assert.NoError(t, client.SetHeader(tenant, nil))
+	if err := client.Call("GetData", &Ping{}, nil, &PingResponse{}, nil); err != nil {
+		t.Fatalf("couldn't call service: %v", err)
+	}
+	assert.Contains(t, string(transport.body), `<soap:Header><Session xmlns="http://example.com/session.xsd"><Id>2</Id></Session></soap:Header>`)
+}
+
+func TestClient_EmptyHeaders(t *testing.T) {
+	transport := &recordingTransport{}
+	client := NewClient("jms://queue", withOptions(func(o *Options) { o.Transport = transport }))
+	session := xml.Name{Space: "http://example.com/session.xsd", Local: "Session"}
+
+	client.Headers = &XmlContent{}
+	if err := client.Call("GetData", &Ping{}, nil, &PingResponse{}, nil); err != nil {
+		t.Fatalf("couldn't call service: %v", err)
+	}
+	assert.NotContains(t, string(transport.body), "Header")
+
+	assert.NoError(t, client.SetHeader(session, &SessionHeader{Id: "1"}))
+	assert.NoError(t, client.SetHeader(session, nil))
+	if err := client.Call("GetData", &Ping{}, nil, &PingResponse{}, nil); err != nil {
+		t.Fatalf("couldn't call service: %v", err)
+	}
+	assert.NotContains(t, string(transport.body), "Header")
+}
+
+func TestClient_CallRawBody(t *testing.T) {
+	transport := &recordingTransport{}
+	client := NewClient("jms://queue", withOptions(func(o *Options) {
+		o.Transport = transport
+		o.HttpHeaders = map[string]string{"x-client": "test"}
+	}))
+	assert.NoError(t, client.SetHeader(xml.Name{Space: "http://example.com/session.xsd", Local: "Session"}, &SessionHeader{Id: "1"}))
+
+	body := []byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` +
+		`<Ping xmlns="http://example.com/service.xsd"><request><Message>edited</Message></request></Ping></soap:Body></soap:Envelope>`)
+	reply := &PingResponse{}
+	if err := client.CallRawBody(context.Background(), "GetData", body, reply); err != nil {
+		t.Fatalf("couldn't call service: %v", err)
+	}
+
+	assert.Equal(t, body, transport.body)
+	assert.Equal(t, "GetData", transport.action)
+	assert.Equal(t, "text/xml; charset=\"utf-8\"", transport.headers["Content-Type"])
+	assert.Equal(t, "test", transport.headers["X-Client"])
+	assert.Equal(t, "pong", reply.PingResult.Message)
+}
+
+func TestClient_CallOperationContext(t *testing.T) {
+	transport := &recordingTransport{}
+	client := NewClient("jms://queue", withOptions(func(o *Options) { o.Transport = transport }))
+
+	if err := client.CallOperationContext(context.Background(), "Ping", "", &Ping{}, nil, &PingResponse{}, nil); err != nil {
+		t.Fatalf("couldn't call service: %v", err)
+	}
+	assert.Equal(t, "Ping", transport.operation)
+	assert.Equal(t, "", transport.action)
+
+	if err := client.Call("GetData", &Ping{}, nil, &PingResponse{}, nil); err != nil {
+		t.Fatalf("couldn't call service: %v", err)
+	}
+	assert.Equal(t, "", transport.operation)
+}
+
+func TestCallTyped(t *testing.T) {
+	transport := &recordingTransport{}
+	client := NewClient("jms://queue", withOptions(func(o *Options) { o.Transport = transport }))
+
+	reply, err := CallTyped[Ping, PingResponse](context.Background(), client, "GetData", &Ping{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "pong", reply.PingResult.Message)
+	}
+	assert.Equal(t, "GetData", transport.action)
+	assert.Equal(t, "", transport.operation)
+
+	reply, err = CallOperationTyped[Ping, PingResponse](context.Background(), client, "Ping", "", &Ping{}, nil, nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "pong", reply.PingResult.Message)
+	}
+	assert.Equal(t, "Ping", transport.operation)
+
+	envelope, err := os.ReadFile(filepath.Join("testdata", "fault11.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write(envelope)
+	}))
+	defer ts.Close()
+	detail := &Wrapper{Item: &SimpleNode{}, hasData: true}
+	reply, err = CallTypedWithFaultDetail[Ping, PingResponse](context.Background(), NewClient(ts.URL, nil), "GetData", &Ping{}, detail)
+	assert.Nil(t, reply)
+	assert.EqualError(t, err, "4.20: account closed")
+}
+
+type PagingHeader struct {
+	XMLName xml.Name `xml:"http://example.com/service.xsd Paging"`
+
+	NextToken string `xml:"NextToken"`
+	Total     int    `xml:"Total"`
+}
+
+type WarningHeader struct {
+	Code string `xml:"Code,attr"`
+	Text string `xml:",chardata"`
+}
+
+func TestClient_ResponseHeaders(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+		<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+			<soap:Header>
+				<CorrelationId xmlns="http://example.com/service.xsd">abc-123</CorrelationId>
+				<Paging xmlns="http://example.com/service.xsd"><NextToken>page-2</NextToken><Total>42</Total></Paging>
+				<Warning xmlns="http://example.com/service.xsd" Code="W1">first</Warning>
+				<Warning xmlns="http://example.com/service.xsd" Code="W2">second</Warning>
+				<Trace xmlns="http://example.com/service.xsd">a</Trace>
+				<Trace xmlns="http://example.com/service.xsd">b</Trace>
+			</soap:Header>
+			<soap:Body>
+				<PingResponse xmlns="http://example.com/service.xsd"></PingResponse>
+			</soap:Body>
+		</soap:Envelope>`))
+	}))
+	defer ts.Close()
+
+	var warnings []WarningHeader
+	headers := map[string]interface{}{
+		"Paging":  &PagingHeader{},
+		"Warning": &warnings,
+	}
+	client := NewClient(ts.URL, nil)
+	if err := client.Call("GetData", &Ping{}, headers, &PingResponse{}, nil); err != nil {
+		t.Fatalf("couldn't call service: %v", err)
+	}
+
+	assert.Equal(t, "abc-123", headers["CorrelationId"])
+	assert.Equal(t, []string{"a", "b"}, headers["Trace"])
+	paging := headers["Paging"].(*PagingHeader)
+	assert.Equal(t, "page-2", paging.NextToken)
+	assert.Equal(t, 42, paging.Total)
+	assert.Equal(t, []WarningHeader{{Code: "W1", Text: "first"}, {Code: "W2", Text: "second"}}, warnings)
+}
+
+type Digest struct {
+	XMLName xml.Name `xml:"Digest"`
+
+	Algorithm HexBinary `xml:"Algorithm,attr"`
+	Value     HexBinary `xml:"Value"`
+}
+
+func TestHexBinary_RoundTrip(t *testing.T) {
+	in := Digest{Algorithm: HexBinary{0x01}, Value: HexBinary{0xde, 0xad, 0xbe, 0xef}}
+	data, err := xml.Marshal(in)
+	if err != nil {
+		t.Fatalf("error encoding: %v", err)
+	}
+	assert.Equal(t, `<Digest Algorithm="01"><Value>DEADBEEF</Value></Digest>`, string(data))
+
+	out := Digest{}
+	if err = xml.Unmarshal([]byte(`<Digest Algorithm="01"><Value> deadBEEF </Value></Digest>`), &out); err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+	assert.Equal(t, in.Algorithm, out.Algorithm)
+	assert.Equal(t, in.Value, out.Value)
+
+	if err = xml.Unmarshal([]byte(`<Digest><Value>xyz</Value></Digest>`), &out); err == nil {
+		t.Error("expected an error for invalid hex digits")
+	}
+}
+
+type SearchResponse struct {
+	XMLName xml.Name `xml:"http://example.com/service.xsd SearchResponse"`
+
+	Result AnyType `xml:"Result"`
+}
+
+type Product struct {
+	XMLName xml.Name
+
+	Name  string  `xml:"http://example.com/service.xsd Name"`
+	Price float64 `xml:"http://example.com/catalog Price"`
+}
+
+func TestAnyType_Value(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+		<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:cat="http://example.com/catalog"
+			xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+			<soap:Body>
+				<SearchResponse xmlns="http://example.com/service.xsd">
+					<Result xsi:type="cat:Product"><Name>Lamp</Name><cat:Price>12.5</cat:Price></Result>
+				</SearchResponse>
+			</soap:Body>
+		</soap:Envelope>`))
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL, nil)
+	reply := &SearchResponse{}
+	if err := client.Call("GetData", &Ping{}, nil, reply, nil); err != nil {
+		t.Fatalf("couln't call service: %v", err)
+	}
+	assert.Equal(t, xml.Name{Space: "http://example.com/service.xsd", Local: "Result"}, reply.Result.Name)
+	assert.Equal(t, xml.Name{Space: "http://example.com/catalog", Local: "Product"}, reply.Result.Type)
+	assert.Equal(t, `<Name>Lamp</Name><cat:Price>12.5</cat:Price>`, reply.Result.InnerXML)
+
+	if _, err := reply.Result.Value(); err == nil {
+		t.Error("expected an error for an unregistered type")
+	}
+
+	NamespaceTypes.Register(reply.Result.Type, func() interface{} { return &Product{} })
+	value, err := reply.Result.Value()
+	if err != nil {
+		t.Fatalf("error decoding the value: %v", err)
+	}
+	product, ok := value.(*Product)
+	if !ok {
+		t.Fatalf("unexpected value type %T", value)
+	}
+	assert.Equal(t, "Lamp", product.Name)
+	assert.Equal(t, 12.5, product.Price)
+
+	direct := Product{}
+	if err = reply.Result.As(&direct); err != nil {
+		t.Fatalf("error decoding into the target: %v", err)
+	}
+	assert.Equal(t, *product, direct)
+
+	data, err := xml.Marshal(reply.Result)
+	if err != nil {
+		t.Fatalf("error encoding: %v", err)
+	}
+	assert.Equal(t, `<AnyType><Name>Lamp</Name><cat:Price>12.5</cat:Price></AnyType>`, string(data))
+}
+
+func TestClient_ExpectContinue(t *testing.T) {
+	var gotExpect string
+	var gotBody []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotExpect = r.Header.Get("Expect")
+		gotBody, _ = ioutil.ReadAll(r.Body)
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+		<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+			<soap:Body>
+				<PingResponse xmlns="http://example.com/service.xsd">
+					<PingResult><Message>Pong</Message></PingResult>
+				</PingResponse>
+			</soap:Body>
+		</soap:Envelope>`))
+	}))
+	defer ts.Close()
+
+	opts := DefaultOptions()
+	opts.ExpectContinueTimeout = time.Second
+	client := NewClient(ts.URL, &opts)
+	reply := &PingResponse{}
+	if err := client.Call("GetData", &Ping{Request: &PingRequest{Message: "Hi"}}, nil, reply, nil); err != nil {
+		t.Fatalf("couln't call service: %v", err)
+	}
+	assert.Equal(t, "100-continue", gotExpect)
+	assert.Contains(t, string(gotBody), "<Message>Hi</Message>")
+	assert.Equal(t, "Pong", reply.PingResult.Message)
+
+	httpClient, err := opts.BuildHttpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, time.Second, httpClient.Transport.(*http.Transport).ExpectContinueTimeout)
+}
+
+func TestClient_DefaultHeaders(t *testing.T) {
+	var gotHeaders http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL, withOptions(func(o *Options) {
+		o.HttpHeaders = map[string]string{"X-Api-Key": "initial", "X-Tenant": "acme"}
+	}))
+
+	client.SetDefaultHeader("x-api-key", "rotated")
+	client.Call("GetTrade", struct{}{}, nil, struct{}{}, nil)
+	assert.Equal(t, "rotated", gotHeaders.Get("X-Api-Key"))
+	assert.Equal(t, "acme", gotHeaders.Get("X-Tenant"))
+
+	client.CallContextWithAttachmentsAndFaultDetail(context.Background(), "GetTrade", struct{}{}, nil, struct{}{}, nil, nil,
+		map[string]string{"X-Api-Key": "per-call"})
+	assert.Equal(t, "per-call", gotHeaders.Get("X-Api-Key"))
+
+	client.RemoveDefaultHeader("X-Api-Key")
+	client.Call("GetTrade", struct{}{}, nil, struct{}{}, nil)
+	assert.Equal(t, "initial", gotHeaders.Get("X-Api-Key"))
+}
+
+func TestClient_CorrelationHeader(t *testing.T) {
+	var gotBody string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		gotBody = string(body)
+	}))
+	defer ts.Close()
+
+	opts := DefaultOptions()
+	opts.CorrelationHeader = &CorrelationHeader{Name: xml.Name{Space: "urn:example:tracing", Local: "CorrelationId"}}
+	client := NewClient(ts.URL, &opts)
+	client.Headers = &XmlContent{}
+	client.Headers.AddItem(&FaultCodeResponse{Code: QName{Local: "Session"}})
+
+	ctx := WithCorrelationID(context.Background(), "req-42")
+	client.CallContext(ctx, "GetData", &Ping{}, nil, &PingResponse{}, nil)
+	assert.Contains(t, gotBody, `<soap:Header><FaultCodeResponse xmlns="http://example.com/service.xsd"><Code>Session</Code></FaultCodeResponse>`+
+		`<CorrelationId xmlns="urn:example:tracing">req-42</CorrelationId></soap:Header>`)
+	assert.Len(t, client.Headers.Items, 1)
+
+	client.CallContext(context.Background(), "GetData", &Ping{}, nil, &PingResponse{}, nil)
+	assert.NotContains(t, gotBody, "CorrelationId")
+}
+
+func TestClient_LenientResponseNamespace(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+		<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+			<soap:Body>
+				<PingResponse xmlns="http://example.com/other.xsd">
+					<PingResult><Message>Pong</Message></PingResult>
+				</PingResponse>
+			</soap:Body>
+		</soap:Envelope>`))
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL, nil)
+	if err := client.Call("GetData", &Ping{}, nil, &PingResponse{}, nil); err == nil {
+		t.Error("expected an error for the response namespace")
+	}
+
+	opts := DefaultOptions()
+	opts.LenientResponseNamespace = true
+	client = NewClient(ts.URL, &opts)
+	reply := &PingResponse{}
+	if err := client.Call("GetData", &Ping{}, nil, reply, nil); err != nil {
+		t.Fatalf("couln't call service: %v", err)
+	}
+	assert.Equal(t, "Pong", reply.PingResult.Message)
+}
+
+func TestClient_PartialResults(t *testing.T) {
+	envelope, err := os.ReadFile(filepath.Join("testdata", "partialfault11.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(envelope)
+	}))
+	defer ts.Close()
+
+	// without PartialResults the body doesn't decode, the response is an HTTPError
+	err = NewClient(ts.URL, nil).Call("GetData", &Ping{}, nil, &PingResponse{}, nil)
+	var httpErr *HTTPError
+	if assert.True(t, errors.As(err, &httpErr), "%v", err) {
+		assert.Equal(t, http.StatusInternalServerError, httpErr.StatusCode)
+	}
+
+	client := NewClient(ts.URL, withOptions(func(o *Options) { o.PartialResults = true }))
+	reply := &PingResponse{}
+	err = client.Call("GetData", &Ping{}, nil, reply, nil)
+	var fault *Fault
+	if assert.True(t, errors.As(err, &fault)) {
+		assert.Equal(t, "Item 3 rejected", fault.String)
+	}
+	if assert.NotNil(t, reply.PingResult) {
+		assert.Equal(t, "2 of 3 items processed", reply.PingResult.Message)
+	}
+
+	fault11, err := os.ReadFile(filepath.Join("testdata", "fault11.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	faultServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(fault11)
+	}))
+	defer faultServer.Close()
+	reply = &PingResponse{}
+	err = NewClient(faultServer.URL, withOptions(func(o *Options) { o.PartialResults = true })).Call("GetData", &Ping{}, nil, reply, nil)
+	assert.EqualError(t, err, "Invalid account")
+	assert.Nil(t, reply.PingResult)
+}
+
+func TestClient_AllowEmptyResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	err := NewClient(ts.URL, nil).Call("GetData", &Ping{}, nil, &PingResponse{}, nil)
+	assert.Error(t, err)
+
+	client := NewClient(ts.URL, withOptions(func(o *Options) { o.AllowEmptyResponse = true }))
+	reply := &PingResponse{}
+	assert.NoError(t, client.Call("GetData", &Ping{}, nil, reply, nil))
+	assert.Nil(t, reply.PingResult)
+}
+
+type StreamItem struct {
+	ID   int    `xml:"Id"`
+	Name string `xml:"Name"`
+}
+
+func TestClient_CallStreamContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+		<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+			<soap:Body>
+				<ListItemsResponse xmlns="http://example.com/service.xsd">
+					<Total>3</Total>
+					<Items>
+						<Item><Id>1</Id><Name>first</Name></Item>
+						<Item><Id>2</Id><Name>second</Name></Item>
+						<Item xmlns="urn:other"><Id>9</Id><Name>other</Name></Item>
+						<Item><Id>3</Id><Name>third</Name></Item>
+					</Items>
+				</ListItemsResponse>
+			</soap:Body>
+		</soap:Envelope>`))
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL, nil)
+	var items []StreamItem
+	handler := func(d *xml.Decoder, start xml.StartElement) error {
+		var item StreamItem
+		if err := d.DecodeElement(&item, &start); err != nil {
+			return err
+		}
+		items = append(items, item)
+		return nil
+	}
+	err := client.CallStreamContext(context.Background(), "ListItems", &Ping{}, nil,
+		xml.Name{Space: "http://example.com/service.xsd", Local: "Item"}, handler, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []StreamItem{{1, "first"}, {2, "second"}, {3, "third"}}, items)
+
+	items = nil
+	err = client.CallStreamContext(context.Background(), "ListItems", &Ping{}, nil, xml.Name{Local: "Item"}, handler, nil)
+	assert.NoError(t, err)
+	assert.Len(t, items, 4)
+
+	stop := errors.New("stop")
+	calls := 0
+	err = client.CallStreamContext(context.Background(), "ListItems", &Ping{}, nil, xml.Name{Local: "Item"},
+		func(d *xml.Decoder, start xml.StartElement) error {
+			calls++
+			return stop
+		}, nil)
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+
+	fault, err := os.ReadFile(filepath.Join("testdata", "fault11.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	faultServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write(fault)
+	}))
+	defer faultServer.Close()
+	err = NewClient(faultServer.URL, nil).CallStreamContext(context.Background(), "ListItems", &Ping{}, nil, xml.Name{Local: "Item"}, handler, nil)
+	assert.EqualError(t, err, "Invalid account")
+}
+
+// pipeTransport answers with the body its writer sends.
+type pipeTransport struct {
+	body *io.PipeReader
+}
+
+func (t *pipeTransport) RoundTrip(ctx context.Context, action string, body []byte, headers map[string]string) (io.ReadCloser, map[string]string, error) {
+	return t.body, map[string]string{"Content-Type": "text/xml"}, nil
+}
+
+func TestClient_CallStreamContextStreams(t *testing.T) {
+	body, writer := io.Pipe()
+	handled := make(chan struct{})
+	go func() {
+		io.WriteString(writer, `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>`+
+			`<ListItemsResponse xmlns="http://example.com/service.xsd"><Items><Item><Id>1</Id><Name>first</Name></Item>`)
+		// the rest follows once the handler got the first item
+		select {
+		case <-handled:
+		case <-time.After(5 * time.Second):
+			t.Error("the handler didn't run before the response was complete")
+		}
+		for i := 2; i <= 1000; i++ {
+			fmt.Fprintf(writer, "<Item><Id>%d</Id><Name>%s</Name></Item>", i, strings.Repeat("x", 100))
+		}
+		io.WriteString(writer, `</Items></ListItemsResponse></soap:Body></soap:Envelope>`)
+		writer.Close()
+	}()
+
+	client := NewClient("jms://queue", withOptions(func(o *Options) { o.Transport = &pipeTransport{body: body} }))
+	count, buffered := 0, 0
+	err := client.CallStreamContext(context.Background(), "ListItems", &Ping{}, nil, xml.Name{Local: "Item"},
+		func(d *xml.Decoder, start xml.StartElement) error {
+			var item StreamItem
+			if err := d.DecodeElement(&item, &start); err != nil {
+				return err
+			}
+			if count++; count == 1 {
+				close(handled)
+			}
+			if tracker, ok := namespaceTrackers.Load(d); ok && tracker.(*namespaceTracker).input.Len() > buffered {
+				buffered = tracker.(*namespaceTracker).input.Len()
+			}
+			return nil
+		}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, count)
+	// the decoder keeps no more than what it read ahead of the item
+	assert.Less(t, buffered, 16*1024)
+}
+
+func TestBuildURL(t *testing.T) {
+	endpoint, err := BuildURL("http://example.com/ws?wsdl&version=1",
+		url.Values{"version": {"2"}, "tenant": {"a&b"}},
+		url.Values{"trace": {"on off"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "http://example.com/ws?tenant=a%26b&trace=on+off&version=2&wsdl=", endpoint)
+
+	endpoint, err = BuildURL("http://example.com/ws?wsdl")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://example.com/ws?wsdl", endpoint)
+
+	_, err = BuildURL("http://example.com/%zz")
+	assert.Error(t, err)
+}
+
+func TestClient_QueryParams(t *testing.T) {
+	var gotQuery url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query()
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL+"/ws?app=billing", withOptions(func(o *Options) {
+		o.QueryParams = url.Values{"tenant": {"acme"}, "version": {"1"}}
+	}))
+
+	client.Call("GetTrade", struct{}{}, nil, struct{}{}, nil)
+	assert.Equal(t, url.Values{"app": {"billing"}, "tenant": {"acme"}, "version": {"1"}}, gotQuery)
+
+	ctx := WithQueryParams(context.Background(), url.Values{"version": {"2"}, "filter": {"a b&c"}})
+	client.CallContext(ctx, "GetTrade", struct{}{}, nil, struct{}{}, nil)
+	assert.Equal(t, url.Values{"app": {"billing"}, "tenant": {"acme"}, "version": {"2"}, "filter": {"a b&c"}}, gotQuery)
+}
+
+type Subscription struct {
+	XMLName xml.Name `xml:"Subscription"`
+
+	Active  XSDBoolean `xml:"Active,attr"`
+	Renewal XSDBoolean `xml:"Renewal"`
+}
+
+func TestXSDBoolean_RoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		value string
+		want  XSDBoolean
+		wire  string
+	}{
+		{"true", true, "1"},
+		{"false", false, "0"},
+		{" 1 ", true, "1"},
+		{"0", false, "0"},
+	} {
+		out := Subscription{}
+		in := fmt.Sprintf(`<Subscription Active="%s"><Renewal>%s</Renewal></Subscription>`, test.value, test.value)
+		if err := xml.Unmarshal([]byte(in), &out); err != nil {
+			t.Fatalf("error decoding %q: %v", test.value, err)
+		}
+		assert.Equal(t, test.want, out.Active)
+		assert.Equal(t, test.want, out.Renewal)
+
+		data, err := xml.Marshal(out)
+		if err != nil {
+			t.Fatalf("error encoding: %v", err)
+		}
+		assert.Equal(t, fmt.Sprintf(`<Subscription Active="%s"><Renewal>%s</Renewal></Subscription>`, test.wire, test.wire), string(data))
+	}
+
+	out := Subscription{}
+	if err := xml.Unmarshal([]byte(`<Subscription><Renewal>yes</Renewal></Subscription>`), &out); err == nil {
+		t.Error("expected an error for an invalid boolean")
+	}
+}
+
+type Registration struct {
+	XMLName xml.Name `xml:"Registration"`
+
+	Code    Token            `xml:"Code,attr"`
+	Name    Token            `xml:"Name"`
+	Address NormalizedString `xml:"Address"`
+	Note    NormalizedString `xml:"Note,attr"`
+}
+
+func TestToken_Whitespace(t *testing.T) {
+	in := "<Registration Code=\"  DE\t 01 \" Note=\"first\tsecond\">" +
+		"<Name>\n   Acme \t  Widgets\r\n  Ltd  </Name>" +
+		"<Address>  Main St\n12\tBerlin </Address>" +
+		"</Registration>"
+	out := Registration{}
+	if err := xml.Unmarshal([]byte(in), &out); err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+	assert.Equal(t, Token("DE 01"), out.Code)
+	assert.Equal(t, Token("Acme Widgets Ltd"), out.Name)
+	assert.Equal(t, NormalizedString("  Main St 12 Berlin "), out.Address)
+	assert.Equal(t, NormalizedString("first second"), out.Note)
+
+	out = Registration{
+		Code:    "  FR  02 ",
+		Name:    " Acme\n\nWidgets ",
+		Address: "Rue\tde la Paix",
+		Note:    "a\r\nb",
+	}
+	data, err := xml.Marshal(out)
+	if err != nil {
+		t.Fatalf("error encoding: %v", err)
+	}
+	assert.Equal(t, `<Registration Code="FR 02" Note="a  b"><Name>Acme Widgets</Name><Address>Rue de la Paix</Address></Registration>`, string(data))
+}
+
+type countingHTTPClient struct {
+	requests int
+}
+
+func (c *countingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	c.requests++
+	return http.DefaultClient.Do(req)
+}
+
+func TestClient_CustomHTTPClientTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><PingResponse xmlns="http://example.com/service.xsd"/></soap:Body></soap:Envelope>`))
+	}))
+	defer ts.Close()
+
+	httpClient := &countingHTTPClient{}
+	opts := withOptions(func(o *Options) {
+		o.Client = httpClient
+		o.ConnectionTimeout = 50 * time.Millisecond
+	})
+	client := NewClient(ts.URL, opts)
+
+	// the custom client takes precedence, without a timeout of its own
+	err := client.Call("GetData", &Ping{}, nil, &PingResponse{}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, httpClient.requests)
+
+	opts.EnforceTimeout = true
+	err = client.Call("GetData", &Ping{}, nil, &PingResponse{}, nil)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "got error %v", err)
+	assert.Equal(t, 2, httpClient.requests)
+}
+
+func TestClient_Accept(t *testing.T) {
+	var gotHeaders http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL, nil)
+	client.Call("GetTrade", struct{}{}, nil, struct{}{}, nil)
+	assert.Equal(t, "text/xml, application/soap+xml", gotHeaders.Get("Accept"))
+
+	client.CallContextWithAttachmentsAndFaultDetail(context.Background(), "GetTrade", struct{}{}, nil, struct{}{}, nil, nil,
+		map[string]string{"accept": "application/soap+xml"})
+	assert.Equal(t, "application/soap+xml", gotHeaders.Get("Accept"))
+
+	client = NewClient(ts.URL, withOptions(func(o *Options) {
+		o.Version = SOAP12
+	}))
+	client.Call("GetTrade", struct{}{}, nil, struct{}{}, nil)
+	assert.Equal(t, "application/soap+xml", gotHeaders.Get("Accept"))
+
+	client = NewClient(ts.URL, withOptions(func(o *Options) {
+		o.Version = SOAP12
+		o.Accept = "application/soap+xml, text/xml"
+	}))
+	client.Call("GetTrade", struct{}{}, nil, struct{}{}, nil)
+	assert.Equal(t, "application/soap+xml, text/xml", gotHeaders.Get("Accept"))
+
+	client = NewClient(ts.URL, withOptions(func(o *Options) {
+		o.Accept = ""
+	}))
+	client.Call("GetTrade", struct{}{}, nil, struct{}{}, nil)
+	assert.Empty(t, gotHeaders.Values("Accept"))
+}
+
+func TestWSSSecurityHeader_Versions(t *testing.T) {
+	tests := []struct {
+		fixture        string
+		version        SOAPVersion
+		mustUnderstand string
+	}{
+		{fixture: "wss11.xml", version: SOAP11, mustUnderstand: "true"},
+		{fixture: "wss12.xml", version: SOAP12, mustUnderstand: "1"},
+	}
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			expected, err := os.ReadFile(filepath.Join("testdata", test.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			header := NewWSSSecurityHeader("admin", "secret", "token-1", test.mustUnderstand)
+			header.Actor = "http://example.com/gateway"
+			header.Version = test.version
+			data, err := xml.MarshalIndent(header, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, strings.TrimSpace(string(expected)), string(data))
+		})
+	}
+}
+
+func TestClient_FaultVersions(t *testing.T) {
+	// servers send SOAP 1.1 faults with status 500, SOAP 1.2 faults with the
+	// status of the code
+	tests := []struct {
+		fixture     string
+		code        string
+		status      int
+		contentType string
+	}{
+		{fixture: "fault11.xml", code: "soap:Client", status: http.StatusInternalServerError, contentType: "text/xml"},
+		{fixture: "fault12.xml", code: "env:Sender", status: http.StatusBadRequest, contentType: "application/soap+xml"},
+	}
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			envelope, err := os.ReadFile(filepath.Join("testdata", test.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", test.contentType)
+				w.WriteHeader(test.status)
+				w.Write(envelope)
+			}))
+			defer ts.Close()
+
+			detail := &Wrapper{Item: &SimpleNode{}, hasData: true}
+			err = NewClient(ts.URL, nil).CallWithFaultDetail("GetData", &Ping{}, nil, &PingResponse{}, detail, nil)
+			var fault *Fault
+			if !assert.True(t, errors.As(err, &fault)) {
+				return
+			}
+			assert.Equal(t, test.code, fault.Code)
+			assert.Equal(t, "Invalid account", fault.String)
+			assert.Equal(t, "http://example.com/ledger", fault.Actor)
+			assert.Equal(t, &SimpleNode{Detail: "account closed", Num: 4.2}, detail.Item)
+			assert.EqualError(t, err, "4.20: account closed")
+
+			err = NewClient(ts.URL, nil).Call("GetData", &Ping{}, nil, &PingResponse{}, nil)
+			assert.EqualError(t, err, "Invalid account")
+		})
+	}
+}
+
+func TestFaultErrors_Map(t *testing.T) {
+	errNotAuthorized := errors.New("not authorized")
+	errQuota := errors.New("quota exceeded")
+	faultErrors := FaultErrors{"NotAuthorized": errNotAuthorized, "soap:Client.Quota": errQuota}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><soap:Fault>` +
+			`<faultcode>tns:NotAuthorized</faultcode><faultstring>token expired</faultstring></soap:Fault></soap:Body></soap:Envelope>`))
+	}))
+	defer ts.Close()
+
+	err := faultErrors.Map(NewClient(ts.URL, nil).Call("GetData", &Ping{}, nil, &PingResponse{}, nil))
+	assert.True(t, errors.Is(err, errNotAuthorized))
+	assert.False(t, errors.Is(err, errQuota))
+	assert.EqualError(t, err, "token expired")
+	var fault *Fault
+	if assert.True(t, errors.As(err, &fault)) {
+		assert.Equal(t, "tns:NotAuthorized", fault.Code)
+	}
+
+	err = faultErrors.Map(&Fault{Code: "soap:Client.Quota"})
+	assert.True(t, errors.Is(err, errQuota))
+
+	unmapped := &Fault{Code: "soap:Server"}
+	assert.Equal(t, unmapped, faultErrors.Map(unmapped))
+	assert.Nil(t, faultErrors.Map(nil))
+
+	// codes of the same local name match in a fixed order
+	errExpired := errors.New("token expired")
+	faultErrors = FaultErrors{"b:Expired": errQuota, "a:Expired": errExpired, "tns:NotAuthorized": errQuota, "NotAuthorized": errNotAuthorized}
+	for i := 0; i < 10; i++ {
+		assert.True(t, errors.Is(faultErrors.Map(&Fault{Code: "tns:NotAuthorized"}), errQuota))
+		assert.True(t, errors.Is(faultErrors.Map(&Fault{Code: "x:NotAuthorized"}), errNotAuthorized))
+		assert.True(t, errors.Is(faultErrors.Map(&Fault{Code: "c:Expired"}), errExpired))
+	}
+}
+
+func TestClient_FaultDetails(t *testing.T) {
+	type quotaDetail struct {
+		Limit int `xml:"Limit"`
+	}
+	type accountDetail struct {
+		Account string `xml:"Account"`
+	}
+	quotaName := xml.Name{Space: "http://example.com/faults", Local: "QuotaExceeded"}
+	accountName := xml.Name{Space: "http://example.com/faults", Local: "AccountClosed"}
+	NamespaceTypes.Register(quotaName, func() interface{} { return new(quotaDetail) })
+	NamespaceTypes.Register(accountName, func() interface{} { return new(accountDetail) })
+
+	var detail string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><soap:Fault>` +
+			`<faultcode>tns:NotAuthorized</faultcode><faultstring>rejected</faultstring><detail xmlns:f="http://example.com/faults">` +
+			detail + `</detail></soap:Fault></soap:Body></soap:Envelope>`))
+	}))
+	defer ts.Close()
+	client := NewClient(ts.URL, nil)
+	details := []xml.Name{quotaName, accountName}
+
+	detail = `<f:QuotaExceeded><f:Limit>10</f:Limit></f:QuotaExceeded>`
+	err := client.CallOperationContextWithFaultDetails(context.Background(), "GetData", "GetData", &Ping{}, nil, &PingResponse{}, details, nil)
+	var detailed *FaultDetailError
+	if assert.True(t, errors.As(err, &detailed)) {
+		assert.Equal(t, quotaName, detailed.Name)
+		assert.Equal(t, &quotaDetail{Limit: 10}, detailed.Detail)
+	}
+	assert.EqualError(t, err, "rejected")
+
+	detail = `<f:Unknown/><f:AccountClosed><f:Account>42</f:Account></f:AccountClosed>`
+	err = client.CallOperationContextWithFaultDetails(context.Background(), "GetData", "GetData", &Ping{}, nil, &PingResponse{}, details, nil)
+	if assert.True(t, errors.As(err, &detailed)) {
+		assert.Equal(t, &accountDetail{Account: "42"}, detailed.Detail)
+	}
+	errNotAuthorized := errors.New("not authorized")
+	mapped := FaultErrors{"NotAuthorized": errNotAuthorized}.Map(err)
+	assert.True(t, errors.Is(mapped, errNotAuthorized))
+	if assert.True(t, errors.As(mapped, &detailed)) {
+		assert.Equal(t, &accountDetail{Account: "42"}, detailed.Detail)
+	}
+
+	// a detail the operation doesn't declare leaves the Fault as is
+	err = client.CallOperationContextWithFaultDetails(context.Background(), "GetData", "GetData", &Ping{}, nil, &PingResponse{}, details[:1], nil)
+	assert.False(t, errors.As(err, &detailed))
+	var fault *Fault
+	assert.True(t, errors.As(err, &fault))
+}
+
+func TestClient_SOAPHeaders(t *testing.T) {
+	var gotBody string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		gotBody = string(body)
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL, nil)
+	client.Headers = &XmlContent{}
+	client.Headers.AddItem(&FaultCodeResponse{Code: QName{Local: "Session"}})
+
+	ctx := WithSOAPHeaders(context.Background(), &PingRequest{Message: "first"})
+	ctx = WithSOAPHeaders(ctx, &PingRequest{Message: "second"})
+	assert.Len(t, SOAPHeaders(ctx), 2)
+
+	client.CallContext(ctx, "GetData", &Ping{}, nil, &PingResponse{}, nil)
+	assert.Contains(t, gotBody, `<soap:Header><FaultCodeResponse xmlns="http://example.com/service.xsd"><Code>Session</Code></FaultCodeResponse>`+
+		`<PingRequest><Message>first</Message></PingRequest><PingRequest><Message>second</Message></PingRequest></soap:Header>`)
+	assert.Len(t, client.Headers.Items, 1)
+
+	client.CallContext(context.Background(), "GetData", &Ping{}, nil, &PingResponse{}, nil)
+	assert.NotContains(t, gotBody, "<PingRequest")
+}
+
+type shipmentItem struct {
+	Parcel *PingReply `xml:"Parcel,omitempty"`
+	Note   string     `xml:"Note,omitempty"`
+}
+
+func (o shipmentItem) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return MarshalChoice(e, o)
+}
+
+func (o *shipmentItem) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return UnmarshalChoice(d, start, o)
+}
+
+type shipment struct {
+	XMLName xml.Name       `xml:"http://example.com/service.xsd Shipment"`
+	Id      string         `xml:"Id"`
+	Choice  []shipmentItem `xml:",any"`
+}
+
+func TestChoice_RoundTrip(t *testing.T) {
+	in := shipment{Id: "1", Choice: []shipmentItem{{Note: "fragile"}, {Parcel: &PingReply{Message: "box"}}, {Note: "urgent"}}}
+	data, err := xml.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `<Shipment xmlns="http://example.com/service.xsd"><Id>1</Id><Note>fragile</Note><Parcel><Message>box</Message></Parcel><Note>urgent</Note></Shipment>`, string(data))
+
+	var out shipment
+	if err = xml.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, in.Choice, out.Choice)
+}
+
+type preferences struct {
+	XMLName  xml.Name         `xml:"Preferences"`
+	Language Nillable[string] `xml:"Language"`
+	PageSize *Nillable[int32] `xml:"PageSize,omitempty"`
+}
+
+func TestNillable(t *testing.T) {
+	defaults := preferences{Language: *NewNillable[string]("en"), PageSize: NewNillable[int32]("20")}
+	data, err := xml.Marshal(defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `<Preferences><Language>en</Language><PageSize>20</PageSize></Preferences>`, string(data))
+
+	data, err = xml.Marshal(preferences{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `<Preferences><Language xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:nil="true"></Language></Preferences>`, string(data))
+
+	var out preferences
+	if err = xml.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, out.Language.Value)
+	assert.Nil(t, out.PageSize)
+
+	out = preferences{Language: *NewNillable[string]("en")}
+	if err = xml.Unmarshal([]byte(`<Preferences><Language>de</Language><PageSize>50</PageSize></Preferences>`), &out); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "de", *out.Language.Value)
+	assert.Equal(t, int32(50), *out.PageSize.Value)
+
+	assert.Nil(t, NewNillable[int32]("many").Value)
+}
+
+// retryingTransport sends each request twice, as a Transport retrying a failed attempt would.
+type retryingTransport struct {
+	recordingTransport
+	keys []string
+}
+
+func (t *retryingTransport) RoundTrip(ctx context.Context, action string, body []byte, headers map[string]string) (io.ReadCloser, map[string]string, error) {
+	for i := 0; i < 2; i++ {
+		t.keys = append(t.keys, headers["Idempotency-Key"])
+	}
+	return t.recordingTransport.RoundTrip(ctx, action, body, headers)
+}
+
+func TestClient_IdempotencyKey(t *testing.T) {
+	transport := &retryingTransport{}
+	client := NewClient("jms://queue", withOptions(func(o *Options) {
+		o.Transport = transport
+		o.IdempotencyKeyHeader = "idempotency-key"
+	}))
+
+	for i := 0; i < 2; i++ {
+		if err := client.Call("GetData", &Ping{}, nil, &PingResponse{}, nil); err != nil {
+			t.Fatalf("couldn't call service: %v", err)
+		}
+	}
+	if assert.Len(t, transport.keys, 4) {
+		assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, transport.keys[0])
+		assert.Equal(t, transport.keys[0], transport.keys[1])
+		assert.NotEqual(t, transport.keys[1], transport.keys[2])
+	}
+
+	ctx := WithIdempotencyKey(context.Background(), "order-42")
+	if err := client.CallContext(ctx, "GetData", &Ping{}, nil, &PingResponse{}, nil); err != nil {
+		t.Fatalf("couldn't call service: %v", err)
+	}
+	assert.Equal(t, "order-42", transport.headers["Idempotency-Key"])
+
+	client = NewClient("jms://queue", withOptions(func(o *Options) { o.Transport = transport }))
+	if err := client.CallContext(ctx, "GetData", &Ping{}, nil, &PingResponse{}, nil); err != nil {
+		t.Fatalf("couldn't call service: %v", err)
+	}
+	assert.NotContains(t, transport.headers, "Idempotency-Key")
+}
+
+// issueCertificate creates a certificate for the template, signed by the parent or self-signed.
+func issueCertificate(t *testing.T, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestOptionsFromPKCS12(t *testing.T) {
+	ca, caKey := issueCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	serverCert, serverKey := issueCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "server"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, caKey)
+	clientCert, clientKey := issueCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "client"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	bundle, err := pkcs12.Modern.Encode(clientKey, clientCert, []*x509.Certificate{ca}, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "client.p12")
+	if err = os.WriteFile(path, bundle, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = OptionsFromPKCS12(path, "wrong")
+	assert.True(t, errors.Is(err, pkcs12.ErrIncorrectPassword))
+
+	tlsConfig, err := OptionsFromPKCS12(path, "secret")
+	if !assert.NoError(t, err) {
+		return
+	}
+	if assert.Len(t, tlsConfig.Certificates, 1) {
+		assert.Equal(t, "client", tlsConfig.Certificates[0].Leaf.Subject.CommonName)
+		assert.Len(t, tlsConfig.Certificates[0].Certificate, 2)
+	}
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+	var peer string
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer = r.TLS.PeerCertificates[0].Subject.CommonName
+		w.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><PingResponse xmlns="http://example.com/service.xsd"><PingResult><Message>Pong</Message></PingResult></PingResponse></soap:Body></soap:Envelope>`))
+	}))
+	ts.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	reply := new(PingResponse)
+	client := NewClient(ts.URL, withOptions(func(o *Options) { o.TlsConfig = tlsConfig }))
+	if assert.NoError(t, client.Call("GetData", &Ping{}, nil, reply, nil)) {
+		assert.Equal(t, "client", peer)
+		assert.Equal(t, "Pong", reply.PingResult.Message)
+	}
+}
+
+func TestOptions_PinCertificate(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><PingResponse xmlns="http://example.com/service.xsd"><PingResult><Message>Pong</Message></PingResult></PingResponse></soap:Body></soap:Envelope>`))
+	}))
+	defer ts.Close()
+	fingerprint := sha256.Sum256(ts.Certificate().Raw)
+	pin := strings.ToUpper(hex.EncodeToString(fingerprint[:]))
+	var pinWithColons []string
+	for i := 0; i < len(pin); i += 2 {
+		pinWithColons = append(pinWithColons, pin[i:i+2])
+	}
+	otherFingerprint := sha256.Sum256([]byte("other"))
+
+	tests := []struct {
+		name string
+		pin  string
+		err  bool
+	}{
+		{name: "hex", pin: hex.EncodeToString(fingerprint[:])},
+		{name: "colons", pin: strings.Join(pinWithColons, ":")},
+		{name: "other certificate", pin: hex.EncodeToString(otherFingerprint[:]), err: true},
+		{name: "invalid pin", pin: "not a fingerprint", err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.PinCertificate(test.pin)
+			reply := new(PingResponse)
+			err := NewClient(ts.URL, &opts).Call("GetData", &Ping{}, nil, reply, nil)
+			if test.err {
+				assert.True(t, errors.Is(err, ErrCertificateNotPinned), "%v", err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, "Pong", reply.PingResult.Message)
+			}
+		})
+	}
+
+	// without a pin the self-signed certificate is rejected
+	err := NewClient(ts.URL, nil).Call("GetData", &Ping{}, nil, new(PingResponse), nil)
+	assert.Error(t, err)
+}
+
+func TestOptions_PinCertificateResumedSession(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><PingResponse xmlns="http://example.com/service.xsd"><PingResult><Message>Pong</Message></PingResult></PingResponse></soap:Body></soap:Envelope>`))
+	}))
+	var mu sync.Mutex
+	var resumed []bool
+	ts.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateActive {
+			mu.Lock()
+			defer mu.Unlock()
+			resumed = append(resumed, conn.(*tls.Conn).ConnectionState().DidResume)
+		}
+	}
+	ts.StartTLS()
+	defer ts.Close()
+	fingerprint := sha256.Sum256(ts.Certificate().Raw)
+	otherFingerprint := sha256.Sum256([]byte("other"))
+
+	opts := DefaultOptions()
+	opts.TlsConfig = &tls.Config{ClientSessionCache: tls.NewLRUClientSessionCache(1)}
+	opts.PinCertificate(hex.EncodeToString(fingerprint[:]))
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, NewClient(ts.URL, &opts).Call("GetData", &Ping{}, nil, new(PingResponse), nil))
+	}
+	mu.Lock()
+	assert.Equal(t, []bool{false, true}, resumed)
+	mu.Unlock()
+
+	// the pin is checked on the session resumed from the cache of the cloned config
+	other := DefaultOptions()
+	other.TlsConfig = opts.TlsConfig
+	other.PinCertificate(hex.EncodeToString(otherFingerprint[:]))
+	err := NewClient(ts.URL, &other).Call("GetData", &Ping{}, nil, new(PingResponse), nil)
+	assert.True(t, errors.Is(err, ErrCertificateNotPinned), "%v", err)
+}
+
+func TestPoller(t *testing.T) {
+	var waits []time.Duration
+	last := time.Now()
+	poller := Poller{Interval: 10 * time.Millisecond, Multiplier: 2, MaxInterval: 30 * time.Millisecond}
+	err := poller.Poll(context.Background(), func(ctx context.Context) (bool, error) {
+		now := time.Now()
+		waits = append(waits, now.Sub(last))
+		last = now
+		return len(waits) == 4, nil
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, waits, 4) {
+		for i, minimum := range []time.Duration{10, 20, 30, 30} {
+			assert.GreaterOrEqual(t, int64(waits[i]), int64(minimum*time.Millisecond), "wait %d", i)
+		}
+	}
+
+	failed := errors.New("status unavailable")
+	err = Poller{Interval: time.Millisecond}.Poll(context.Background(), func(ctx context.Context) (bool, error) {
+		return false, failed
+	})
+	assert.Equal(t, failed, err)
+
+	polls := 0
+	err = Poller{Interval: time.Millisecond, MaxPolls: 3}.Poll(context.Background(), func(ctx context.Context) (bool, error) {
+		polls++
+		return false, nil
+	})
+	assert.Equal(t, ErrPollLimit, err)
+	assert.Equal(t, 3, polls)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = Poller{Interval: 5 * time.Millisecond}.Poll(ctx, func(ctx context.Context) (bool, error) {
+		return false, nil
+	})
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestStructToMap(t *testing.T) {
+	type Base struct {
+		ID int32 `xml:"Id"`
+	}
+	type Line struct {
+		Sku string `xml:"Sku"`
+		Qty *int64 `xml:"Qty,omitempty"`
+	}
+	type Amount struct {
+		Value    float64 `xml:",chardata"`
+		Currency string  `xml:"currency,attr"`
+	}
+	type Order struct {
+		XMLName xml.Name `xml:"http://example.com/orders Order"`
+		*Base
+		Status string      `xml:"status,attr,omitempty"`
+		Lines  []*Line     `xml:"http://example.com/orders Line"`
+		Tags   []string    `xml:"Tag"`
+		Total  Amount      `xml:"Total"`
+		Placed XSDDateTime `xml:"Placed"`
+		Flag   XSDBoolean  `xml:"Flag"`
+		Note   *string     `xml:"Note,omitempty"`
+		Data   []byte      `xml:"Data"`
+	}
+	qty := int64(3)
+	placed := CreateXsdDateTime(time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC), true)
+	order := Order{
+		Base:   &Base{ID: 7},
+		Status: "open",
+		Lines:  []*Line{{Sku: "A-1", Qty: &qty}, {Sku: "B-2"}},
+		Tags:   []string{"rush"},
+		Total:  Amount{Value: 9.5, Currency: "EUR"},
+		Placed: placed,
+		Flag:   true,
+		Data:   []byte("raw"),
+	}
+
+	m, err := StructToMap(&order)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, map[string]interface{}{
+		"Id":     int32(7),
+		"status": "open",
+		"Line": []interface{}{
+			map[string]interface{}{"Sku": "A-1", "Qty": int64(3)},
+			map[string]interface{}{"Sku": "B-2"},
+		},
+		"Tag":    []interface{}{"rush"},
+		"Total":  map[string]interface{}{"Value": 9.5, "currency": "EUR"},
+		"Placed": "2024-05-01T12:30:00Z",
+		"Flag":   XSDBoolean(true),
+		"Data":   []byte("raw"),
+	}, m)
+
+	var back Order
+	if assert.NoError(t, MapToStruct(m, &back)) {
+		assert.Equal(t, order.Base, back.Base)
+		assert.Equal(t, order.Lines, back.Lines)
+		assert.Equal(t, order.Total, back.Total)
+		assert.Equal(t, order.Placed.ToGoTime(), back.Placed.ToGoTime())
+		assert.Equal(t, order, back)
+	}
+
+	// generic input, like JSON decoded, converts to the field types
+	var decoded Order
+	err = MapToStruct(map[string]interface{}{
+		"Id":   float64(8),
+		"Line": []interface{}{map[string]interface{}{"Sku": "C-3", "Qty": float64(2)}},
+		"Flag": false,
+		"Note": "gift",
+	}, &decoded)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int32(8), decoded.ID)
+		assert.Equal(t, "C-3", decoded.Lines[0].Sku)
+		assert.Equal(t, int64(2), *decoded.Lines[0].Qty)
+		assert.Equal(t, "gift", *decoded.Note)
+	}
+
+	assert.EqualError(t, MapToStruct(map[string]interface{}{"Line": []interface{}{map[string]interface{}{"Qty": 1.5}}}, &decoded),
+		"Line: 0: Qty: 1.5 doesn't fit int64")
+	assert.EqualError(t, MapToStruct(map[string]interface{}{"Unknown": 1}, &decoded), "Unknown: no field in soap.Order")
+	assert.Error(t, MapToStruct(map[string]interface{}{"Tag": "rush"}, &decoded))
+	assert.Error(t, MapToStruct(map[string]interface{}{}, decoded))
+}
+
+func TestClient_SetSecurityHeader(t *testing.T) {
+	var body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+		w.Header().Set("Content-Type", "application/soap+xml")
+		w.Write([]byte(`<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope"><env:Body><PingResponse xmlns="http://example.com/service.xsd"><PingResult><Message>pong</Message></PingResult></PingResponse></env:Body></env:Envelope>`))
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL, &Options{Version: SOAP12})
+	assert.NoError(t, client.SetSecurityHeader(NewWSSSecurityHeader("old", "secret", "", "1")))
+	assert.NoError(t, client.SetSecurityHeader(NewWSSSecurityHeader("admin", "secret", "", "1")))
+	assert.NoError(t, client.Call("GetData", &Ping{}, nil, &PingResponse{}, nil))
+	assert.Equal(t, 1, strings.Count(body, "<wsse:UsernameToken"))
+	assert.Contains(t, body, "<wsse:Username xmlns:wsse=\"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd\">admin</wsse:Username>")
+	assert.Contains(t, body, `env:mustUnderstand="true"`)
+
+	policy := SecurityPolicy{Tokens: []string{"UsernameToken"}}
+	assert.True(t, policy.RequiresToken("UsernameToken"))
+	assert.False(t, policy.RequiresToken("X509Token"))
+}
+
+type legItem struct {
+	Leg   string   `xml:"Leg,omitempty"`
+	Scan  []string `xml:"Scan,omitempty"`
+	Items []string `xml:",any"`
+}
+
+func (o legItem) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return MarshalChoice(e, o)
+}
+
+type legItems []legItem
+
+func (o legItems) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return MarshalSequence(e, o)
+}
+
+func (o *legItems) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return UnmarshalSequence(d, start, o)
+}
+
+type route struct {
+	XMLName  xml.Name `xml:"http://example.com/service.xsd Route"`
+	Sequence legItems `xml:",any"`
+}
+
+func TestSequence_RoundTrip(t *testing.T) {
+	data := `<Route xmlns="http://example.com/service.xsd"><Leg>a</Leg><Scan>1</Scan><Scan>2</Scan><Leg>b</Leg><Leg>c</Leg><Scan>3</Scan></Route>`
+	var out route
+	if err := xml.Unmarshal([]byte(data), &out); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, legItems{{Leg: "a", Scan: []string{"1", "2"}}, {Leg: "b"}, {Leg: "c", Scan: []string{"3"}}}, out.Sequence)
+
+	in, err := xml.Marshal(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, data, string(in))
+}
+
+func TestSequence_Wildcard(t *testing.T) {
+	var out route
+	err := xml.Unmarshal([]byte(`<Route xmlns="http://example.com/service.xsd"><Leg>a</Leg><Other>x</Other><Leg>b</Leg></Route>`), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, legItems{{Leg: "a", Items: []string{"x"}}, {Leg: "b"}}, out.Sequence)
+}
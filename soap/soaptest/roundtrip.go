@@ -0,0 +1,211 @@
+// Package soaptest checks generated types in tests, the round trip tests
+// gowsdl generates with -roundtrip-tests use it.
+package soaptest
+
+import (
+	"encoding"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fillDepth limits the nesting of the structs Fill sets, types referencing
+// themselves would be filled endlessly.
+const fillDepth = 4
+
+var (
+	xmlNameType       = reflect.TypeOf(xml.Name{})
+	xmlMarshalerType  = reflect.TypeOf((*xml.Marshaler)(nil)).Elem()
+	attrMarshalerType = reflect.TypeOf((*xml.MarshalerAttr)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// RoundTrip fills the value made by newValue with sample data, marshals it
+// to XML and unmarshals that into another value of newValue. It fails t if
+// either fails or the values differ, which points at inconsistent struct
+// tags. XMLName fields are not compared, nor are fields holding arbitrary
+// XML.
+func RoundTrip(t testing.TB, newValue func() interface{}) {
+	t.Helper()
+	value := newValue()
+	Fill(value)
+	data, err := xml.Marshal(value)
+	if err != nil {
+		t.Fatalf("couldn't marshal %T: %v", value, err)
+	}
+
+	decoded := newValue()
+	if err = xml.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("couldn't unmarshal %T: %v\n%s", value, err, data)
+	}
+	if path := difference(reflect.ValueOf(value), reflect.ValueOf(decoded), "", fillDepth+1); path != "" {
+		t.Errorf("%T differs after a round trip at %s\n%s", value, path, data)
+	}
+}
+
+// Fill sets the fields of the struct pointed to by value to sample data:
+// strings to "sample", numbers to one, booleans to true, slices to a single
+// element. Types marshaling themselves are left as they are, their values
+// may be restricted.
+func Fill(value interface{}) {
+	fill(reflect.ValueOf(value), fillDepth)
+}
+
+func fill(v reflect.Value, depth int) {
+	if v.Type() == xmlNameType || marshalsItself(v.Type()) || (!v.CanSet() && v.Kind() != reflect.Ptr) {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString("sample")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.5)
+	case reflect.Ptr:
+		if v.IsNil() {
+			if depth == 0 || !v.CanSet() || marshalsItself(v.Type().Elem()) {
+				return
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		fill(v.Elem(), depth-1)
+	case reflect.Slice:
+		if depth == 0 {
+			return
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte("sample"))
+			return
+		}
+		item := reflect.New(v.Type().Elem()).Elem()
+		fill(item, depth-1)
+		if item.IsZero() {
+			// an item left alone may not be written at all
+			return
+		}
+		v.Set(reflect.Append(reflect.MakeSlice(v.Type(), 0, 1), item))
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Type().Field(i); compared(field) {
+				fill(v.Field(i), depth)
+			}
+		}
+	}
+}
+
+// difference returns the path of the first difference between a and b,
+// empty if there is none.
+func difference(a, b reflect.Value, path string, depth int) string {
+	if a.Type() == xmlNameType || depth < 0 {
+		return ""
+	}
+	if marshalsItself(a.Type()) {
+		// compare how they are written, their fields may not be exported
+		dataA, errA := xml.Marshal(addressed(a))
+		dataB, errB := xml.Marshal(addressed(b))
+		if string(dataA) != string(dataB) || (errA == nil) != (errB == nil) {
+			return pathOrRoot(path)
+		}
+		return ""
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr:
+		if a.IsNil() && b.IsNil() {
+			return ""
+		}
+		// a missing element decodes like an empty one
+		if a.IsNil() {
+			a = reflect.New(a.Type().Elem())
+		}
+		if b.IsNil() {
+			b = reflect.New(b.Type().Elem())
+		}
+		return difference(a.Elem(), b.Elem(), path, depth-1)
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return pathOrRoot(path) + fmt.Sprintf(" (%d items, got %d)", a.Len(), b.Len())
+		}
+		for i := 0; i < a.Len(); i++ {
+			if diff := difference(a.Index(i), b.Index(i), fmt.Sprintf("%s[%d]", path, i), depth-1); diff != "" {
+				return diff
+			}
+		}
+		return ""
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			field := a.Type().Field(i)
+			if !compared(field) {
+				continue
+			}
+			if diff := difference(a.Field(i), b.Field(i), path+"."+field.Name, depth); diff != "" {
+				return diff
+			}
+		}
+		return ""
+	case reflect.Interface, reflect.Map, reflect.Func, reflect.Chan:
+		return ""
+	}
+
+	if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+		return pathOrRoot(path) + fmt.Sprintf(" (%v, got %v)", a.Interface(), b.Interface())
+	}
+	return ""
+}
+
+// compared reports whether the field takes part in the round trip, which
+// unexported fields, fields left out of the XML and fields holding
+// arbitrary XML don't.
+func compared(field reflect.StructField) bool {
+	if field.PkgPath != "" {
+		return false
+	}
+	tag := field.Tag.Get("xml")
+	if tag == "-" {
+		return false
+	}
+	flags := strings.Split(tag, ",")[1:]
+	for _, flag := range flags {
+		if flag == "any" || flag == "innerxml" || flag == "comment" {
+			return false
+		}
+	}
+	return true
+}
+
+// marshalsItself reports whether values of the type control their XML.
+func marshalsItself(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface {
+		return false
+	}
+	for _, marshaler := range []reflect.Type{xmlMarshalerType, attrMarshalerType, textMarshalerType} {
+		if t.Implements(marshaler) || reflect.PtrTo(t).Implements(marshaler) {
+			return true
+		}
+	}
+	return false
+}
+
+// addressed returns a pointer to v if possible, so methods with pointer
+// receivers are found.
+func addressed(v reflect.Value) interface{} {
+	if v.CanAddr() {
+		return v.Addr().Interface()
+	}
+	return v.Interface()
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "the value"
+	}
+	return strings.TrimPrefix(path, ".")
+}
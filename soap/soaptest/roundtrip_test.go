@@ -0,0 +1,69 @@
+package soaptest
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/hooklift/gowsdl/soap"
+)
+
+type color string
+
+func (c color) MarshalText() ([]byte, error) {
+	return []byte(c), nil
+}
+
+type line struct {
+	Sku      string  `xml:"Sku"`
+	Quantity int32   `xml:"Quantity,attr"`
+	Note     *string `xml:"Note,omitempty"`
+}
+
+type order struct {
+	XMLName xml.Name
+	Lines   []*line             `xml:"Lines>Line"`
+	Color   color               `xml:"Color,omitempty"`
+	Placed  *soap.XSDDateTime   `xml:"Placed,omitempty"`
+	Extra   []string            `xml:",any"`
+	Parent  *order              `xml:"Parent,omitempty"`
+	Skipped map[string]struct{} `xml:"-"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	RoundTrip(t, func() interface{} { return &order{XMLName: xml.Name{Space: "urn:orders", Local: "Order"}} })
+}
+
+func TestFill(t *testing.T) {
+	value := &order{}
+	Fill(value)
+
+	if len(value.Lines) != 1 || value.Lines[0].Sku != "sample" || value.Lines[0].Quantity != 1 || *value.Lines[0].Note != "sample" {
+		t.Errorf("got lines %+v", value.Lines)
+	}
+	if value.Color != "" || value.Placed != nil || value.Extra != nil || value.XMLName.Local != "" {
+		t.Errorf("filled fields marshaling themselves or left out of the round trip: %+v", value)
+	}
+	if value.Parent == nil || value.Parent.Parent == nil {
+		t.Error("didn't fill the nested orders")
+	}
+}
+
+func TestDifference(t *testing.T) {
+	a, b := &order{}, &order{}
+	Fill(a)
+	Fill(b)
+	b.Lines[0].Quantity = 2
+	b.Extra = []string{"ignored"}
+	b.XMLName.Local = "ignored"
+
+	diff := difference(reflect.ValueOf(a), reflect.ValueOf(b), "", fillDepth+1)
+	if diff != "Lines[0].Quantity (1, got 2)" {
+		t.Errorf("got difference %q", diff)
+	}
+
+	b.Lines[0].Quantity = 1
+	if diff = difference(reflect.ValueOf(a), reflect.ValueOf(b), "", fillDepth+1); diff != "" {
+		t.Errorf("got difference %q of equal values", diff)
+	}
+}
@@ -0,0 +1,66 @@
+package soap
+
+import (
+	"context"
+	"encoding/xml"
+)
+
+// ElementHandler handles an element of a streamed response. It must consume
+// the element up to its end, for example with d.DecodeElement(&item, &start)
+// or d.Skip().
+type ElementHandler func(d *xml.Decoder, start xml.StartElement) error
+
+// CallStreamContext performs the call like CallContext, but instead of
+// decoding the response into a value it walks the response element and calls
+// handler for each element named element within it, which then decodes it
+// alone. The response is walked token by token while the Transport delivers
+// it, so the items of a large list response are handled one at a time without
+// the whole response or list in memory. An element without Space matches in
+// any namespace. The call stops with the first error handler returns.
+//
+// MTOM responses and Options.Debug are the exception, they read the
+// envelope completely before it is walked.
+func (s *Client) CallStreamContext(ctx context.Context, soapAction string, request interface{}, responseHeader map[string]interface{},
+	element xml.Name, handler ElementHandler, headers map[string]string) error {
+	return s.call(ctx, soapAction, request, responseHeader, &streamContent{element: element, handler: handler}, nil, nil, headers)
+}
+
+// streamContent is the response content of CallStreamContext, it passes the
+// elements of its name to the handler instead of decoding them.
+type streamContent struct {
+	element xml.Name
+	handler ElementHandler
+}
+
+func (c *streamContent) matches(name xml.Name) bool {
+	return name.Local == c.element.Local && (c.element.Space == "" || name.Space == c.element.Space)
+}
+
+func (c *streamContent) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if c.matches(start.Name) {
+		return c.handler(d, start)
+	}
+
+	depth := 0
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch se := token.(type) {
+		case xml.StartElement:
+			if c.matches(se.Name) {
+				if err = c.handler(d, se); err != nil {
+					return err
+				}
+				continue
+			}
+			depth++
+		case xml.EndElement:
+			if depth == 0 {
+				return nil
+			}
+			depth--
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package soap
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// Token is a string of xsd:token, its whitespace is collapsed when it is
+// read or written: tabs and line breaks become spaces, runs of spaces become
+// one and leading and trailing spaces are removed. Compare Tokens to exact
+// values without trimming them first.
+type Token string
+
+// MarshalXML writes the collapsed value.
+func (t Token) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(collapseWhitespace(string(t)), start)
+}
+
+// UnmarshalXML reads the value, collapsing its whitespace.
+func (t *Token) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var value string
+	if err := d.DecodeElement(&value, &start); err != nil {
+		return err
+	}
+	*t = Token(collapseWhitespace(value))
+	return nil
+}
+
+// MarshalXMLAttr writes the collapsed value as attribute.
+func (t Token) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: name, Value: collapseWhitespace(string(t))}, nil
+}
+
+// UnmarshalXMLAttr reads the attribute value, collapsing its whitespace.
+func (t *Token) UnmarshalXMLAttr(attr xml.Attr) error {
+	*t = Token(collapseWhitespace(attr.Value))
+	return nil
+}
+
+// NormalizedString is a string of xsd:normalizedString, its tabs and line
+// breaks are replaced by spaces when it is read or written.
+type NormalizedString string
+
+// MarshalXML writes the normalized value.
+func (s NormalizedString) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(replaceWhitespace(string(s)), start)
+}
+
+// UnmarshalXML reads the value, replacing its tabs and line breaks.
+func (s *NormalizedString) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var value string
+	if err := d.DecodeElement(&value, &start); err != nil {
+		return err
+	}
+	*s = NormalizedString(replaceWhitespace(value))
+	return nil
+}
+
+// MarshalXMLAttr writes the normalized value as attribute.
+func (s NormalizedString) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: name, Value: replaceWhitespace(string(s))}, nil
+}
+
+// UnmarshalXMLAttr reads the attribute value, replacing its tabs and line
+// breaks.
+func (s *NormalizedString) UnmarshalXMLAttr(attr xml.Attr) error {
+	*s = NormalizedString(replaceWhitespace(attr.Value))
+	return nil
+}
+
+// replaceWhitespace applies the whiteSpace facet replace, XML whitespace
+// characters become spaces.
+func replaceWhitespace(value string) string {
+	return strings.Map(func(r rune) rune {
+		if isXMLSpace(r) {
+			return ' '
+		}
+		return r
+	}, value)
+}
+
+// collapseWhitespace applies the whiteSpace facet collapse, runs of XML
+// whitespace become one space, leading and trailing whitespace is removed.
+func collapseWhitespace(value string) string {
+	return strings.Join(strings.FieldsFunc(value, isXMLSpace), " ")
+}
+
+func isXMLSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
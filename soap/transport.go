@@ -0,0 +1,124 @@
+package soap
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Transport carries encoded SOAP messages to the service, the Client
+// marshals the Envelope and decodes the response around it. Implementations
+// allow services exposed over JMS or in process, the default sends the
+// messages with HTTP POST.
+type Transport interface {
+	// RoundTrip sends the request body with its headers, among them
+	// Content-Type, and returns the response body and headers. The Client
+	// decodes the response while reading respBody and closes it. Content-Type
+	// of the response is used to detect MTOM and MIME multipart messages.
+	RoundTrip(ctx context.Context, action string, body []byte, headers map[string]string) (respBody io.ReadCloser, respHeaders map[string]string, err error)
+}
+
+// httpTransport is the default Transport posting to the url of the Client.
+type httpTransport struct {
+	url  string
+	opts *Options
+}
+
+func (t *httpTransport) RoundTrip(ctx context.Context, action string, body []byte, headers map[string]string) (respBody io.ReadCloser, respHeaders map[string]string, err error) {
+	cancel := context.CancelFunc(func() {})
+	if t.opts.EnforceTimeout && t.opts.ConnectionTimeout > 0 {
+		// the timeout covers reading the response, until the body is closed
+		ctx, cancel = context.WithTimeout(ctx, t.opts.ConnectionTimeout)
+	}
+	defer func() {
+		if err != nil {
+			cancel()
+		}
+	}()
+
+	endpoint := t.url
+	callParams, _ := QueryParams(ctx)
+	if len(t.opts.QueryParams) > 0 || len(callParams) > 0 {
+		if endpoint, err = BuildURL(endpoint, t.opts.QueryParams, callParams); err != nil {
+			return
+		}
+	}
+
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body)); err != nil {
+		return
+	}
+	if t.opts.BasicAuth != nil {
+		req.SetBasicAuth(t.opts.BasicAuth.Login, t.opts.BasicAuth.Password)
+	}
+	if action != "" && t.opts.Version != SOAP12 {
+		// SOAP 1.2 carries the action in the Content-Type
+		req.Header.Set("SOAPAction", action)
+	}
+	if t.opts.ExpectContinueTimeout > 0 {
+		req.Header.Set("Expect", "100-continue")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	req.Close = true
+
+	var client HTTPClient
+	if client, err = t.opts.getOrBuildHttpClient(); err != nil {
+		return
+	}
+
+	if t.opts.Debug {
+		fmt.Printf("\n=== Start: Debug Request ===\n")
+		fmt.Printf("\nrequest: url=%v, header=%v, body=%v\n", req.URL, req.Header, string(body))
+		fmt.Printf("\n=== End: Debug Request===\n")
+	}
+
+	var res *http.Response
+	if res, err = client.Do(req); err != nil {
+		return
+	}
+
+	if res.StatusCode >= 400 {
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		return nil, nil, &HTTPError{
+			StatusCode:   res.StatusCode,
+			ResponseBody: body,
+			ContentType:  res.Header.Get("Content-Type"),
+		}
+	}
+
+	respBody = res.Body
+	if t.opts.Debug {
+		data, readErr := io.ReadAll(res.Body)
+		res.Body.Close()
+		if readErr != nil {
+			return nil, nil, readErr
+		}
+		fmt.Printf("\n=== Start: Debug Response ===\n")
+		fmt.Printf("\nresponse: body=%v, header=%v\n", string(data), res.Header)
+		fmt.Printf("\n=== End: Debug Response===\n")
+		respBody = io.NopCloser(bytes.NewReader(data))
+	}
+
+	respHeaders = map[string]string{}
+	for k := range res.Header {
+		respHeaders[k] = res.Header.Get(k)
+	}
+	return &responseBody{ReadCloser: respBody, cancel: cancel}, respHeaders, nil
+}
+
+// responseBody is the body of a response, closing it ends the timeout of
+// the request.
+type responseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *responseBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
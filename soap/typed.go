@@ -0,0 +1,36 @@
+package soap
+
+import "context"
+
+// CallTyped calls soapAction with the request and returns the response
+// decoded into a new Resp, the typed form of CallContext.
+func CallTyped[Req, Resp any](ctx context.Context, client *Client, soapAction string, request *Req) (*Resp, error) {
+	response := new(Resp)
+	if err := client.call(ctx, soapAction, request, nil, response, nil, nil, nil); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// CallOperationTyped is CallTyped for the named operation, with the response
+// headers and HTTP headers of CallOperationContext. The generated services
+// call it when generated with GenericCalls.
+func CallOperationTyped[Req, Resp any](ctx context.Context, client *Client, operation, soapAction string, request *Req,
+	responseHeader map[string]interface{}, headers map[string]string) (*Resp, error) {
+	response := new(Resp)
+	if err := client.call(WithOperation(ctx, operation), soapAction, request, responseHeader, response, nil, nil, headers); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// CallTypedWithFaultDetail is CallTyped which decodes the detail of a SOAP
+// fault into detail, the typed form of CallContextWithFaultDetail.
+func CallTypedWithFaultDetail[Req, Resp any, Detail FaultError](ctx context.Context, client *Client, soapAction string, request *Req,
+	detail Detail) (*Resp, error) {
+	response := new(Resp)
+	if err := client.call(ctx, soapAction, request, nil, response, detail, nil, nil); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,49 @@
+package soap
+
+import "fmt"
+
+// Validator is implemented by request and response types which check their
+// content against the constraints of the schema.
+type Validator interface {
+	Validate() error
+}
+
+// ValidationError is returned by the Client if Options.Validate is set and
+// a request or response doesn't pass its Validate method.
+type ValidationError struct {
+	// Response tells whether the response failed validation, the request otherwise.
+	Response bool
+	Err      error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Response {
+		return fmt.Sprintf("invalid response: %v", e.Err)
+	}
+	return fmt.Sprintf("invalid request: %v", e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// validate calls the Validate method of content, if it has one.
+func validate(content interface{}, response bool) error {
+	if prefixed, ok := content.(*PrefixedContent); ok {
+		content = prefixed.Content
+	}
+	if prefixed, ok := content.(*StrictPrefixedContent); ok {
+		content = prefixed.Content
+	}
+	if element, ok := content.(*Element); ok {
+		content = element.Content
+	}
+	validator, ok := content.(Validator)
+	if !ok {
+		return nil
+	}
+	if err := validator.Validate(); err != nil {
+		return &ValidationError{Response: response, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package soap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// XSDBoolean is a bool written as 1 or 0, the numeric lexical form of
+// xsd:boolean, for servers which reject true and false.
+type XSDBoolean bool
+
+// MarshalXML writes the value as 1 or 0.
+func (b XSDBoolean) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(b.String(), start)
+}
+
+// UnmarshalXML reads any lexical form of xsd:boolean, true, false, 1 or 0.
+func (b *XSDBoolean) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var value string
+	if err := d.DecodeElement(&value, &start); err != nil {
+		return err
+	}
+	return b.decode(value)
+}
+
+func (b XSDBoolean) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: name, Value: b.String()}, nil
+}
+
+func (b *XSDBoolean) UnmarshalXMLAttr(attr xml.Attr) error {
+	return b.decode(attr.Value)
+}
+
+// String returns the numeric lexical form of the value.
+func (b XSDBoolean) String() string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func (b *XSDBoolean) decode(value string) error {
+	switch strings.TrimSpace(value) {
+	case "true", "1":
+		*b = true
+	case "false", "0":
+		*b = false
+	default:
+		return fmt.Errorf("invalid xsd:boolean value %q", value)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// filterOperations drops the operations not named by Operations from the port
+// types and bindings, along with the messages and schema declarations only
+// they reference.
+func (g *GoWSDL) filterOperations() error {
+	if len(g.Operations) == 0 {
+		return nil
+	}
+
+	wanted := map[string]bool{}
+	for _, name := range g.Operations {
+		wanted[strings.TrimSpace(name)] = true
+	}
+
+	found := map[string]bool{}
+	messages := map[xml.Name]bool{}
+	keepOperations := func(operations []*WSDLOperation) (ret []*WSDLOperation) {
+		for _, operation := range operations {
+			if !wanted[operation.Name] {
+				continue
+			}
+			found[operation.Name] = true
+			ret = append(ret, operation)
+
+			for _, message := range []string{operation.Input.Message, operation.Output.Message} {
+				messages[g.wsdl.qname(message)] = true
+			}
+			for _, fault := range operation.Faults {
+				messages[g.wsdl.qname(fault.Message)] = true
+			}
+			for _, header := range append(operation.Input.SOAPHeader, operation.Output.SOAPHeader...) {
+				messages[g.wsdl.qname(header.Message)] = true
+			}
+		}
+		return
+	}
+	for _, portType := range g.wsdl.PortTypes {
+		portType.Operations = keepOperations(portType.Operations)
+	}
+	for _, binding := range g.wsdl.Binding {
+		binding.Operations = keepOperations(binding.Operations)
+	}
+
+	var unknown []string
+	for name := range wanted {
+		if !found[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("operations not found in the WSDL: %s", strings.Join(unknown, ", "))
+	}
+
+	var roots []xml.Name
+	keepMessages := func(doc *WSDL) {
+		var kept []*WSDLMessage
+		collector := &referenceCollector{schema: &XSDSchema{TargetNamespace: doc.TargetNamespace, Xmlns: doc.Xmlns}}
+		for _, message := range doc.Messages {
+			if !messages[xml.Name{Space: doc.TargetNamespace, Local: message.Name}] {
+				continue
+			}
+			kept = append(kept, message)
+			for _, part := range message.Parts {
+				collector.add(part.Element)
+				collector.add(part.Type)
+			}
+		}
+		doc.Messages = kept
+		roots = append(roots, collector.refs...)
+	}
+	keepMessages(g.wsdl)
+	for _, imported := range g.wsdl.Imported {
+		keepMessages(imported)
+	}
+
+	g.wsdl.pruneSchemas(roots)
+	return nil
+}
+
+// qname resolves a prefixed name with the namespaces of the WSDL, names
+// without prefix belong to its target namespace.
+func (w *WSDL) qname(name string) xml.Name {
+	if i := strings.Index(name, ":"); i >= 0 {
+		return xml.Name{Space: w.Xmlns[name[:i]], Local: name[i+1:]}
+	}
+	return xml.Name{Space: w.TargetNamespace, Local: name}
+}
+
+// findMessage returns the message of the qualified reference and the document declaring it.
+func (w *WSDL) findMessage(ref string) (*WSDLMessage, *WSDL) {
+	name := w.qname(ref)
+	for _, doc := range append([]*WSDL{w}, w.Imported...) {
+		if doc.TargetNamespace != name.Space {
+			continue
+		}
+		for _, message := range doc.Messages {
+			if message.Name == name.Local {
+				return message, doc
+			}
+		}
+	}
+	return nil, nil
+}
+
+// pruneSchemas removes the global declarations of the schemas which are not
+// transitively referenced from roots.
+func (w *WSDL) pruneSchemas(roots []xml.Name) {
+	reachable := map[interface{}]bool{}
+	visited := map[xml.Name]bool{}
+	for len(roots) > 0 {
+		ref := roots[0]
+		roots = roots[1:]
+		if visited[ref] {
+			continue
+		}
+		visited[ref] = true
+
+		for _, schema := range w.Types.Schemas {
+			if schema.TargetNamespace != ref.Space {
+				continue
+			}
+			collector := &referenceCollector{schema: schema}
+			for _, ct := range schema.ComplexTypes {
+				if ct.Name == ref.Local {
+					reachable[ct] = true
+					collector.complexType(ct)
+				}
+			}
+			for _, st := range schema.SimpleType {
+				if st.Name == ref.Local {
+					reachable[st] = true
+					collector.simpleType(st)
+				}
+			}
+			for _, elm := range schema.Elements {
+				if elm.Name == ref.Local {
+					reachable[elm] = true
+					collector.element(elm)
+				}
+			}
+			for _, attr := range schema.Attributes {
+				if attr.Name == ref.Local {
+					reachable[attr] = true
+					collector.attributes([]*XSDAttribute{attr})
+				}
+			}
+			roots = append(roots, collector.refs...)
+		}
+	}
+
+	for _, schema := range w.Types.Schemas {
+		complexTypes := schema.ComplexTypes[:0]
+		for _, ct := range schema.ComplexTypes {
+			if reachable[ct] {
+				complexTypes = append(complexTypes, ct)
+			}
+		}
+		schema.ComplexTypes = complexTypes
+
+		simpleTypes := schema.SimpleType[:0]
+		for _, st := range schema.SimpleType {
+			if reachable[st] {
+				simpleTypes = append(simpleTypes, st)
+			}
+		}
+		schema.SimpleType = simpleTypes
+
+		elements := schema.Elements[:0]
+		for _, elm := range schema.Elements {
+			if reachable[elm] {
+				elements = append(elements, elm)
+			}
+		}
+		schema.Elements = elements
+
+		attributes := schema.Attributes[:0]
+		for _, attr := range schema.Attributes {
+			if reachable[attr] {
+				attributes = append(attributes, attr)
+			}
+		}
+		schema.Attributes = attributes
+	}
+}
@@ -0,0 +1,53 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// structTagKeys returns the keys of the struct tags in their order.
+func (g *GoWSDL) structTagKeys() []string {
+	if len(g.StructTags) == 0 {
+		return []string{"xml", "json"}
+	}
+	return g.StructTags
+}
+
+// checkStructTags validates StructTags.
+func (g *GoWSDL) checkStructTags() error {
+	seen := map[string]bool{}
+	for _, key := range g.structTagKeys() {
+		if key == "" || strings.ContainsAny(key, " \":`") || strings.IndexFunc(key, func(r rune) bool { return r < ' ' || r == 0x7f }) >= 0 {
+			return fmt.Errorf("invalid struct tag key %q", key)
+		}
+		if seen[key] {
+			return fmt.Errorf("struct tag key %q is listed twice", key)
+		}
+		seen[key] = true
+	}
+	if !seen["xml"] {
+		return fmt.Errorf("struct tags %q lack the xml tag", g.StructTags)
+	}
+	return nil
+}
+
+// structTag returns the struct tag of a field, xmlValue is the value of the
+// xml tag and name with omitempty the value of the other tags.
+func (g *GoWSDL) structTag(xmlValue string, name string, omitempty bool) string {
+	if omitempty && !g.NoOmitEmpty {
+		name += ",omitempty"
+	}
+	tags := make([]string, 0, len(g.structTagKeys()))
+	for _, key := range g.structTagKeys() {
+		value := name
+		if key == "xml" {
+			value = xmlValue
+		}
+		tags = append(tags, key+":\""+value+"\"")
+	}
+	return "`" + strings.Join(tags, " ") + "`"
+}
@@ -0,0 +1,36 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"example.com/app/ws/example.com/orders"
+	"github.com/hooklift/gowsdl/soap"
+)
+
+func TestOrderSoapPlaceOrder(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.Header().Set("Content-Type", "text/xml")
+		io.WriteString(w, `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>`+
+			`<PlaceOrderResponse xmlns="http://example.com/orders"><OrderId>42</OrderId><Confirmed>true</Confirmed></PlaceOrderResponse></soap:Body></soap:Envelope>`)
+	}))
+	defer server.Close()
+
+	var service orders.OrderSoap = NewOrderSoap(soap.NewClient(server.URL, nil))
+	response, err := service.PlaceOrder(orders.NewPlaceOrder().WithCustomer(orders.NewCustomer().WithName("Ada")), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.OrderId != "42" || !response.Confirmed {
+		t.Errorf("got %+v", response)
+	}
+	if !strings.Contains(body, `<PlaceOrder xmlns="http://example.com/orders"><Customer xmlns="http://example.com/orders"><Name>Ada</Name></Customer></PlaceOrder>`) {
+		t.Errorf("got request %s", body)
+	}
+}
@@ -0,0 +1,38 @@
+package search
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestDefaultSearchOptions(t *testing.T) {
+	options := DefaultSearchOptions()
+	if options.Format != "json" || options.PageSize != 50 || options.Timeout != 2.5 || options.Priority != PriorityNormal {
+		t.Errorf("got %+v", options)
+	}
+	if options.Fallback == nil || *options.Fallback != PriorityLow {
+		t.Errorf("got fallback %v", options.Fallback)
+	}
+	// strings keep the white space of their default, tokens collapse it
+	if options.Separator != " | " || options.Sort != "by date" {
+		t.Errorf("got separator %q and sort %q", options.Separator, options.Sort)
+	}
+
+	data, err := xml.Marshal(NewSearch().WithOptions(*options.WithPageSize(10)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`version="1.0" maxResults="100"`,
+		`<Format>json</Format><PageSize>10</PageSize><Timeout>2.5</Timeout><Exact>false</Exact><Priority>Normal</Priority><Fallback>Low</Fallback><Locale>en</Locale>`,
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("%s doesn't contain %s", data, expected)
+		}
+	}
+
+	if search := DefaultSearch(); !search.Trace || search.Offset != 0 {
+		t.Errorf("got %+v", search)
+	}
+}
@@ -0,0 +1,39 @@
+package quotes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewQuoteSoap12ForEnvironment(t *testing.T) {
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		w.Header().Set("Content-Type", "application/soap+xml")
+		w.Write([]byte(`<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body>` +
+			`<GetQuote12Response xmlns="http://example.com/quotes"><Price>1.5</Price></GetQuote12Response></soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+	QuotesEndpoints[QuotesEnvironmentPreProd] = server.URL
+
+	service, err := NewQuoteSoap12ForEnvironment(QuotesEnvironmentPreProd, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := service.GetQuote12(NewGetQuote12().WithSymbol("GO"), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Price != 1.5 {
+		t.Errorf("got %+v", response)
+	}
+	if !strings.HasPrefix(contentType, "application/soap+xml") {
+		t.Errorf("sent the content type %q", contentType)
+	}
+
+	if _, err = NewQuoteSoapForEnvironment(QuotesEnvironment("staging"), nil); err == nil {
+		t.Error("expected an error for an environment without endpoint")
+	}
+}
@@ -0,0 +1,38 @@
+package orders
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hooklift/gowsdl/soap"
+)
+
+func TestPlaceOrderFaultDetail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(w, `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><soap:Fault>`+
+			`<faultcode>soap:Client</faultcode><faultstring>out of stock</faultstring><detail>`+
+			`<OutOfStock xmlns="http://example.com/orders"><Sku>A-1</Sku><Available>3</Available></OutOfStock>`+
+			`</detail></soap:Fault></soap:Body></soap:Envelope>`)
+	}))
+	defer server.Close()
+
+	service := NewOrdersSoap(soap.NewClient(server.URL, nil))
+	_, err := service.PlaceOrder(NewPlaceOrder().WithSku("A-1").WithQuantity(5), nil, nil)
+	var detailed *soap.FaultDetailError
+	if !errors.As(err, &detailed) {
+		t.Fatalf("got %#v", err)
+	}
+	detail, ok := detailed.Detail.(*OutOfStock)
+	if !ok || detail.Sku != "A-1" || detail.Available != 3 {
+		t.Errorf("got detail %#v", detailed.Detail)
+	}
+	var fault *soap.Fault
+	if !errors.As(err, &fault) || fault.String != "out of stock" {
+		t.Errorf("got fault %#v", fault)
+	}
+}
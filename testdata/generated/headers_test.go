@@ -0,0 +1,38 @@
+package ledger
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hooklift/gowsdl/soap"
+)
+
+func TestWithAuthTokenHeader(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.Header().Set("Content-Type", "text/xml")
+		io.WriteString(w, `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>`+
+			`<PostEntryResponse xmlns="http://example.com/ledger"><EntryId>1</EntryId></PostEntryResponse></soap:Body></soap:Envelope>`)
+	}))
+	defer server.Close()
+
+	// struct literals leave the XMLName empty
+	service := NewLedgerSoap(soap.NewClient(server.URL, nil))
+	if err := service.WithAuthTokenHeader(&AuthToken{Token: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.WithAuthTokenHeader(&AuthToken{Token: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.PostEntry(&PostEntry{Account: "cash"}, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(body, "<AuthToken") != 1 || !strings.Contains(body, `<AuthToken xmlns="http://example.com/ledger"><Token>b</Token></AuthToken>`) {
+		t.Errorf("got request %s", body)
+	}
+}
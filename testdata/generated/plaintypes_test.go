@@ -0,0 +1,51 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"example.com/app/ws/example.com/orders"
+	"github.com/hooklift/gowsdl/soap"
+)
+
+func TestOrderSoapPlainTypes(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.Header().Set("Content-Type", "text/xml")
+		io.WriteString(w, `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>`+
+			`<PlaceOrderResponse xmlns="http://example.com/orders"><OrderId>42</OrderId><Confirmed>true</Confirmed><Express>1</Express></PlaceOrderResponse></soap:Body></soap:Envelope>`)
+	}))
+	defer server.Close()
+
+	service := NewOrderSoap(soap.NewClient(server.URL, nil))
+	response, err := service.PlaceOrder(orders.NewPlaceOrder().WithCustomer(orders.NewCustomer().WithName("Ada")), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.OrderId != "42" || !response.Confirmed || response.Express != orders.Express(true) {
+		t.Errorf("got %+v", response)
+	}
+	// the client names the request element the plain type has no XMLName for
+	if !strings.Contains(body, `<PlaceOrder xmlns="http://example.com/orders"><Customer><Name>Ada</Name></Customer></PlaceOrder>`) {
+		t.Errorf("got request %s", body)
+	}
+}
+
+func TestOrderServerPlainTypes(t *testing.T) {
+	server := NewOrderServer()
+	server.SetOperationFault(OrderOperationCancelOrder, &Fault{Code: "Soap:Client", String: "unknown order"})
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	_, err := NewOrderSoap(soap.NewClient(ts.URL, nil)).CancelOrder(orders.NewCancelOrder().WithOrderId("42"), nil, nil)
+	var fault *soap.Fault
+	if !errors.As(err, &fault) || fault.String != "unknown order" {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package accounts
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hooklift/gowsdl/soap"
+)
+
+func TestNewAccountsSoapWithUsernameToken(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.Header().Set("Content-Type", "text/xml")
+		io.WriteString(w, `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>`+
+			`<TransferResponse xmlns="http://example.com/accounts"><Status>done</Status></TransferResponse></soap:Body></soap:Envelope>`)
+	}))
+	defer server.Close()
+
+	service, err := NewAccountsSoapWithUsernameToken(soap.NewClient(server.URL, nil), "alice", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := service.Transfer(NewTransfer().WithAmount(10), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Status != "done" {
+		t.Errorf("got %+v", response)
+	}
+	for _, expected := range []string{">alice</wsse:Username>", ">secret</wsse:Password>", "<Amount>10</Amount>"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("request %s doesn't contain %s", body, expected)
+		}
+	}
+	if !AccountsSoapSecurityPolicy.HttpsToken || DigestSoapSecurityPolicy.HttpsToken {
+		t.Errorf("got policies %+v and %+v", AccountsSoapSecurityPolicy, DigestSoapSecurityPolicy)
+	}
+}
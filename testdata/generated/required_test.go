@@ -0,0 +1,29 @@
+package orders
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+func TestNewOrderRequired(t *testing.T) {
+	signature := Signature("signed")
+	request := NewPlaceOrderRequired(*NewOrderRequired(7, "retail", []Line{*NewLineRequired("A-1", 2)}, &signature, "web").WithNote("gift wrap"))
+	data, err := xml.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<PlaceOrder xmlns="http://example.com/orders"><Order xmlns="http://example.com/orders" channel="web"><Id>7</Id><Kind>retail</Kind><Note>gift wrap</Note>` +
+		`<Line xmlns="http://example.com/orders"><Sku>A-1</Sku><Quantity>2</Quantity></Line><Gift>false</Gift><Signature>signed</Signature><Delivery></Delivery></Order></PlaceOrder>`
+	if string(data) != expected {
+		t.Errorf("got %s", data)
+	}
+
+	decoded := new(PlaceOrder)
+	if err = xml.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, request) {
+		t.Errorf("decoded %+v, want %+v", decoded, request)
+	}
+}
@@ -0,0 +1,53 @@
+package wire
+
+import (
+	"encoding/xml"
+	"testing"
+
+	pointers "example.com/app/ws/pointers/example.com/orders"
+	values "example.com/app/ws/values/example.com/orders"
+)
+
+// RequiredValues changes the Go types of required references and bases, not
+// the XML the requests are sent as.
+func TestRequiredValuesWire(t *testing.T) {
+	withPointers := pointers.NewPlaceOrder().
+		WithLinesAppend(*pointers.NewOrderLineAs("Lines").WithItem(pointers.NewItem().WithSku("A-1").WithCurrency("EUR").WithGift(true)).WithQuantity(2)).
+		WithCustomer(pointers.NewCustomer().WithName("Ada")).
+		WithCoupon(pointers.NewCoupon().WithCode("SPRING"))
+	withValues := values.NewPlaceOrder().
+		WithLinesAppend(*values.NewOrderLineAs("Lines").WithItem(*values.NewItem().WithSku("A-1").WithCurrency("EUR").WithGift(true)).WithQuantity(2)).
+		WithCustomer(*values.NewCustomer().WithName("Ada")).
+		WithCoupon(values.NewCoupon().WithCode("SPRING"))
+
+	pointersXML, err := xml.Marshal(withPointers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valuesXML, err := xml.Marshal(withValues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(pointersXML) != string(valuesXML) {
+		t.Fatalf("got different XML\npointers: %s\nvalues:   %s", pointersXML, valuesXML)
+	}
+
+	// each decodes what the other sends
+	var decodedValues values.PlaceOrder
+	if err = xml.Unmarshal(pointersXML, &decodedValues); err != nil {
+		t.Fatal(err)
+	}
+	var decodedPointers pointers.PlaceOrder
+	if err = xml.Unmarshal(valuesXML, &decodedPointers); err != nil {
+		t.Fatal(err)
+	}
+	for name, decoded := range map[string]interface{}{"values": &decodedValues, "pointers": &decodedPointers} {
+		data, err := xml.Marshal(decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != string(pointersXML) {
+			t.Errorf("decoded %s marshal to\n%s\nwanted\n%s", name, data, pointersXML)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package shipping
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestRouteSequence(t *testing.T) {
+	data := `<Route xmlns="http://example.com/shipping"><Stop>Oslo</Stop><Eta>2026-01-02T10:00:00Z</Eta><Stop>Bergen</Stop><Stop>Trondheim</Stop></Route>`
+	var route Route
+	if err := xml.Unmarshal([]byte(data), &route); err != nil {
+		t.Fatal(err)
+	}
+	if len(route.Sequence) != 3 || route.Sequence[0].Stop != "Oslo" || route.Sequence[0].Eta == nil ||
+		route.Sequence[1].Stop != "Bergen" || route.Sequence[1].Eta != nil || route.Sequence[2].Stop != "Trondheim" {
+		t.Fatalf("got sequence %+v", route.Sequence)
+	}
+	out, err := xml.Marshal(route)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != data {
+		t.Errorf("got %s", out)
+	}
+}
+
+func TestConsignmentSequence(t *testing.T) {
+	data := `<Consignment xmlns="http://example.com/shipping"><Weight>2.5</Weight>` +
+		`<Leg>A</Leg><Carrier>X</Carrier><Scan>1</Scan><Scan>2</Scan><Leg>B</Leg><Courier>Y</Courier></Consignment>`
+	var consignment Consignment
+	if err := xml.Unmarshal([]byte(data), &consignment); err != nil {
+		t.Fatal(err)
+	}
+	items := consignment.Sequence
+	if len(items) != 2 || items[0].Leg != "A" || items[0].Carrier != "X" || len(items[0].Scan) != 2 ||
+		items[1].Leg != "B" || items[1].Courier != "Y" || items[1].Scan != nil {
+		t.Fatalf("got sequence %+v", items)
+	}
+	out, err := xml.Marshal(consignment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != data {
+		t.Errorf("got %s", out)
+	}
+}
+
+func TestBundleChoiceWildcard(t *testing.T) {
+	data := `<Bundle xmlns="http://example.com/shipping"><Note>a</Note><Extra xmlns="urn:other">x</Extra><Note>b</Note></Bundle>`
+	var bundle Bundle
+	if err := xml.Unmarshal([]byte(data), &bundle); err != nil {
+		t.Fatal(err)
+	}
+	items := bundle.Choice
+	if len(items) != 3 || items[0].Note != "a" || len(items[1].Items) != 1 || items[1].Items[0] != "x" || items[2].Note != "b" {
+		t.Errorf("got choice %+v", items)
+	}
+}
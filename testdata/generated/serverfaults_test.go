@@ -0,0 +1,48 @@
+package orders
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hooklift/gowsdl/soap"
+)
+
+func TestOrderServerFaults(t *testing.T) {
+	t.Parallel()
+	server := NewOrderServer()
+	server.SetOperationFault(OrderOperationPlaceOrder, &Fault{Code: "Soap:Client", String: "out of stock"})
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	service := NewOrderSoap(soap.NewClient(ts.URL, nil))
+	_, err := service.PlaceOrder(NewPlaceOrder(), nil, nil)
+	var fault *soap.Fault
+	if !errors.As(err, &fault) {
+		t.Fatalf("got %v", err)
+	}
+	if fault.Code != "Soap:Client" || fault.String != "out of stock" {
+		t.Errorf("got fault %+v", fault)
+	}
+
+	// without a fault set, the handler answers
+	server.SetOperationFault(OrderOperationPlaceOrder, nil)
+	_, err = service.PlaceOrder(NewPlaceOrder(), nil, nil)
+	if !errors.As(err, &fault) || fault.String != WSDLUndefinedError.Error() {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestOrderServerFaultsPerServer(t *testing.T) {
+	t.Parallel()
+	faulty := NewOrderServer()
+	faulty.SetOperationFault(OrderOperationCancelOrder, &Fault{Code: "Soap:Server", String: "unavailable"})
+	ts := httptest.NewServer(NewOrderServer())
+	defer ts.Close()
+
+	_, err := NewOrderSoap(soap.NewClient(ts.URL, nil)).CancelOrder(NewCancelOrder(), nil, nil)
+	var fault *soap.Fault
+	if !errors.As(err, &fault) || fault.String != WSDLUndefinedError.Error() {
+		t.Errorf("got %v", err)
+	}
+}
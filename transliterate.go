@@ -0,0 +1,74 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"strings"
+	"text/template"
+)
+
+// transliterations maps accented and other non-ASCII Latin letters to their
+// ASCII spelling.
+var transliterations = map[rune]string{
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Å': "A", 'Ā': "A", 'Ă': "A", 'Ą': "A",
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'Ä': "Ae", 'ä': "ae", 'Æ': "Ae", 'æ': "ae",
+	'Ç': "C", 'Ć': "C", 'Č': "C", 'ç': "c", 'ć': "c", 'č': "c",
+	'Ď': "D", 'Đ': "D", 'Ð': "D", 'ď': "d", 'đ': "d", 'ð': "d",
+	'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ē': "E", 'Ę': "E", 'Ě': "E",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ę': "e", 'ě': "e",
+	'Ğ': "G", 'ğ': "g",
+	'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I", 'Ī': "I", 'İ': "I",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'ı': "i",
+	'Ł': "L", 'ł': "l",
+	'Ñ': "N", 'Ń': "N", 'Ň': "N", 'ñ': "n", 'ń': "n", 'ň': "n",
+	'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ø': "O", 'Ō': "O", 'Ő': "O",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ø': "o", 'ō': "o", 'ő': "o",
+	'Ö': "Oe", 'ö': "oe", 'Œ': "Oe", 'œ': "oe",
+	'Ř': "R", 'ř': "r",
+	'Ś': "S", 'Š': "S", 'Ş': "S", 'ś': "s", 'š': "s", 'ş': "s", 'ß': "ss",
+	'Ť': "T", 'Ţ': "T", 'ť': "t", 'ţ': "t", 'Þ': "Th", 'þ': "th",
+	'Ù': "U", 'Ú': "U", 'Û': "U", 'Ū': "U", 'Ů': "U", 'Ű': "U",
+	'ù': "u", 'ú': "u", 'û': "u", 'ū': "u", 'ů': "u", 'ű': "u",
+	'Ü': "Ue", 'ü': "ue",
+	'Ý': "Y", 'Ÿ': "Y", 'ý': "y", 'ÿ': "y",
+	'Ź': "Z", 'Ż': "Z", 'Ž': "Z", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// transliterate replaces the letters of value found in transliterations with
+// their ASCII spelling.
+func transliterate(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if ascii, ok := transliterations[r]; ok {
+			b.WriteString(ascii)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// identifier returns the name an identifier is built from, transliterated
+// if TransliterateNames is set.
+func (g *GoWSDL) identifier(name string) string {
+	if g.TransliterateNames {
+		return transliterate(name)
+	}
+	return name
+}
+
+// identifierFuncs makes the template functions building identifiers from XML
+// names transliterate them if TransliterateNames is set.
+func (g *GoWSDL) identifierFuncs(funcMap template.FuncMap) {
+	if !g.TransliterateNames {
+		return
+	}
+	for _, name := range []string{"normalize", "replaceReservedWords", "replaceAttrReservedWords", "makePublic", "makeFieldPublic"} {
+		if fn, ok := funcMap[name].(func(string) string); ok {
+			funcMap[name] = func(value string) string { return fn(transliterate(value)) }
+		}
+	}
+}
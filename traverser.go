@@ -5,20 +5,38 @@ import (
 	"strings"
 )
 
+// SchemaVisitor is called for the types, elements and attributes of a
+// schema, including the anonymous ones nested in them. Nested declarations
+// are visited before the declaration containing them. NsTypeResolver
+// registers the Go types this way, GoWSDL.Visitors can collect metadata or
+// drive custom generation.
+type SchemaVisitor interface {
+	OnComplexType(item *XSDComplexType)
+	OnSimpleType(item *XSDSimpleType)
+	OnElement(item *XSDElement)
+	OnAttribute(item *XSDAttribute)
+}
+
 type traverser struct {
-	c        *XSDSchema
-	all      []*XSDSchema
-	resolver *NsTypeResolver
+	c       *XSDSchema
+	all     []*XSDSchema
+	visitor SchemaVisitor
 }
 
-func newTraverser(c *XSDSchema, all []*XSDSchema, resolver *NsTypeResolver) *traverser {
+func newTraverser(c *XSDSchema, all []*XSDSchema, visitor SchemaVisitor) *traverser {
 	return &traverser{
-		c:        c,
-		all:      all,
-		resolver: resolver,
+		c:       c,
+		all:     all,
+		visitor: visitor,
 	}
 }
 
+// WalkSchema calls the visitor for the declarations of the schema, all are
+// the schemas global attribute references are resolved against.
+func WalkSchema(schema *XSDSchema, all []*XSDSchema, visitor SchemaVisitor) {
+	newTraverser(schema, all, visitor).Traverse()
+}
+
 func (t *traverser) Traverse() {
 	for _, ct := range t.c.ComplexTypes {
 		t.traverseComplexType(ct)
@@ -29,6 +47,7 @@ func (t *traverser) Traverse() {
 	for _, elm := range t.c.Elements {
 		t.traverseElement(elm)
 	}
+	t.traverseAttributes(t.c.Attributes)
 	return
 }
 
@@ -39,32 +58,43 @@ func (t *traverser) traverseElements(ct []*XSDElement) {
 }
 
 func (t *traverser) traverseElement(elm *XSDElement) {
+	if elm.Type != "" && elm.SimpleType != nil {
+		// An inline restriction refines the referenced type.
+		if elm.SimpleType.Restriction.Base == "" {
+			elm.SimpleType.Restriction.Base = elm.Type
+		}
+		elm.Type = ""
+	}
 	if elm.ComplexType != nil {
 		t.traverseComplexType(elm.ComplexType)
 	}
 	if elm.SimpleType != nil {
 		t.traverseSimpleType(elm.SimpleType)
 	}
-	t.resolver.OnElement(elm)
+	t.visitor.OnElement(elm)
 }
 
 func (t *traverser) traverseSimpleType(st *XSDSimpleType) {
-	t.resolver.OnSimpleType(st)
+	t.visitor.OnSimpleType(st)
 }
 
 func (t *traverser) traverseComplexType(ct *XSDComplexType) {
 	t.traverseElements(ct.Sequence)
 	t.traverseElements(ct.Choice)
 	t.traverseElements(ct.SequenceChoice)
+	t.traverseElements(ct.RepeatedChoice)
+	t.traverseElements(ct.RepeatedSequence)
 	t.traverseElements(ct.All)
 	t.traverseAttributes(ct.Attributes)
 	t.traverseAttributes(ct.ComplexContent.Extension.Attributes)
 	t.traverseElements(ct.ComplexContent.Extension.Sequence)
 	t.traverseElements(ct.ComplexContent.Extension.Choice)
 	t.traverseElements(ct.ComplexContent.Extension.SequenceChoice)
+	t.traverseElements(ct.ComplexContent.Extension.RepeatedChoice)
+	t.traverseElements(ct.ComplexContent.Extension.RepeatedSequence)
 	t.traverseAttributes(ct.SimpleContent.Extension.Attributes)
 
-	t.resolver.OnComplexType(ct)
+	t.visitor.OnComplexType(ct)
 }
 
 func (t *traverser) traverseAttributes(attrs []*XSDAttribute) {
@@ -74,10 +104,20 @@ func (t *traverser) traverseAttributes(attrs []*XSDAttribute) {
 }
 
 func (t *traverser) traverseAttribute(attr *XSDAttribute) {
-	if attr.Ref != "" {
+	t.resolveAttribute(attr)
+	t.visitor.OnAttribute(attr)
+}
+
+// resolveAttribute copies the declaration of a referenced attribute and the
+// base of an inline simple type to the attribute.
+func (t *traverser) resolveAttribute(attr *XSDAttribute) {
+	if local, ok := xmlAttribute(attr.Ref); ok {
+		// xml:lang, xml:base, xml:space and xml:id are all strings.
+		attr.Name = local
+	} else if attr.Ref != "" {
 		refAttr := t.getGlobalAttribute(attr.Ref)
 		if refAttr != nil && refAttr.Ref == "" {
-			t.traverseAttribute(refAttr)
+			t.resolveAttribute(refAttr)
 			attr.Name = refAttr.Name
 			attr.Type = refAttr.Type
 			if attr.Fixed == "" {
@@ -12,14 +12,125 @@ import (
 	{{GoImports}}
 )
 
+// {{namespaceConst}} is the target namespace of the types in this file.
+const {{namespaceConst}} = "{{.TargetNamespace}}"
+
+`
+
+var schemaFooter = `
+// {{namespaceConst}}Elements maps the global elements of {{namespaceConst}} to their namespace.
+var {{namespaceConst}}Elements = map[string]string{
+	{{range .}}"{{goString .}}": {{namespaceConst}},
+	{{end}}
+}
 `
 
 var schemaTmpl = `
 {{ $targetNamespace := .TargetNamespace }}
 
+{{define "HexBinaryMarshalers"}}
+	func (hb {{.}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+		return soap.HexBinary(hb).MarshalXML(e, start)
+	}
+
+	func (hb *{{.}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+		return (*soap.HexBinary)(hb).UnmarshalXML(d, start)
+	}
+
+	func (hb {{.}}) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+		return soap.HexBinary(hb).MarshalXMLAttr(name)
+	}
+
+	func (hb *{{.}}) UnmarshalXMLAttr(attr xml.Attr) error {
+		return (*soap.HexBinary)(hb).UnmarshalXMLAttr(attr)
+	}
+{{end}}
+
+{{define "XSDBooleanMarshalers"}}
+	func (xb {{.}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+		return soap.XSDBoolean(xb).MarshalXML(e, start)
+	}
+
+	func (xb *{{.}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+		return (*soap.XSDBoolean)(xb).UnmarshalXML(d, start)
+	}
+
+	func (xb {{.}}) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+		return soap.XSDBoolean(xb).MarshalXMLAttr(name)
+	}
+
+	func (xb *{{.}}) UnmarshalXMLAttr(attr xml.Attr) error {
+		return (*soap.XSDBoolean)(xb).UnmarshalXMLAttr(attr)
+	}
+{{end}}
+
+{{define "WhitespaceMarshalers"}}
+	{{$typeName := .typeName}}
+	{{$base := .base}}
+	func (v {{$typeName}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+		return {{$base}}(v).MarshalXML(e, start)
+	}
+
+	func (v *{{$typeName}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+		return (*{{$base}})(v).UnmarshalXML(d, start)
+	}
+
+	func (v {{$typeName}}) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+		return {{$base}}(v).MarshalXMLAttr(name)
+	}
+
+	func (v *{{$typeName}}) UnmarshalXMLAttr(attr xml.Attr) error {
+		return (*{{$base}})(v).UnmarshalXMLAttr(attr)
+	}
+{{end -}}
+
+{{define "OccursValidator"}}
+	{{ $typeName := get . "typeName" }}
+	{{ with occursChecks (get . "items") }}
+		// Validate checks the number of items of the elements with a bounded
+		// maxOccurs, nested values aren't validated.
+		func (o *{{ $typeName }}) Validate() error {
+			{{- range .}}
+				if n := len(o.{{.Field}}); {{if gt .Min 0}}n < {{.Min}} || {{end}}n > {{.Max}} {
+					return fmt.Errorf("invalid {{.Field}} of {{ $typeName }}: %d items, expected {{.Min}} to {{.Max}}", n)
+				}
+			{{- end}}
+			return nil
+		}
+	{{end}}
+{{end -}}
+
+{{define "EnumTextMarshalers"}}
+	{{$typeName := .typeName}}
+	func (v {{$typeName}}) MarshalText() ([]byte, error) {
+		return []byte(v), nil
+	}
+
+	// UnmarshalText accepts the values of the enumeration only.
+	func (v *{{$typeName}}) UnmarshalText(text []byte) error {
+		switch value := {{$typeName}}(text); value {
+		case {{range $i, $e := .enumeration}}{{if $i}}, {{end}}{{$typeName}}{{normalize $e.Value | makeFieldPublic}}{{end}}:
+			*v = value
+			return nil
+		}
+		return fmt.Errorf("invalid {{$typeName}} %q", text)
+	}
+
+	// UnmarshalXML accepts any value, so responses with values added to the
+	// enumeration later still decode.
+	func (v *{{$typeName}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+		return d.DecodeElement((*string)(v), &start)
+	}
+
+	func (v *{{$typeName}}) UnmarshalXMLAttr(attr xml.Attr) error {
+		*v = {{$typeName}}(attr.Value)
+		return nil
+	}
+{{end}}
+
 {{define "SimpleType"}}
 	{{$typeName := findTypeName .Name }}
-	{{if .Doc}} {{.Doc | comment}} {{end}}
+	{{if .Doc}} {{.Doc | comment}} {{end}}{{deprecated .Doc .AppInfo}}
 	{{if ne .List.ItemType ""}}
 		type {{$typeName}} []{{findTypeNillable .List.ItemType true }}
 	{{else if ne .Union.MemberTypes ""}}
@@ -28,6 +139,13 @@ var schemaTmpl = `
 		type {{$typeName}} string
 	{{else if .Restriction.Base}}
 		type {{$typeName}} {{findTypeNillable .Restriction.Base true }}
+		{{if eq (findTypeNillable .Restriction.Base true) "soap.HexBinary"}}
+			{{template "HexBinaryMarshalers" $typeName}}
+		{{else if eq (findTypeNillable .Restriction.Base true) "soap.XSDBoolean"}}
+			{{template "XSDBooleanMarshalers" $typeName}}
+		{{else if or (eq (findTypeNillable .Restriction.Base true) "soap.Token") (eq (findTypeNillable .Restriction.Base true) "soap.NormalizedString")}}
+			{{template "WhitespaceMarshalers" dict "typeName" $typeName "base" (findTypeNillable .Restriction.Base true)}}
+		{{end}}
     {{else}}
 		type {{$typeName}} interface{}
 	{{end}}
@@ -36,23 +154,66 @@ var schemaTmpl = `
 	const (
 		{{with .Restriction}}
 			{{range .Enumeration}}
-				{{if .Doc}} {{.Doc | comment}} {{end}}
-				{{$typeName}}{{$value := findTypeName .Value}} {{$typeName}} = "{{goString .Value}}" {{end}}
+				{{if .Doc}} {{.Doc | comment}} {{end}}{{deprecated .Doc .AppInfo}}
+				{{$typeName}}{{normalize .Value | makeFieldPublic}} {{$typeName}} = "{{goString .Value}}" {{end}}
 		{{end}}
 	)
+	{{if eq (findTypeNillable .Restriction.Base true) "string"}}
+		{{template "EnumTextMarshalers" dict "typeName" $typeName "enumeration" .Restriction.Enumeration}}
+	{{end}}
 	{{end}}
 {{end}}
 
+{{define "InlineSimpleTypes"}}
+	{{range .}}
+		{{if and .SimpleType (not .Type) .SimpleType.Restriction.Enumeration}}
+			{{$typeName := findInlineType .}}
+			{{if .Doc}} {{.Doc | comment}} {{end}}{{deprecated .Doc .AppInfo}}
+			type {{$typeName}} {{findTypeNillable .SimpleType.Restriction.Base true}}
+
+			const (
+				{{range .SimpleType.Restriction.Enumeration}}
+					{{if .Doc}} {{.Doc | comment}} {{end}}{{deprecated .Doc .AppInfo}}
+					{{$typeName}}{{normalize .Value | makeFieldPublic}} {{$typeName}} = "{{goString .Value}}" {{end}}
+			)
+			{{if eq (findTypeNillable .SimpleType.Restriction.Base true) "string"}}
+				{{template "EnumTextMarshalers" dict "typeName" $typeName "enumeration" .SimpleType.Restriction.Enumeration}}
+			{{end}}
+		{{end}}
+		{{with .ComplexType}}
+			{{template "ComplexTypeInlineSimpleTypes" .}}
+		{{end}}
+	{{end}}
+{{end}}
+
+{{define "ComplexTypeInlineSimpleTypes"}}
+	{{template "InlineSimpleTypes" .Sequence}}
+	{{template "InlineSimpleTypes" .Choice}}
+	{{template "InlineSimpleTypes" .SequenceChoice}}
+	{{template "InlineSimpleTypes" .RepeatedChoice}}
+	{{template "InlineSimpleTypes" .RepeatedSequence}}
+	{{template "InlineSimpleTypes" .All}}
+	{{template "InlineSimpleTypes" .ComplexContent.Extension.Sequence}}
+	{{template "InlineSimpleTypes" .ComplexContent.Extension.Choice}}
+	{{template "InlineSimpleTypes" .ComplexContent.Extension.SequenceChoice}}
+	{{template "InlineSimpleTypes" .ComplexContent.Extension.RepeatedChoice}}
+	{{template "InlineSimpleTypes" .ComplexContent.Extension.RepeatedSequence}}
+{{end}}
+
 {{define "ComplexContent"}}
-	{{$baseType := findTypeNillable .Extension.Base true}}
+	{{ $items := get . "items" }}
+	{{ $typeName := get . "typeName" }}
+	{{$baseType := findBaseType $items.Extension.Base}}
 	{{ if $baseType }}
 		{{$baseType}}
 	{{end}}
 
-	{{template "Elements" .Extension.Sequence}}
-	{{template "Elements" .Extension.Choice}}
-	{{template "Elements" .Extension.SequenceChoice}}
-	{{template "Attributes" .Extension.Attributes}}
+	{{template "Elements" $items.Extension.Sequence}}
+	{{template "Elements" $items.Extension.Choice}}
+	{{template "Elements" $items.Extension.SequenceChoice}}
+	{{template "RepeatedChoice" dict "items" $items.Extension.RepeatedChoice "typeName" $typeName}}
+	{{template "RepeatedSequence" dict "items" $items.Extension.RepeatedSequence "typeName" $typeName}}
+	{{template "Attributes" $items.Extension.Attributes}}
 {{end}}
 
 {{define "ComplexContentWith"}}
@@ -63,7 +224,7 @@ var schemaTmpl = `
 	{{ if $baseType }}
 		{{ $fieldName := $baseType }}
 		{{ $paramName := $fieldName | untitle }}
-		func (o *{{ $typeName }}) With{{ $fieldName }}({{ $paramName }} *{{ $baseType }}) *{{ $typeName }} {
+		func (o *{{ $typeName }}) With{{ $fieldName }}({{ $paramName }} {{ findBaseType $items.Extension.Base }}) *{{ $typeName }} {
 			o.{{ $fieldName }} = {{ $paramName }}
 			return o
 		}
@@ -72,21 +233,25 @@ var schemaTmpl = `
 	{{template "ElementsWith" dict "items" $items.Extension.Sequence "typeName" $typeName }}
 	{{template "ElementsWith" dict "items" $items.Extension.Choice "typeName" $typeName }}
 	{{template "ElementsWith" dict "items" $items.Extension.SequenceChoice "typeName" $typeName }}
+	{{template "RepeatedChoiceWith" dict "items" $items.Extension.RepeatedChoice "typeName" $typeName }}
+	{{template "RepeatedSequenceWith" dict "items" $items.Extension.RepeatedSequence "typeName" $typeName }}
 	{{template "AttributesWith" dict "items" $items.Extension.Attributes "typeName" $typeName}}
 {{end}}
 
 {{define "Attributes"}}
     {{ $targetNamespace := getNS }}
 	{{range .}}
-		{{if .Doc}} {{.Doc | comment}} {{end}}
+		{{if .Doc}} {{.Doc | comment}} {{end}}{{deprecated .Doc .AppInfo}}
 		{{ $type := "string" }}
 		{{ if ne .Type "" }}
 			{{ $type = findTypeNillable .Type false }}
 		{{ end }}
-		{{ if ne $type "bool" }}
-			{{ normalize .Name | makeFieldPublic}} {{$type}} ` + "`" + `xml:"{{.Name}},attr,omitempty" json:"{{.Name}},omitempty"` + "`" + `
+		{{ if eq .Use "required" }}
+			{{ normalize .Name | makeFieldPublic}} {{$type}} {{structTag (print (attributeName .) ",attr") .Name false}}
+		{{ else if and (ne $type "bool") (ne $type "soap.XSDBoolean") }}
+			{{ normalize .Name | makeFieldPublic}} {{$type}} {{structTag (print (attributeName .) ",attr,omitempty") .Name true}}
 		{{ else }}
-			{{ normalize .Name | makeFieldPublic}} {{$type}} ` + "`" + `xml:"{{.Name}},attr" json:"{{.Name}}"` + "`" + `
+			{{ normalize .Name | makeFieldPublic}} {{$type}} {{structTag (print (attributeName .) ",attr") .Name false}}
 		{{ end }}
 	{{end}}
 {{end}}
@@ -109,7 +274,7 @@ var schemaTmpl = `
 {{end}}
 
 {{define "SimpleContent"}}
-	Value {{findTypeNillable .Extension.Base true}} ` + "`xml:\",chardata\" json:\"-,\"`" + `
+	Value {{findTypeNillable .Extension.Base true}} {{structTag ",chardata" "-," false}}
 	{{template "Attributes" .Extension.Attributes}}
 {{end}}
 
@@ -118,54 +283,102 @@ var schemaTmpl = `
 	{{ $typeName := get . "typeName" }}
 	{{ $fieldName := "Value" }}
 	{{ $paramName := $fieldName | untitle }}
-	func (o *{{ $typeName }}) With{{ $fieldName }}({{ $paramName }} {{ findTypeNillable .Extension.Base true }}) *{{ $typeName }} {
+	func (o *{{ $typeName }}) With{{ $fieldName }}({{ $paramName }} {{ findTypeNillable $items.Extension.Base true }}) *{{ $typeName }} {
 		o.{{ $fieldName }} = {{ $paramName }}
 		return o
 	}
 	{{template "AttributesWith" dict "items" $items.Extension.Attributes "typeName" $typeName}}
 {{end}}
 
+{{define "Defaults"}}
+	{{ $typeName := get . "typeName" }}
+	{{ with fieldDefaults (get . "items") }}
+		// Defaults the schema declares for fields of {{$typeName}}.
+		const (
+			{{range .}}{{$typeName}}{{.Field}}Default {{.Type}} = {{.Value}}
+			{{end}}
+		)
+
+		// Default{{$typeName}} returns a new {{$typeName}} with the fields
+		// which have a default set to it.
+		func Default{{$typeName}}() *{{$typeName}} {
+			o := New{{$typeName}}()
+			{{- range .}}
+				{{- if .Pointer}}
+			default{{.Field}} := {{$typeName}}{{.Field}}Default
+			o.{{.Field}} = &default{{.Field}}
+				{{- else}}
+			o.{{.Field}} = {{$typeName}}{{.Field}}Default
+				{{- end}}
+			{{- end}}
+			return o
+		}
+	{{end}}
+{{end}}
+
+{{define "RequiredConstructor"}}
+	{{ $typeName := get . "typeName" }}
+	{{ if requiredConstructors }}
+		{{ with requiredFields (get . "items") }}
+			// New{{$typeName}}Required returns a new {{$typeName}} with the
+			// fields of its required elements set.
+			func New{{$typeName}}Required({{range $i, $field := .}}{{if $i}}, {{end}}{{.Param}} {{.Type}}{{end}}) *{{$typeName}} {
+				o := New{{$typeName}}()
+				{{- range .}}
+				o.{{.Field}} = {{.Param}}
+				{{- end}}
+				return o
+			}
+		{{end}}
+	{{end}}
+{{end}}
+
 {{define "ComplexTypeInline"}}
-	{{findTypeName .Name }} {{if eq .MaxOccurs "unbounded"}}[]{{end}}struct {
+	{{findTypeName .Name }} {{if isRepeated .}}[]{{end}}struct {
 	{{with .ComplexType}}
 		{{if ne .ComplexContent.Extension.Base ""}}
-			{{template "ComplexContent" .ComplexContent}}
+			{{template "ComplexContent" dict "items" .ComplexContent "typeName" ""}}
 		{{else if ne .SimpleContent.Extension.Base ""}}
 			{{template "SimpleContent" .SimpleContent}}
 		{{else}}
 			{{template "Elements" .Sequence}}
 			{{template "Elements" .Choice}}
 			{{template "Elements" .SequenceChoice}}
+			{{template "RepeatedChoice" dict "items" .RepeatedChoice "typeName" ""}}
+			{{template "RepeatedSequence" dict "items" .RepeatedSequence "typeName" ""}}
 			{{template "Elements" .All}}
 			{{template "Attributes" .Attributes}}
 		{{end}}
 	{{end}}
-	} ` + "`" + `xml:"{{.Name}},omitempty" json:"{{.Name}},omitempty"` + "`" + `
+	} {{structTag (print .Name ",omitempty") .Name true}}
 {{end}}
 
 {{define "Elements"}}
 	{{range .}}
 		{{if ne .Ref ""}}
-			{{removeNS .Ref | replaceReservedWords  | makePublic}} {{if eq .MaxOccurs "unbounded"}}[]{{end}}{{findTypeNillable .Ref true }} ` + "`" + `xml:"{{.Ref | removeNS}},omitempty" json:"{{.Ref | removeNS}},omitempty"` + "`" + `
+			{{removeNS .Ref | replaceReservedWords  | makePublic}} {{if isRepeated .}}[]{{end}}{{findRefType . }} {{structTag (print (refElementName .) ",omitempty") (removeNS .Ref) true}}
 		{{else}}
 		{{if not .Type}}
 			{{if .SimpleType}}
-				{{if .Doc}} {{.Doc | comment}} {{end}}
+				{{if .Doc}} {{.Doc | comment}} {{end}}{{deprecated .Doc .AppInfo}}
 				{{if ne .SimpleType.List.ItemType ""}}
-					{{ normalize .Name | makeFieldPublic}} []{{findTypeNillable .SimpleType.List.ItemType true}} ` + "`" + `xml:"{{.Name}},omitempty" json:"{{.Name}},omitempty"` + "`" + `
+					{{ normalize .Name | makeFieldPublic}} []{{findTypeNillable .SimpleType.List.ItemType true}} {{structTag (print .Name ",omitempty") .Name true}}
 				{{else}}
-					{{ normalize .Name | makeFieldPublic}} {{findTypeNillable .SimpleType.Restriction.Base true}} ` + "`" + `xml:"{{.Name}},omitempty" json:"{{.Name}},omitempty"` + "`" + `
+					{{ normalize .Name | makeFieldPublic}} {{findInlineType .}} {{structTag (print .Name ",omitempty") .Name true}}
 				{{end}}
 			{{else}}
 				{{template "ComplexTypeInline" .}}
 			{{end}}
 		{{else}}
-			{{if .Doc}}{{.Doc | comment}} {{end}}
-			{{ $type := findTypeNillable .Type true }}
-			{{ if ne $type "bool" }}
-				{{replaceAttrReservedWords .Name | makeFieldPublic}} {{if eq .MaxOccurs "unbounded"}}[]{{end}}{{$type}} ` + "`" + `xml:"{{.Name}},omitempty" json:"{{.Name}},omitempty"` + "`" + `
+			{{if .Doc}}{{.Doc | comment}} {{end}}{{deprecated .Doc .AppInfo}}
+			{{if and .Nillable .Default}}
+				// Nillable with default {{printf "%q" .Default}}, set by the constructor: a nil Value is sent as xsi:nil{{if eq .MinOccurs "0"}}, a nil field omits the element{{end}}.
+			{{end -}}
+			{{ $type := findElementType . -}}
+			{{ if and (ne $type "bool") (ne $type "soap.XSDBoolean") -}}
+				{{replaceAttrReservedWords .Name | makeFieldPublic}} {{if isRepeated .}}[]{{end}}{{$type}} {{structTag (print .Name ",omitempty") .Name true}}
 			{{ else }}
-				{{replaceAttrReservedWords .Name | makeFieldPublic}} {{if eq .MaxOccurs "unbounded"}}[]{{end}}{{$type}} ` + "`" + `xml:"{{.Name}}" json:"{{.Name}}"` + "`" + `
+				{{replaceAttrReservedWords .Name | makeFieldPublic}} {{if isRepeated .}}[]{{end}}{{$type}} {{structTag .Name .Name false}}
 			{{ end }}{{end}}
 		{{end}}
 	{{end}}
@@ -178,12 +391,12 @@ var schemaTmpl = `
 		{{if ne .Ref ""}}
 			{{ $fieldName := removeNS .Ref | replaceReservedWords | makeFieldPublic }}
 			{{ $paramName := $fieldName | untitle }}
-			func (o *{{ $typeName }}) With{{ $fieldName }}({{ $paramName }} {{if eq .MaxOccurs "unbounded"}}[]{{end}}{{ findTypeNillable .Ref true }}) *{{ $typeName }} {
+			func (o *{{ $typeName }}) With{{ $fieldName }}({{ $paramName }} {{if isRepeated .}}[]{{end}}{{ findRefType . }}) *{{ $typeName }} {
 				o.{{ $fieldName }} = {{ $paramName }}
 				return o
 			}
 
-			{{if eq .MaxOccurs "unbounded"}}func (o *{{ $typeName }}) With{{ $fieldName }}Append({{ $paramName }} {{ findTypeNillable .Ref true }}) *{{ $typeName }} {
+			{{if isRepeated .}}func (o *{{ $typeName }}) With{{ $fieldName }}Append({{ $paramName }} {{ findRefType . }}) *{{ $typeName }} {
 				o.{{ $fieldName }} = append(o.{{ $fieldName }}, {{ $paramName }})
 				return o
 			}{{end}}
@@ -205,7 +418,7 @@ var schemaTmpl = `
 				{{else}}
 					{{ $fieldName := normalize .Name | replaceReservedWords | makeFieldPublic }}
 					{{ $paramName := $fieldName | untitle }}
-					func (o *{{ $typeName }}) With{{ $fieldName }}({{ $paramName }} {{ findTypeNillable .SimpleType.Restriction.Base true }}) *{{ $typeName }} {
+					func (o *{{ $typeName }}) With{{ $fieldName }}({{ $paramName }} {{ findInlineType . }}) *{{ $typeName }} {
 						o.{{ $fieldName }} = {{ $paramName }}
 						return o
 					}
@@ -214,11 +427,11 @@ var schemaTmpl = `
 		{{else}}
 			{{ $fieldName := replaceAttrReservedWords .Name | makeFieldPublic }}
 			{{ $paramName := $fieldName | untitle }}
-			func (o *{{ $typeName }}) With{{ $fieldName  }}({{ $paramName }} {{if eq .MaxOccurs "unbounded"}}[]{{end}}{{ findTypeNillable .Type true }}) *{{ $typeName }} {
+			func (o *{{ $typeName }}) With{{ $fieldName  }}({{ $paramName }} {{if isRepeated .}}[]{{end}}{{ findElementType . }}) *{{ $typeName }} {
 				o.{{ $fieldName }} = {{ $paramName }}
 				return o
 			}
-			{{if eq .MaxOccurs "unbounded"}}func (o *{{ $typeName }}) With{{ $fieldName }}Append({{ $paramName }} {{ findTypeNillable .Type true }}) *{{ $typeName }} {
+			{{if isRepeated .}}func (o *{{ $typeName }}) With{{ $fieldName }}Append({{ $paramName }} {{ findElementType . }}) *{{ $typeName }} {
 				o.{{ $fieldName }} = append(o.{{ $fieldName }}, {{ $paramName }})
 				return o
 			}{{end}}{{end}}
@@ -226,30 +439,205 @@ var schemaTmpl = `
 	{{end}}
 {{end}}
 
+{{define "Getter"}}
+	{{ $typeName := get . "typeName" }}
+	{{ $fieldName := get . "fieldName" }}
+	{{ $fieldType := get . "fieldType" }}
+	{{ $valueType := trimPrefix "*" $fieldType }}
+	{{ if and (hasPrefix "*" $fieldType) (isBasicType $valueType) }}
+		func (o *{{ $typeName }}) Get{{ $fieldName }}() {{ $valueType }} {
+			if o == nil || o.{{ $fieldName }} == nil {
+				var zero {{ $valueType }}
+				return zero
+			}
+			return *o.{{ $fieldName }}
+		}
+	{{ else }}
+		func (o *{{ $typeName }}) Get{{ $fieldName }}() {{ $fieldType }} {
+			if o == nil {
+				var zero {{ $fieldType }}
+				return zero
+			}
+			return o.{{ $fieldName }}
+		}
+	{{ end }}
+{{end}}
+
+{{define "ElementsGet"}}
+	{{ $items := get . "items" }}
+	{{ $typeName := get . "typeName" }}
+	{{ range $items }}
+		{{if ne .Ref ""}}
+			{{ $fieldName := removeNS .Ref | replaceReservedWords | makePublic }}
+			{{ $fieldType := findRefType . }}
+			{{ if isRepeated . }}{{ $fieldType = printf "[]%s" $fieldType }}{{ end }}
+			{{ template "Getter" dict "typeName" $typeName "fieldName" $fieldName "fieldType" $fieldType }}
+		{{else if .Type}}
+			{{ $fieldName := replaceAttrReservedWords .Name | makeFieldPublic }}
+			{{ $fieldType := findElementType . }}
+			{{ if isRepeated . }}{{ $fieldType = printf "[]%s" $fieldType }}{{ end }}
+			{{ template "Getter" dict "typeName" $typeName "fieldName" $fieldName "fieldType" $fieldType }}
+		{{else if .SimpleType}}
+			{{ $fieldName := normalize .Name | makeFieldPublic }}
+			{{ if ne .SimpleType.List.ItemType "" }}
+				{{ template "Getter" dict "typeName" $typeName "fieldName" $fieldName "fieldType" (printf "[]%s" (findTypeNillable .SimpleType.List.ItemType true)) }}
+			{{ else }}
+				{{ template "Getter" dict "typeName" $typeName "fieldName" $fieldName "fieldType" (findInlineType .) }}
+			{{ end }}
+		{{end}}
+	{{end}}
+{{end}}
+
+{{define "RepeatedGet"}}
+	{{ $choice := get . "choice" }}
+	{{ $sequence := get . "sequence" }}
+	{{ $typeName := get . "typeName" }}
+	{{ if plainTypes }}
+		{{ template "ElementsGet" dict "items" (repeatedElements $choice) "typeName" $typeName }}
+		{{ template "ElementsGet" dict "items" (repeatedElements $sequence) "typeName" $typeName }}
+	{{ else }}
+		{{ if $choice }}
+			{{ template "Getter" dict "typeName" $typeName "fieldName" "Choice" "fieldType" (printf "[]%sChoiceItem" $typeName) }}
+		{{ end }}
+		{{ if $sequence }}
+			{{ template "Getter" dict "typeName" $typeName "fieldName" "Sequence" "fieldType" (printf "%sSequenceItems" $typeName) }}
+		{{ end }}
+	{{ end }}
+{{end}}
+
+{{define "Getters"}}
+	{{ $items := get . "items" }}
+	{{ $typeName := get . "typeName" }}
+	{{if ne $items.ComplexContent.Extension.Base ""}}
+		{{ template "ElementsGet" dict "items" $items.ComplexContent.Extension.Sequence "typeName" $typeName }}
+		{{ template "ElementsGet" dict "items" $items.ComplexContent.Extension.Choice "typeName" $typeName }}
+		{{ template "ElementsGet" dict "items" $items.ComplexContent.Extension.SequenceChoice "typeName" $typeName }}
+		{{ template "RepeatedGet" dict "choice" $items.ComplexContent.Extension.RepeatedChoice "sequence" $items.ComplexContent.Extension.RepeatedSequence "typeName" $typeName }}
+	{{else if eq $items.SimpleContent.Extension.Base ""}}
+		{{ template "ElementsGet" dict "items" $items.Sequence "typeName" $typeName }}
+		{{ template "ElementsGet" dict "items" $items.Choice "typeName" $typeName }}
+		{{ template "ElementsGet" dict "items" $items.SequenceChoice "typeName" $typeName }}
+		{{ template "RepeatedGet" dict "choice" $items.RepeatedChoice "sequence" $items.RepeatedSequence "typeName" $typeName }}
+		{{ template "ElementsGet" dict "items" $items.All "typeName" $typeName }}
+	{{end}}
+{{end}}
+
 {{define "Any"}}
-	{{range .}}
-		Items     []string ` + "`" + `xml:",any" json:"items,omitempty"` + "`" + `
+	{{/* one field collects the elements of all wildcards, however often they occur */}}
+	{{if .}}
+		Items     []string {{structTag ",any" "items" true}}
 	{{end}}
 {{end}}
 
 {{define "AnyWith"}}
 	{{ $items := get . "items" }}
 	{{ $typeName := get . "typeName" }}
-	{{ range $items }}
+	{{ if $items }}
 		{{ $fieldName := "Items" }}
 		{{ $paramName := "items" }}
-		func (o *{{ $typeName }}) With{{ $fieldName  }}({{ $paramName }} []sting) *{{ $typeName }} {
+		func (o *{{ $typeName }}) With{{ $fieldName  }}({{ $paramName }} []string) *{{ $typeName }} {
 			o.{{ $fieldName }} = {{ $paramName }}
 			return o
 		}
 
-		func (o *{{ $typeName }}) With{{ $fieldName  }}Append({{ $paramName }} sting) *{{ $typeName }} {
+		func (o *{{ $typeName }}) With{{ $fieldName  }}Append({{ $paramName }} string) *{{ $typeName }} {
 			o.{{ $fieldName }} = append(o.{{ $fieldName }}, {{ $paramName }})
 			return o
 		}
 	{{end}}
 {{end}}
 
+{{define "RepeatedChoice"}}
+	{{if or plainTypes (not (get . "typeName"))}}
+		{{template "Elements" (repeatedElements (get . "items"))}}
+	{{else if get . "items"}}
+		Choice []{{get . "typeName"}}ChoiceItem {{structTag ",any" "Choice" true}}
+	{{end}}
+{{end}}
+
+{{define "RepeatedChoiceWith"}}
+	{{ $items := get . "items" }}
+	{{ $typeName := get . "typeName" }}
+	{{ if plainTypes }}
+		{{ template "ElementsWith" dict "items" (repeatedElements $items) "typeName" $typeName }}
+	{{ else if $items }}
+		// {{ $typeName }}ChoiceItem is an occurrence of the repeating choice of
+		// {{ $typeName }}, one of its elements is set.
+		type {{ $typeName }}ChoiceItem struct {
+			{{template "Elements" $items}}
+			{{template "Any" (get . "any")}}
+		}
+
+		func (o {{ $typeName }}ChoiceItem) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+			return soap.MarshalChoice(e, o)
+		}
+
+		func (o *{{ $typeName }}ChoiceItem) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+			return soap.UnmarshalChoice(d, start, o)
+		}
+
+		func (o *{{ $typeName }}) WithChoice(choice []{{ $typeName }}ChoiceItem) *{{ $typeName }} {
+			o.Choice = choice
+			return o
+		}
+
+		func (o *{{ $typeName }}) WithChoiceAppend(choice {{ $typeName }}ChoiceItem) *{{ $typeName }} {
+			o.Choice = append(o.Choice, choice)
+			return o
+		}
+	{{end}}
+{{end}}
+
+{{define "RepeatedSequence"}}
+	{{if or plainTypes (not (get . "typeName"))}}
+		{{template "Elements" (repeatedElements (get . "items"))}}
+	{{else if get . "items"}}
+		Sequence {{get . "typeName"}}SequenceItems {{structTag ",any" "Sequence" true}}
+	{{end}}
+{{end}}
+
+{{define "RepeatedSequenceWith"}}
+	{{ $items := get . "items" }}
+	{{ $typeName := get . "typeName" }}
+	{{ if plainTypes }}
+		{{ template "ElementsWith" dict "items" (repeatedElements $items) "typeName" $typeName }}
+	{{ else if $items }}
+		// {{ $typeName }}SequenceItem is an occurrence of the repeating sequence
+		// of {{ $typeName }}.
+		type {{ $typeName }}SequenceItem struct {
+			{{template "Elements" $items}}
+			{{template "Any" (get . "any")}}
+		}
+
+		func (o {{ $typeName }}SequenceItem) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+			return soap.MarshalChoice(e, o)
+		}
+
+		// {{ $typeName }}SequenceItems are the occurrences of the repeating
+		// sequence of {{ $typeName }}, which its elements are grouped into
+		// by their order.
+		type {{ $typeName }}SequenceItems []{{ $typeName }}SequenceItem
+
+		func (o {{ $typeName }}SequenceItems) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+			return soap.MarshalSequence(e, o)
+		}
+
+		func (o *{{ $typeName }}SequenceItems) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+			return soap.UnmarshalSequence(d, start, o)
+		}
+
+		func (o *{{ $typeName }}) WithSequence(sequence []{{ $typeName }}SequenceItem) *{{ $typeName }} {
+			o.Sequence = sequence
+			return o
+		}
+
+		func (o *{{ $typeName }}) WithSequenceAppend(item {{ $typeName }}SequenceItem) *{{ $typeName }} {
+			o.Sequence = append(o.Sequence, item)
+			return o
+		}
+	{{end}}
+{{end}}
+
 {{range .SimpleType}}
 	{{template "SimpleType" .}}
 {{end}}
@@ -257,46 +645,72 @@ var schemaTmpl = `
 {{range .Elements}}
 	{{$name := .Name }}
 	{{$typeName := findTypeName .Name }}
+	{{$deprecated := deprecation .Doc .AppInfo }}
+	{{ enterType .Name }}
 	{{if not .Type}}
 		{{/* ComplexTypeLocal */}}
 		{{with .ComplexType}}
+			{{with $deprecated}}// Deprecated: {{.}}{{end}}
 			type {{$typeName}} struct {
-				XMLName xml.Name
+				{{if not plainTypes}}XMLName xml.Name{{end}}
 				{{if ne .ComplexContent.Extension.Base ""}}
-					{{template "ComplexContent" .ComplexContent}}
+					{{template "ComplexContent" dict "items" .ComplexContent "typeName" $typeName}}
 				{{else if ne .SimpleContent.Extension.Base ""}}
 					{{template "SimpleContent" .SimpleContent}}
 				{{else}}
 					{{template "Elements" .Sequence}}
-					{{template "Any" .Any}}
+					{{if or plainTypes (not (or .RepeatedChoice .RepeatedSequence))}}
+						{{template "Any" .Any}}
+					{{end}}
 					{{template "Elements" .Choice}}
 					{{template "Elements" .SequenceChoice}}
+					{{template "RepeatedChoice" dict "items" .RepeatedChoice "any" .Any "typeName" $typeName}}
+					{{template "RepeatedSequence" dict "items" .RepeatedSequence "any" .Any "typeName" $typeName}}
 					{{template "Elements" .All}}
 					{{template "Attributes" .Attributes}}
 				{{end}}
 			}
-			func New{{$typeName}}As(tagName string) *{{$typeName}} {
-				return &{{$typeName}}{XMLName: xml.Name{Space: "{{$targetNamespace}}", Local: tagName}}
-			}
-			func New{{$typeName}}() *{{$typeName}} {
-				return New{{$typeName}}As("{{$name}}")
-			}
+			{{if plainTypes}}
+				func New{{$typeName}}() *{{$typeName}} {
+					return &{{$typeName}}{}
+				}
+			{{else}}
+				func New{{$typeName}}As(tagName string) *{{$typeName}} {
+					return &{{$typeName}}{XMLName: xml.Name{Space: {{namespaceConst}}, Local: tagName}{{range nillableDefaults .}}, {{.Field}}: {{.Value}}{{end}}}
+				}
+				func New{{$typeName}}() *{{$typeName}} {
+					return New{{$typeName}}As("{{$name}}")
+				}
+			{{end}}
+			{{ template "Defaults" dict "items" . "typeName" $typeName }}
+			{{ template "RequiredConstructor" dict "items" . "typeName" $typeName }}
 			{{if ne .ComplexContent.Extension.Base ""}}
 				{{ template "ComplexContentWith" dict "items" .ComplexContent "typeName" $typeName }}
 			{{else if ne .SimpleContent.Extension.Base ""}}
 				{{ template "SimpleContentWith" dict "items" .SimpleContent "typeName" $typeName }}
 			{{else}}
 				{{ template "ElementsWith" dict "items" .Sequence "typeName" $typeName }}
-				{{ template "AnyWith" dict "items" .Any "typeName" $typeName }}
+				{{if or plainTypes (not (or .RepeatedChoice .RepeatedSequence))}}
+					{{ template "AnyWith" dict "items" .Any "typeName" $typeName }}
+				{{end}}
 				{{ template "ElementsWith" dict "items" .Choice "typeName" $typeName }}
 				{{ template "ElementsWith" dict "items" .SequenceChoice "typeName" $typeName }}
+				{{ template "RepeatedChoiceWith" dict "items" .RepeatedChoice "any" .Any "typeName" $typeName }}
+				{{ template "RepeatedSequenceWith" dict "items" .RepeatedSequence "any" .Any "typeName" $typeName }}
 				{{ template "ElementsWith" dict "items" .All "typeName" $typeName }}
 				{{ template "AttributesWith" dict "items" .Attributes "typeName" $typeName }}
 			{{end}}
+			{{if generateGetters}}
+				{{ template "Getters" dict "items" . "typeName" $typeName }}
+			{{end}}
+			{{if validateOccurs}}
+				{{ template "OccursValidator" dict "items" . "typeName" $typeName }}
+			{{end}}
+			{{template "ComplexTypeInlineSimpleTypes" .}}
 		{{end}}
 		{{/* SimpleTypeLocal */}}
 		{{with .SimpleType}}
-			{{if .Doc}} {{.Doc | comment}} {{end}}
+			{{if .Doc}} {{.Doc | comment}} {{end}}{{deprecated .Doc .AppInfo}}
 			{{if ne .List.ItemType ""}}
 				type {{$typeName}} []{{findTypeNillable .List.ItemType true }}
 			{{else if ne .Union.MemberTypes ""}}
@@ -313,16 +727,34 @@ var schemaTmpl = `
 			const (
 				{{with .Restriction}}
 					{{range .Enumeration}}
-						{{if .Doc}} {{.Doc | comment}} {{end}}
-						{{$typeName}}{{$value := findTypeName .Value}} {{$typeName}} = "{{goString .Value}}" {{end}}
+						{{if .Doc}} {{.Doc | comment}} {{end}}{{deprecated .Doc .AppInfo}}
+						{{$typeName}}{{normalize .Value | makeFieldPublic}} {{$typeName}} = "{{goString .Value}}" {{end}}
 				{{end}}
 			)
+			{{if eq (findTypeNillable .Restriction.Base true) "string"}}
+				{{template "EnumTextMarshalers" dict "typeName" $typeName "enumeration" .Restriction.Enumeration}}
+			{{end}}
 			{{end}}
 		{{end}}
 	{{else}}
 		{{$type := findTypeNillable .Type .Nillable}}
 		{{if ne ($typeName) ($type)}}
+			{{with $deprecated}}// Deprecated: {{.}}{{end}}
 			type {{$typeName}} {{$type}}
+			{{if and (not .Nillable) (isStructType .Type)}}
+				{{if plainTypes}}
+					func New{{$typeName}}() *{{$typeName}} {
+						return &{{$typeName}}{}
+					}
+				{{else}}
+					func New{{$typeName}}As(tagName string) *{{$typeName}} {
+						return &{{$typeName}}{XMLName: xml.Name{Space: {{namespaceConst}}, Local: tagName}}
+					}
+					func New{{$typeName}}() *{{$typeName}} {
+						return New{{$typeName}}As("{{$name}}")
+					}
+				{{end}}
+			{{end}}
 			{{if eq ($type) ("soap.XSDDateTime")}}
 				func (xdt {{$typeName}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 					return soap.XSDDateTime(xdt).MarshalXML(e, start)
@@ -347,6 +779,20 @@ var schemaTmpl = `
 				func (xt *{{$typeName}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 					return (*soap.XSDTime)(xt).UnmarshalXML(d, start)
 				}
+			{{else if eq ($type) ("soap.QName")}}
+				func (qn {{$typeName}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+					return soap.QName(qn).MarshalXML(e, start)
+				}
+
+				func (qn *{{$typeName}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+					return (*soap.QName)(qn).UnmarshalXML(d, start)
+				}
+			{{else if eq ($type) ("soap.HexBinary")}}
+				{{template "HexBinaryMarshalers" $typeName}}
+			{{else if eq ($type) ("soap.XSDBoolean")}}
+				{{template "XSDBooleanMarshalers" $typeName}}
+			{{else if or (eq ($type) ("soap.Token")) (eq ($type) ("soap.NormalizedString"))}}
+				{{template "WhitespaceMarshalers" dict "typeName" $typeName "base" $type}}
 			{{end}}
 		{{end}}
 	{{end}}
@@ -356,44 +802,70 @@ var schemaTmpl = `
 	{{/* ComplexTypeGlobal */}}
 	{{$name := .Name }}
 	{{$typeName := findTypeName .Name }}
+	{{ enterType .Name }}
 	{{ log "generate complex type" .Name "as" $typeName }}
 	{{if and (eq (len .SimpleContent.Extension.Attributes) 0) (eq (findTypeNillable .SimpleContent.Extension.Base true) "string") }}
+		{{with deprecation .Doc .AppInfo}}// Deprecated: {{.}}{{end}}
 		type {{$typeName}} string
 	{{else}}
+		{{with deprecation .Doc .AppInfo}}// Deprecated: {{.}}{{end}}
 		type {{$typeName}} struct {
-				XMLName xml.Name
+			{{if not plainTypes}}XMLName xml.Name{{end}}
 			{{if ne .ComplexContent.Extension.Base ""}}
-				{{template "ComplexContent" .ComplexContent}}
+				{{template "ComplexContent" dict "items" .ComplexContent "typeName" $typeName}}
 			{{else if ne .SimpleContent.Extension.Base ""}}
 				{{template "SimpleContent" .SimpleContent}}
 			{{else}}
 				{{template "Elements" .Sequence}}
-				{{template "Any" .Any}}
+				{{if or plainTypes (not (or .RepeatedChoice .RepeatedSequence))}}
+					{{template "Any" .Any}}
+				{{end}}
 				{{template "Elements" .Choice}}
 				{{template "Elements" .SequenceChoice}}
+				{{template "RepeatedChoice" dict "items" .RepeatedChoice "any" .Any "typeName" $typeName}}
+				{{template "RepeatedSequence" dict "items" .RepeatedSequence "any" .Any "typeName" $typeName}}
 				{{template "Elements" .All}}
 				{{template "Attributes" .Attributes}}
 			{{end}}
 		}
 
-		func New{{$typeName}}As(tagName string) *{{$typeName}} {
-			return &{{$typeName}}{XMLName: xml.Name{Space: "{{$targetNamespace}}", Local: tagName}}
-		}
-		func New{{$typeName}}() *{{$typeName}} {
-			return New{{$typeName}}As("{{$name}}")
-		}
+		{{if plainTypes}}
+			func New{{$typeName}}() *{{$typeName}} {
+				return &{{$typeName}}{}
+			}
+		{{else}}
+			func New{{$typeName}}As(tagName string) *{{$typeName}} {
+				return &{{$typeName}}{XMLName: xml.Name{Space: {{namespaceConst}}, Local: tagName}{{range nillableDefaults .}}, {{.Field}}: {{.Value}}{{end}}}
+			}
+			func New{{$typeName}}() *{{$typeName}} {
+				return New{{$typeName}}As("{{$name}}")
+			}
+		{{end}}
+		{{ template "Defaults" dict "items" . "typeName" $typeName }}
+		{{ template "RequiredConstructor" dict "items" . "typeName" $typeName }}
 		{{if ne .ComplexContent.Extension.Base ""}}
 			{{ template "ComplexContentWith" dict "items" .ComplexContent "typeName" $typeName }}
 		{{else if ne .SimpleContent.Extension.Base ""}}
 			{{ template "SimpleContentWith" dict "items" .SimpleContent "typeName" $typeName }}
 		{{else}}
 			{{ template "ElementsWith" dict "items" .Sequence "typeName" $typeName }}
-			{{ template "AnyWith" dict "items" .Any "typeName" $typeName }}
+			{{if or plainTypes (not (or .RepeatedChoice .RepeatedSequence))}}
+				{{ template "AnyWith" dict "items" .Any "typeName" $typeName }}
+			{{end}}
 			{{ template "ElementsWith" dict "items" .Choice "typeName" $typeName }}
 			{{ template "ElementsWith" dict "items" .SequenceChoice "typeName" $typeName }}
+			{{ template "RepeatedChoiceWith" dict "items" .RepeatedChoice "any" .Any "typeName" $typeName }}
+			{{ template "RepeatedSequenceWith" dict "items" .RepeatedSequence "any" .Any "typeName" $typeName }}
 			{{ template "ElementsWith" dict "items" .All "typeName" $typeName }}
 			{{ template "AttributesWith" dict "items" .Attributes "typeName" $typeName }}
 		{{end}}
+		{{if generateGetters}}
+			{{ template "Getters" dict "items" . "typeName" $typeName }}
+		{{end}}
+		{{if validateOccurs}}
+			{{ template "OccursValidator" dict "items" . "typeName" $typeName }}
+		{{end}}
+		{{template "ComplexTypeInlineSimpleTypes" .}}
 	{{end}}
 {{end}}
 
@@ -4,7 +4,12 @@
 
 package gowsdl
 
-import "encoding/xml"
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
 
 const wsdlNamespace = "http://schemas.xmlsoap.org/wsdl/"
 
@@ -20,6 +25,11 @@ type WSDL struct {
 	PortTypes       []*WSDLPortType   `xml:"http://schemas.xmlsoap.org/wsdl/ portType"`
 	Binding         []*WSDLBinding    `xml:"http://schemas.xmlsoap.org/wsdl/ binding"`
 	Service         []*WSDLService    `xml:"http://schemas.xmlsoap.org/wsdl/ service"`
+	// Policies are the WS-Policy declarations a PolicyReference refers to.
+	Policies []*WSPolicy `xml:"-"`
+	// Imported are the documents merged from wsdl:import, their messages
+	// are resolved in their own target namespace.
+	Imported []*WSDL `xml:"-"`
 }
 
 // UnmarshalXML implements interface xml.Unmarshaler for XSDSchema.
@@ -59,19 +69,32 @@ Loop:
 				if err := d.DecodeElement(&w.Doc, &t); err != nil {
 					return err
 				}
+			case t.Name.Local == "Policy" && policyNamespaces[t.Name.Space]:
+				x := new(WSPolicy)
+				if err := d.DecodeElement(x, &t); err != nil {
+					return err
+				}
+				w.Policies = append(w.Policies, x)
 			case t.Name.Space == wsdlNamespace:
 				switch t.Name.Local {
 				case "types":
-					if err := d.DecodeElement(&w.Types, &t); err != nil {
+					// some documents split their schemas over several
+					// types sections, they are merged into one
+					types := new(WSDLType)
+					if err := d.DecodeElement(types, &t); err != nil {
 						return err
 					}
 					for prefix, namespace := range w.Xmlns {
-						for _, s := range w.Types.Schemas {
+						for _, s := range types.Schemas {
 							if _, ok := s.Xmlns[prefix]; !ok {
 								s.Xmlns[prefix] = namespace
 							}
 						}
 					}
+					if w.Types.Doc == "" {
+						w.Types.Doc = types.Doc
+					}
+					w.Types.Schemas = append(w.Types.Schemas, types.Schemas...)
 				case "message":
 					x := new(WSDLMessage)
 					if err := d.DecodeElement(x, &t); err != nil {
@@ -112,6 +135,105 @@ Loop:
 	return nil
 }
 
+// merge adds the types, port types, bindings and services of an imported
+// WSDL, rebasing their qualified references onto the prefixes of w.
+func (w *WSDL) merge(imported *WSDL) {
+	w.Types.Schemas = append(w.Types.Schemas, imported.Types.Schemas...)
+	rebase := func(qname string) string {
+		return w.rebaseQName(qname, imported.Xmlns)
+	}
+
+	for _, portType := range imported.PortTypes {
+		for _, operation := range portType.Operations {
+			operation.Input.Message = rebase(operation.Input.Message)
+			operation.Output.Message = rebase(operation.Output.Message)
+			for _, fault := range operation.Faults {
+				fault.Message = rebase(fault.Message)
+			}
+		}
+	}
+	for _, binding := range imported.Binding {
+		binding.Type = rebase(binding.Type)
+	}
+	for _, service := range imported.Service {
+		for _, port := range service.Ports {
+			port.Binding = rebase(port.Binding)
+		}
+	}
+
+	w.Imported = append(w.Imported, imported)
+	w.PortTypes = append(w.PortTypes, imported.PortTypes...)
+	w.Binding = append(w.Binding, imported.Binding...)
+	w.Service = append(w.Service, imported.Service...)
+}
+
+// selectBodyParts marks the message parts the soap:body parts of the
+// bindings select, which must be called after the imports are merged.
+func (w *WSDL) selectBodyParts() {
+	for _, binding := range w.Binding {
+		for _, portType := range w.PortTypes {
+			if portType.Name != stripns(binding.Type) {
+				continue
+			}
+			for _, operation := range portType.Operations {
+				for _, bindingOperation := range binding.Operations {
+					if bindingOperation.Name != operation.Name {
+						continue
+					}
+					w.selectBodyPart(operation.Input.Message, bindingOperation.Input.SOAPBody)
+					w.selectBodyPart(operation.Output.Message, bindingOperation.Output.SOAPBody)
+				}
+			}
+		}
+	}
+}
+
+func (w *WSDL) selectBodyPart(ref string, body WSDLSOAPBody) {
+	parts := strings.Fields(body.Parts)
+	if len(parts) == 0 {
+		return
+	}
+	if message, _ := w.findMessage(ref); message != nil {
+		message.bodyPart = parts[0]
+	}
+}
+
+// rebaseQName rewrites a prefixed name declared with the xmlns of another
+// document to a prefix of w bound to the same namespace, declaring one if needed.
+func (w *WSDL) rebaseQName(qname string, xmlns map[string]string) string {
+	parts := strings.SplitN(qname, ":", 2)
+	if len(parts) != 2 {
+		return qname
+	}
+	namespace, ok := xmlns[parts[0]]
+	if !ok {
+		return qname
+	}
+
+	prefixes := make([]string, 0, len(w.Xmlns))
+	for prefix := range w.Xmlns {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		if prefix != "" && w.Xmlns[prefix] == namespace {
+			return prefix + ":" + parts[1]
+		}
+	}
+
+	prefix := fmt.Sprintf("imp%d", len(w.Xmlns))
+	for _, exists := w.Xmlns[prefix]; exists; _, exists = w.Xmlns[prefix] {
+		prefix += "_"
+	}
+	w.Xmlns[prefix] = namespace
+	for _, schema := range w.Types.Schemas {
+		if _, ok := schema.Xmlns[prefix]; !ok {
+			schema.Xmlns[prefix] = namespace
+		}
+	}
+	return prefix + ":" + parts[1]
+}
+
 // WSDLImport is the struct used for deserializing WSDL imports.
 type WSDLImport struct {
 	Namespace string `xml:"namespace,attr"`
@@ -136,6 +258,24 @@ type WSDLMessage struct {
 	Name  string      `xml:"name,attr"`
 	Doc   string      `xml:"documentation"`
 	Parts []*WSDLPart `xml:"http://schemas.xmlsoap.org/wsdl/ part"`
+
+	// bodyPart names the part a binding selects for the SOAP body with
+	// soap:body parts, the other parts go to headers.
+	bodyPart string
+}
+
+// BodyPart returns the part sent in the SOAP body, the first part unless a
+// binding selects another one.
+func (m *WSDLMessage) BodyPart() *WSDLPart {
+	for _, part := range m.Parts {
+		if part.Name == m.bodyPart {
+			return part
+		}
+	}
+	if len(m.Parts) == 0 {
+		return nil
+	}
+	return m.Parts[0]
 }
 
 // WSDLFault represents a WSDL fault message.
@@ -153,6 +293,11 @@ type WSDLInput struct {
 	Doc        string            `xml:"documentation"`
 	SOAPBody   WSDLSOAPBody      `xml:"http://schemas.xmlsoap.org/wsdl/soap/ body"`
 	SOAPHeader []*WSDLSOAPHeader `xml:"http://schemas.xmlsoap.org/wsdl/soap/ header"`
+	// SOAP12Body is the body of a SOAP 1.2 binding.
+	SOAP12Body WSDLSOAPBody `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ body"`
+	// Policies and PolicyReferences are the WS-Policy attached to it.
+	Policies         []*WSPolicy `xml:"Policy"`
+	PolicyReferences []*WSPolicy `xml:"PolicyReference"`
 }
 
 // WSDLOutput represents a WSDL output message.
@@ -162,6 +307,11 @@ type WSDLOutput struct {
 	Doc        string            `xml:"documentation"`
 	SOAPBody   WSDLSOAPBody      `xml:"http://schemas.xmlsoap.org/wsdl/soap/ body"`
 	SOAPHeader []*WSDLSOAPHeader `xml:"http://schemas.xmlsoap.org/wsdl/soap/ header"`
+	// SOAP12Body is the body of a SOAP 1.2 binding.
+	SOAP12Body WSDLSOAPBody `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ body"`
+	// Policies and PolicyReferences are the WS-Policy attached to it.
+	Policies         []*WSPolicy `xml:"Policy"`
+	PolicyReferences []*WSPolicy `xml:"PolicyReference"`
 }
 
 // WSDLOperation represents the contract of an entire operation or function.
@@ -172,6 +322,11 @@ type WSDLOperation struct {
 	Output        WSDLOutput        `xml:"output"`
 	Faults        []*WSDLFault      `xml:"fault"`
 	SOAPOperation WSDLSOAPOperation `xml:"http://schemas.xmlsoap.org/wsdl/soap/ operation"`
+	// SOAP12Operation is the operation of a SOAP 1.2 binding.
+	SOAP12Operation WSDLSOAPOperation `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ operation"`
+	// Policies and PolicyReferences are the WS-Policy attached to it.
+	Policies         []*WSPolicy `xml:"Policy"`
+	PolicyReferences []*WSPolicy `xml:"PolicyReference"`
 }
 
 // WSDLPortType defines the service, operations that can be performed and the messages involved.
@@ -182,6 +337,16 @@ type WSDLPortType struct {
 	Operations []*WSDLOperation `xml:"http://schemas.xmlsoap.org/wsdl/ operation"`
 }
 
+// operation returns the operation of the port type with the name, nil if there is none.
+func (p *WSDLPortType) operation(name string) *WSDLOperation {
+	for _, operation := range p.Operations {
+		if operation.Name == name {
+			return operation
+		}
+	}
+	return nil
+}
+
 // WSDLSOAPBinding represents a SOAP binding to the web service.
 type WSDLSOAPBinding struct {
 	Style     string `xml:"style,attr"`
@@ -241,6 +406,39 @@ type WSDLBinding struct {
 	Doc         string           `xml:"documentation"`
 	SOAPBinding WSDLSOAPBinding  `xml:"http://schemas.xmlsoap.org/wsdl/soap/ binding"`
 	Operations  []*WSDLOperation `xml:"http://schemas.xmlsoap.org/wsdl/ operation"`
+	// SOAP12Binding is set for SOAP 1.2 bindings.
+	SOAP12Binding *WSDLSOAPBinding `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ binding"`
+	// Policies and PolicyReferences are the WS-Policy attached to it.
+	Policies         []*WSPolicy `xml:"Policy"`
+	PolicyReferences []*WSPolicy `xml:"PolicyReference"`
+}
+
+// style returns the style of the operation of the binding, its own
+// soap:operation style or else the one of the soap:binding, document if
+// neither declares one. Operations of a SOAP 1.2 binding are styled by their
+// soap12:operation, so one binding can mix document and rpc operations.
+func (b *WSDLBinding) style(operation *WSDLOperation) string {
+	soapBinding, soapOperation := &b.SOAPBinding, &operation.SOAPOperation
+	if b.SOAP12Binding != nil {
+		soapBinding, soapOperation = b.SOAP12Binding, &operation.SOAP12Operation
+	}
+	for _, style := range []string{soapOperation.Style, soapBinding.Style} {
+		if style != "" {
+			return style
+		}
+	}
+	return "document"
+}
+
+// encoded reports whether the input or output body of the operation of a
+// binding declares use encoded.
+func (o *WSDLOperation) encoded() bool {
+	for _, body := range []WSDLSOAPBody{o.Input.SOAPBody, o.Input.SOAP12Body, o.Output.SOAPBody, o.Output.SOAP12Body} {
+		if body.Use == "encoded" {
+			return true
+		}
+	}
+	return false
 }
 
 // WSDLPort defines the properties for a SOAP port only.
@@ -249,6 +447,21 @@ type WSDLPort struct {
 	Binding     string          `xml:"binding,attr"`
 	Doc         string          `xml:"documentation"`
 	SOAPAddress WSDLSOAPAddress `xml:"http://schemas.xmlsoap.org/wsdl/soap/ address"`
+	// SOAP12Address is the address of a port of a SOAP 1.2 binding.
+	SOAP12Address WSDLSOAPAddress `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ address"`
+	// HTTPAddress is the address of a port of an HTTP binding.
+	HTTPAddress WSDLSOAPAddress `xml:"http://schemas.xmlsoap.org/wsdl/http/ address"`
+}
+
+// address returns the location of the soap:address of the port, else of
+// its soap12:address or http:address.
+func (p *WSDLPort) address() string {
+	for _, address := range []WSDLSOAPAddress{p.SOAPAddress, p.SOAP12Address, p.HTTPAddress} {
+		if address.Location != "" {
+			return address.Location
+		}
+	}
+	return ""
 }
 
 // WSDLService defines the list of SOAP services associated with the WSDL.
@@ -6,25 +6,43 @@ package gowsdl
 
 import (
 	"encoding/xml"
+	"strings"
 )
 
 const xmlschema11 = "http://www.w3.org/2001/XMLSchema"
 
+// xmlNamespace is the namespace of the xml prefix, its attributes like
+// xml:lang are built in and its schema is never fetched.
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// xmlAttribute returns the local name of a reference to an attribute of the
+// XML namespace, like xml:lang. The xml prefix can't be bound to another
+// namespace, nor another prefix to the XML namespace.
+func xmlAttribute(ref string) (string, bool) {
+	if strings.HasPrefix(ref, "xml:") {
+		return strings.TrimPrefix(ref, "xml:"), true
+	}
+	return "", false
+}
+
 // XSDSchema represents an entire Schema structure.
 type XSDSchema struct {
-	XMLName            xml.Name          `xml:"schema"`
-	Xmlns              map[string]string `xml:"-"`
-	Tns                string            `xml:"xmlns tns,attr"`
-	Xs                 string            `xml:"xmlns xs,attr"`
-	Version            string            `xml:"version,attr"`
-	TargetNamespace    string            `xml:"targetNamespace,attr"`
-	ElementFormDefault string            `xml:"elementFormDefault,attr"`
-	Includes           []*XSDInclude     `xml:"include"`
-	Imports            []*XSDImport      `xml:"import"`
-	Elements           []*XSDElement     `xml:"element"`
-	Attributes         []*XSDAttribute   `xml:"attribute"`
-	ComplexTypes       []*XSDComplexType `xml:"complexType"` // global
-	SimpleType         []*XSDSimpleType  `xml:"simpleType"`
+	XMLName              xml.Name          `xml:"schema"`
+	Xmlns                map[string]string `xml:"-"`
+	Tns                  string            `xml:"xmlns tns,attr"`
+	Xs                   string            `xml:"xmlns xs,attr"`
+	ID                   string            `xml:"id,attr"`
+	Version              string            `xml:"version,attr"`
+	TargetNamespace      string            `xml:"targetNamespace,attr"`
+	ElementFormDefault   string            `xml:"elementFormDefault,attr"`
+	AttributeFormDefault string            `xml:"attributeFormDefault,attr"`
+	Includes             []*XSDInclude     `xml:"include"`
+	Imports              []*XSDImport      `xml:"import"`
+	Elements             []*XSDElement     `xml:"element"`
+	Attributes           []*XSDAttribute   `xml:"attribute"`
+	ComplexTypes         []*XSDComplexType `xml:"complexType"` // global
+	SimpleType           []*XSDSimpleType  `xml:"simpleType"`
+	AppInfo              []*XSDAppInfo     `xml:"-"`
 }
 
 // UnmarshalXML implements interface xml.Unmarshaler for XSDSchema.
@@ -38,12 +56,16 @@ func (s *XSDSchema) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 		}
 
 		switch attr.Name.Local {
+		case "id":
+			s.ID = attr.Value
 		case "version":
 			s.Version = attr.Value
 		case "targetNamespace":
 			s.TargetNamespace = attr.Value
 		case "elementFormDefault":
 			s.ElementFormDefault = attr.Value
+		case "attributeFormDefault":
+			s.AttributeFormDefault = attr.Value
 		}
 	}
 
@@ -98,6 +120,12 @@ Loop:
 					return err
 				}
 				s.SimpleType = append(s.SimpleType, x)
+			case "annotation":
+				x := new(XSDAnnotation)
+				if err := d.DecodeElement(x, &t); err != nil {
+					return err
+				}
+				s.AppInfo = append(s.AppInfo, x.AppInfo...)
 			default:
 				d.Skip()
 				continue Loop
@@ -110,6 +138,21 @@ Loop:
 	return nil
 }
 
+// XSDAnnotation represents the annotation of a schema component, the
+// documentation is read into the Doc of the component.
+type XSDAnnotation struct {
+	AppInfo []*XSDAppInfo `xml:"appinfo"`
+}
+
+// XSDAppInfo represents an appinfo annotation, information for applications
+// like the code generation hints of vendor tools. Its content isn't part of
+// the schema, SchemaVisitors can read the hints from the AppInfo of the
+// declarations and adjust them before the code is generated.
+type XSDAppInfo struct {
+	Source  string `xml:"source,attr"`
+	Content string `xml:",innerxml"`
+}
+
 // XSDInclude represents schema includes.
 type XSDInclude struct {
 	SchemaLocation string `xml:"schemaLocation,attr"`
@@ -127,7 +170,9 @@ type XSDElement struct {
 	XMLName     xml.Name        `xml:"element"`
 	Name        string          `xml:"name,attr"`
 	Doc         string          `xml:"annotation>documentation"`
+	AppInfo     []*XSDAppInfo   `xml:"annotation>appinfo"`
 	Nillable    bool            `xml:"nillable,attr"`
+	Default     string          `xml:"default,attr"`
 	Type        string          `xml:"type,attr"`
 	Ref         string          `xml:"ref,attr"`
 	MinOccurs   string          `xml:"minOccurs,attr"`
@@ -135,6 +180,16 @@ type XSDElement struct {
 	ComplexType *XSDComplexType `xml:"complexType"` // local
 	SimpleType  *XSDSimpleType  `xml:"simpleType"`
 	Groups      []*XSDGroup     `xml:"group"`
+	// Abstract, Block and Final constrain substitution and derivation, which
+	// the generated types don't restrict, they are ignored for generation.
+	Abstract bool   `xml:"abstract,attr"`
+	Block    string `xml:"block,attr"`
+	Final    string `xml:"final,attr"`
+}
+
+// repeated reports whether the element may occur more than once.
+func (e *XSDElement) repeated() bool {
+	return e.MaxOccurs != "" && e.MaxOccurs != "0" && e.MaxOccurs != "1"
 }
 
 // XSDAny represents a Schema element.
@@ -151,16 +206,161 @@ type XSDAny struct {
 type XSDComplexType struct {
 	XMLName        xml.Name          `xml:"complexType"`
 	Abstract       bool              `xml:"abstract,attr"`
+	Block          string            `xml:"block,attr"` // ignored for generation, like Final
+	Final          string            `xml:"final,attr"`
 	Name           string            `xml:"name,attr"`
+	Doc            string            `xml:"annotation>documentation"`
+	AppInfo        []*XSDAppInfo     `xml:"annotation>appinfo"`
 	Mixed          bool              `xml:"mixed,attr"`
-	Sequence       []*XSDElement     `xml:"sequence>element"`
-	Choice         []*XSDElement     `xml:"choice>element"`
-	SequenceChoice []*XSDElement     `xml:"sequence>choice>element"`
+	Sequence       []*XSDElement     `xml:"-"`
+	Choice         []*XSDElement     `xml:"-"`
+	SequenceChoice []*XSDElement     `xml:"-"`
 	All            []*XSDElement     `xml:"all>element"`
 	ComplexContent XSDComplexContent `xml:"complexContent"`
 	SimpleContent  XSDSimpleContent  `xml:"simpleContent"`
 	Attributes     []*XSDAttribute   `xml:"attribute"`
-	Any            []*XSDAny         `xml:"sequence>any"`
+	Any            []*XSDAny         `xml:"-"`
+	SequenceGroup  *XSDCompositor    `xml:"sequence"`
+	ChoiceGroup    *XSDCompositor    `xml:"choice"`
+	// RepeatedChoice are the elements of choices with a maxOccurs above
+	// one, which repeat as a whole.
+	RepeatedChoice []*XSDElement `xml:"-"`
+	// RepeatedSequence are the elements of a sequence with a maxOccurs above
+	// one, which repeats as a whole.
+	RepeatedSequence []*XSDElement `xml:"-"`
+}
+
+// UnmarshalXML implements interface xml.Unmarshaler for XSDComplexType. It
+// sorts the elements of the sequence and choice groups by their occurrence.
+func (t *XSDComplexType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type complexType XSDComplexType
+	if err := d.DecodeElement((*complexType)(t), &start); err != nil {
+		return err
+	}
+
+	groups := sortCompositors(t.SequenceGroup, t.ChoiceGroup)
+	t.Sequence, t.Choice, t.SequenceChoice = groups.sequence, groups.choice, groups.sequenceChoice
+	t.RepeatedChoice, t.RepeatedSequence, t.Any = groups.repeatedChoice, groups.repeatedSequence, groups.any
+	return nil
+}
+
+// compositorElements are the elements of the sequence and choice groups of a
+// complex type or an extension sorted by their occurrence.
+type compositorElements struct {
+	sequence         []*XSDElement
+	choice           []*XSDElement
+	sequenceChoice   []*XSDElement
+	repeatedChoice   []*XSDElement
+	repeatedSequence []*XSDElement
+	any              []*XSDAny
+}
+
+// sortCompositors sorts the elements of the sequence and choice groups: the
+// elements of a repeating sequence, with those of its choices, go to
+// repeatedSequence in the order of the schema, the elements of a repeating
+// choice go to repeatedChoice.
+func sortCompositors(sequence, choice *XSDCompositor) (ret compositorElements) {
+	if sequence != nil {
+		ret.any = sequence.Any
+		if sequence.repeated() {
+			for _, particle := range sequence.particles {
+				switch particle := particle.(type) {
+				case *XSDElement:
+					ret.repeatedSequence = append(ret.repeatedSequence, particle)
+				case *XSDCompositor:
+					if particle.repeated() {
+						ret.repeatedSequence = append(ret.repeatedSequence, repeatedElements(particle.Elements)...)
+					} else {
+						ret.repeatedSequence = append(ret.repeatedSequence, particle.Elements...)
+					}
+				}
+			}
+		} else {
+			ret.sequence = sequence.Elements
+			for _, choice := range sequence.Choices {
+				if choice.repeated() {
+					ret.repeatedChoice = append(ret.repeatedChoice, choice.Elements...)
+				} else {
+					ret.sequenceChoice = append(ret.sequenceChoice, choice.Elements...)
+				}
+			}
+		}
+	}
+	if choice != nil {
+		if choice.repeated() {
+			ret.repeatedChoice = append(ret.repeatedChoice, choice.Elements...)
+		} else {
+			ret.choice = choice.Elements
+		}
+	}
+	return
+}
+
+// XSDCompositor is a sequence or choice group of a complex type.
+type XSDCompositor struct {
+	MinOccurs string
+	MaxOccurs string
+	Elements  []*XSDElement
+	Choices   []*XSDCompositor
+	Any       []*XSDAny
+	// particles are the elements and choices of the group in the order of
+	// the schema.
+	particles []interface{}
+}
+
+// UnmarshalXML implements interface xml.Unmarshaler for XSDCompositor,
+// keeping the order of the elements and choices of the group.
+func (c *XSDCompositor) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "minOccurs":
+			c.MinOccurs = attr.Value
+		case "maxOccurs":
+			c.MaxOccurs = attr.Value
+		}
+	}
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch token.Name.Local {
+			case "element":
+				element := new(XSDElement)
+				if err = d.DecodeElement(element, &token); err != nil {
+					return err
+				}
+				c.Elements = append(c.Elements, element)
+				c.particles = append(c.particles, element)
+			case "choice":
+				choice := new(XSDCompositor)
+				if err = d.DecodeElement(choice, &token); err != nil {
+					return err
+				}
+				c.Choices = append(c.Choices, choice)
+				c.particles = append(c.particles, choice)
+			case "any":
+				wildcard := new(XSDAny)
+				if err = d.DecodeElement(wildcard, &token); err != nil {
+					return err
+				}
+				c.Any = append(c.Any, wildcard)
+			default:
+				if err = d.Skip(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// repeated reports whether the group may occur more than once.
+func (c *XSDCompositor) repeated() bool {
+	return c.MaxOccurs != "" && c.MaxOccurs != "0" && c.MaxOccurs != "1"
 }
 
 // XSDGroup element is used to define a group of elements to be used in complex type definitions.
@@ -191,9 +391,30 @@ type XSDExtension struct {
 	XMLName        xml.Name        `xml:"extension"`
 	Base           string          `xml:"base,attr"`
 	Attributes     []*XSDAttribute `xml:"attribute"`
-	Sequence       []*XSDElement   `xml:"sequence>element"`
-	Choice         []*XSDElement   `xml:"choice>element"`
-	SequenceChoice []*XSDElement   `xml:"sequence>choice>element"`
+	Sequence       []*XSDElement   `xml:"-"`
+	Choice         []*XSDElement   `xml:"-"`
+	SequenceChoice []*XSDElement   `xml:"-"`
+	SequenceGroup  *XSDCompositor  `xml:"sequence"`
+	ChoiceGroup    *XSDCompositor  `xml:"choice"`
+	// RepeatedChoice and RepeatedSequence are the elements of the repeating
+	// groups, as of XSDComplexType.
+	RepeatedChoice   []*XSDElement `xml:"-"`
+	RepeatedSequence []*XSDElement `xml:"-"`
+}
+
+// UnmarshalXML implements interface xml.Unmarshaler for XSDExtension. It
+// sorts the elements of the sequence and choice groups like XSDComplexType,
+// wildcards of extensions are ignored.
+func (e *XSDExtension) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type extension XSDExtension
+	if err := d.DecodeElement((*extension)(e), &start); err != nil {
+		return err
+	}
+
+	groups := sortCompositors(e.SequenceGroup, e.ChoiceGroup)
+	e.Sequence, e.Choice, e.SequenceChoice = groups.sequence, groups.choice, groups.sequenceChoice
+	e.RepeatedChoice, e.RepeatedSequence = groups.repeatedChoice, groups.repeatedSequence
+	return nil
 }
 
 // XSDAttribute represent an element attribute. Simple elements cannot have
@@ -202,10 +423,13 @@ type XSDExtension struct {
 type XSDAttribute struct {
 	Doc        string         `xml:"annotation>documentation"`
 	Name       string         `xml:"name,attr"`
+	AppInfo    []*XSDAppInfo  `xml:"annotation>appinfo"`
 	Ref        string         `xml:"ref,attr"`
 	Type       string         `xml:"type,attr"`
 	Use        string         `xml:"use,attr"`
+	Default    string         `xml:"default,attr"`
 	Fixed      string         `xml:"fixed,attr"`
+	Form       string         `xml:"form,attr"`
 	SimpleType *XSDSimpleType `xml:"simpleType"`
 }
 
@@ -214,10 +438,11 @@ type XSDAttribute struct {
 type XSDSimpleType struct {
 	Name        string         `xml:"name,attr"`
 	Doc         string         `xml:"annotation>documentation"`
+	AppInfo     []*XSDAppInfo  `xml:"annotation>appinfo"`
 	Restriction XSDRestriction `xml:"restriction"`
 	List        XSDList        `xml:"list"`
 	Union       XSDUnion       `xml:"union"`
-	Final       string         `xml:"final"`
+	Final       string         `xml:"final,attr"`
 }
 
 // XSDList represents a element list
@@ -248,6 +473,7 @@ type XSDRestriction struct {
 
 // XSDRestrictionValue represents a restriction value.
 type XSDRestrictionValue struct {
-	Doc   string `xml:"annotation>documentation"`
-	Value string `xml:"value,attr"`
+	Doc     string        `xml:"annotation>documentation"`
+	AppInfo []*XSDAppInfo `xml:"annotation>appinfo"`
+	Value   string        `xml:"value,attr"`
 }